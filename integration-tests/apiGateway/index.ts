@@ -107,3 +107,22 @@ const methodSettingsArgs: aws.apigateway.MethodSettingsArgs = {
     },
 }
 const methodSettings = new aws.apigateway.MethodSettings("methodSettings", methodSettingsArgs);
+
+// Protect the stage with a WAF web ACL in the compliant scenario.
+if (testScenario >= 3) {
+    const webAcl = new aws.wafv2.WebAcl("webAcl", {
+        scope: "REGIONAL",
+        defaultAction: {
+            allow: {},
+        },
+        visibilityConfig: {
+            cloudwatchMetricsEnabled: false,
+            metricName: "awsguard-test",
+            sampledRequestsEnabled: false,
+        },
+    });
+    const webAclAssociation = new aws.wafv2.WebAclAssociation("webAclAssociation", {
+        resourceArn: stage.arn,
+        webAclArn: webAcl.arn,
+    });
+}
@@ -46,6 +46,12 @@ const elbBucket = new aws.s3.Bucket("test-bucket", {
     loggings: [{
         targetBucket: "random-bucket",
     }],
+    lifecycleRules: [{
+        enabled: true,
+        expiration: {
+            days: 30,
+        },
+    }],
 });
 
 let elbArgs: aws.elasticloadbalancing.LoadBalancerArgs = {
@@ -22,6 +22,8 @@ const testScenario = config.getNumber("scenario");
 
 let encryptedAtRestParam: aws.types.input.elasticsearch.DomainEncryptAtRest | undefined;
 let vpcOptionsParam: aws.types.input.elasticsearch.DomainVpcOptions | undefined;
+let logPublishingOptionsParam: aws.types.input.elasticsearch.DomainLogPublishingOption[] | undefined;
+let esDomainDependencies: pulumi.Resource[] = [];
 
 console.log(`Running test scenario #${testScenario}`);
 switch (testScenario) {
@@ -55,6 +57,26 @@ switch (testScenario) {
             // You cannot set the VPC ID. Instead you must specify exactly one subnet ID.
             subnetIds: [defaultVpc.publicSubnetIds[0]],
         };
+
+        // Publish audit logs to CloudWatch Logs, which must allow Elasticsearch to write to the log group.
+        const auditLogGroup = new aws.cloudwatch.LogGroup("audit-logs");
+        const auditLogPolicy = new aws.cloudwatch.LogResourcePolicy("audit-logs", {
+            policyName: `awsguard-${pulumi.getStack()}-audit-logs`,
+            policyDocument: auditLogGroup.arn.apply(arn => JSON.stringify({
+                Version: "2012-10-17",
+                Statement: [{
+                    Effect: "Allow",
+                    Principal: { Service: "es.amazonaws.com" },
+                    Action: ["logs:PutLogEvents", "logs:CreateLogStream"],
+                    Resource: `${arn}:*`,
+                }],
+            })),
+        });
+        logPublishingOptionsParam = [{
+            logType: "AUDIT_LOGS",
+            cloudwatchLogGroupArn: auditLogGroup.arn,
+        }];
+        esDomainDependencies = [auditLogPolicy];
         break;
     default:
         throw new Error(`Unexpected test scenario ${testScenario}`);
@@ -69,6 +91,13 @@ const esDomain = new aws.elasticsearch.Domain("not-encrypted-at-rest", {
     // Configure specific parameters based on test scenario.
     encryptAtRest: encryptedAtRestParam,
     vpcOptions: vpcOptionsParam,
+    logPublishingOptions: logPublishingOptionsParam,
+
+    // Only allow TLS 1.2 or later, as required by AWS guard.
+    domainEndpointOptions: {
+        enforceHttps: true,
+        tlsSecurityPolicy: "Policy-Min-TLS-1-2-2019-07",
+    },
 
     // Encryption at rest, which we are verifying is enabled, is only offered
     // on certain machine sizes. (Which is why we are using m4.large and not
@@ -89,6 +118,6 @@ const esDomain = new aws.elasticsearch.Domain("not-encrypted-at-rest", {
     tags: {
         "Source": "testing pulumi-awsguard",
     },
-});
+}, { dependsOn: esDomainDependencies });
 
 exports.esDomainName = esDomain.domainName;
@@ -15,6 +15,8 @@
 package integrationtests
 
 import (
+	"bufio"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -36,11 +38,76 @@ func abortIfFailed(t *testing.T) {
 type policyTestScenario struct {
 	// WantErrors is the error message we expect to see in the command's output.
 	WantErrors []string
+	// WantViolationCount, if set, is the number of violations we expect the policy pack to
+	// report. Violations are counted from the pack's report file, so advisory violations are
+	// included, as are any duplicates. Use violationCount to set it.
+	WantViolationCount *int
+	// Config is additional configuration to set before the scenario's preview, e.g. to exercise
+	// different thresholds or allow-lists with the same program. It is reset afterward, restoring
+	// any value from the initial configuration.
+	Config map[string]string
+}
+
+// violationCount returns a WantViolationCount of n, which may be zero.
+func violationCount(n int) *int {
+	return &n
+}
+
+// violationRecord is a single line of the policy pack's report file.
+type violationRecord struct {
+	PolicyName string `json:"policyName"`
+	URN        string `json:"urn"`
+	Message    string `json:"message"`
+}
+
+// countViolations returns the number of violations in the report file at path. Each line is one
+// reported violation, so a violation reported twice is counted twice. The file not existing means
+// no violations were reported.
+func countViolations(path string) (int, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	count := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record violationRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return 0, fmt.Errorf("parsing violation record %q: %w", scanner.Text(), err)
+		}
+		count++
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// applyScenarioConfig sets the scenario's configuration on the stack, and returns a function that
+// resets it to the baseline: keys in the baseline get their baseline value back, others are removed.
+func applyScenarioConfig(e *ptesting.Environment, config, baseline map[string]string) func() {
+	for k, v := range config {
+		e.RunCommand("pulumi", "config", "set", k, v)
+	}
+	return func() {
+		for k := range config {
+			if v, ok := baseline[k]; ok {
+				e.RunCommand("pulumi", "config", "set", k, v)
+			} else {
+				e.RunCommand("pulumi", "config", "rm", k)
+			}
+		}
+	}
 }
 
 // runPolicyPackIntegrationTest creates a new Pulumi stack and then runs through
 // a sequence of test scenarios where a configuration value is set and then
-// the stack is updated or previewed, confirming the expected result.
+// the stack is updated or previewed, confirming the expected result. Each
+// scenario may also set its own configuration, which is reset afterward.
 func runPolicyPackIntegrationTest(
 	t *testing.T, pulumiProgramDir string,
 	awsGuardSettings awsGuardSettings,
@@ -76,6 +143,10 @@ func runPolicyPackIntegrationTest(
 	e.RunCommand("yarn", "install")
 	abortIfFailed(t)
 
+	// Have the policy pack write each violation it reports to a file, so they can be counted.
+	reportFile := filepath.Join(e.RootPath, "violations.jsonl")
+	e.SetEnvVars(append(e.Env, "AWSGUARD_REPORT_FILE="+reportFile))
+
 	// Initial configuration.
 	for k, v := range initialConfig {
 		e.RunCommand("pulumi", "config", "set", k, v)
@@ -98,6 +169,11 @@ func runPolicyPackIntegrationTest(
 			e.T = t
 
 			e.RunCommand("pulumi", "config", "set", "scenario", fmt.Sprintf("%d", idx+1))
+			restoreConfig := applyScenarioConfig(e, scenario.Config, initialConfig)
+			defer restoreConfig()
+			if err := os.Remove(reportFile); err != nil && !os.IsNotExist(err) {
+				t.Fatalf("Error removing violation report file: %v", err)
+			}
 
 			if len(scenario.WantErrors) == 0 {
 				t.Log("No errors are expected.")
@@ -118,6 +194,14 @@ func runPolicyPackIntegrationTest(
 					t.Logf("Command output:\nSTDOUT:\n%v\n\nSTDERR:\n%v\n\n", stdout, stderr)
 				}
 			}
+
+			if scenario.WantViolationCount != nil {
+				count, err := countViolations(reportFile)
+				if err != nil {
+					t.Fatalf("Error reading violation report file: %v", err)
+				}
+				assert.Equal(t, *scenario.WantViolationCount, count, "unexpected number of violations")
+			}
 		})
 	}
 
@@ -174,7 +258,18 @@ func TestElasticSearch(t *testing.T) {
 func TestComputeEC2(t *testing.T) {
 	runPolicyPackIntegrationTest(
 		t, "compute",
-		awsGuardSettings{},
+		awsGuardSettings{
+			// The scenarios exercise the monitoring, public IP, EBS volume and load balancer logging policies.
+			// These policies check unrelated settings of the instance and its account, so don't apply them.
+			disablePolicies: []string{
+				"ec2Imdsv2Required",
+				"ebsVolumeTypeAllowlist",
+				"ebsGp2Deprecated",
+				"ec2InstanceProfileRequired",
+				"ebsAccountDefaultEncryption",
+				"inspectorEnabled",
+			},
+		},
 		map[string]string{
 			"aws:region": "us-west-2",
 		},
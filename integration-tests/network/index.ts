@@ -24,6 +24,9 @@ const testScenario = config.getNumber("scenario");
 // Construct the ALB and a target group to receive all traffic.
 // We create an HTTPS listener. The HTTP listener is created later,
 // depending on the test scenario.
+//
+// The integration test counts the violations reported in each scenario, so every
+// resource other than the HTTP listener must comply with all AWS guard policies.
 
 const httpPort = 80;
 const httpsPort = 443;
@@ -37,6 +40,12 @@ const accessLogsBucket = new aws.s3.Bucket("accessLogs", {
     loggings: [{
         targetBucket: testBucketName,  // Write access logs into itself.
     }],
+    lifecycleRules: [{
+        enabled: true,
+        expiration: {
+            days: 30,
+        },
+    }],
 });
 
 const alb = new aws.elasticloadbalancingv2.LoadBalancer(
@@ -70,6 +79,8 @@ const httpsListener = new aws.elasticloadbalancingv2.Listener("httpsListener", {
     loadBalancerArn: alb.arn,
     port: httpsPort,
     protocol: "HTTPS",
+    // Only allow TLS 1.2 or later, as required by AWS guard.
+    sslPolicy: "ELBSecurityPolicy-TLS-1-2-2017-01",
     defaultActions: [{
         targetGroupArn: testTargetGroup.arn,
         type: "fixed-response",
@@ -110,6 +121,20 @@ switch (testScenario) {
             },
         ];
         break;
+    case 3:
+    case 4:
+        // The redirect protocol is taken from config, which scenario 3 overrides and scenario 4
+        // relies on being restored to the baseline value ("HTTP").
+        httpListenerDefaultActions = [
+            {
+                type: "redirect",
+                redirect: {
+                    protocol: config.require("redirectProtocol"),
+                    statusCode: "HTTP_301",
+                },
+            },
+        ];
+        break;
     default:
         throw new Error(`Unexpected test scenario ${testScenario}`);
 }
@@ -23,19 +23,40 @@ func TestNetwork(t *testing.T) {
 		t, "network",
 		awsGuardSettings{},
 		map[string]string{
-			"aws:region": "us-west-2",
+			"aws:region":       "us-west-2",
+			"redirectProtocol": "HTTP",
 		},
 		[]policyTestScenario{
-			// Test scenario 1 - ALB Listener is using HTTP and not redirecting to HTTPS.
+			// Test scenario 1 - ALB Listener is using HTTP and not redirecting to HTTPS. That is the
+			// only violation, and it must be reported exactly once. The program's other resources,
+			// such as the access logs bucket and the HTTPS listener, comply with every policy.
 			{
-				[]string{
+				WantErrors: []string{
 					"mandatory",
 					"Default action for HTTP listener must be a redirect using HTTPS.",
 				},
+				WantViolationCount: violationCount(1),
 			},
-			// Test scenario 2 - AOK.
+			// Test scenario 2 - AOK. No policy reports a violation.
 			{
-				WantErrors: nil,
+				WantErrors:         nil,
+				WantViolationCount: violationCount(0),
+			},
+			// Test scenario 3 - the redirect protocol comes from config, which this scenario sets to HTTPS.
+			{
+				Config: map[string]string{
+					"redirectProtocol": "HTTPS",
+				},
+				WantErrors:         nil,
+				WantViolationCount: violationCount(0),
+			},
+			// Test scenario 4 - the redirect protocol is restored to the baseline, HTTP.
+			{
+				WantErrors: []string{
+					"mandatory",
+					"Default action for HTTP listener must be a redirect using HTTPS.",
+				},
+				WantViolationCount: violationCount(1),
 			},
 		})
 }
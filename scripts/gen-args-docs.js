@@ -0,0 +1,185 @@
+// Copyright 2016-2021, Pulumi Corporation.  All rights reserved.
+
+// Generates the doc comments of the AwsGuardArgs properties that each policy module mixes in, from
+// the name, description and config schema of the policy registered under that property, so editors
+// show the policy's documentation when configuring AwsGuard. Policies are the single source of this
+// metadata; run this script after changing one to regenerate the comments:
+//
+//     node scripts/gen-args-docs.js [--check] [src directory]
+//
+// With --check, the files are left unmodified and the script fails if any comments are out of date.
+
+var fs = require("fs");
+var path = require("path");
+
+var args = process.argv.slice(2);
+var check = args.indexOf("--check") !== -1;
+var srcDir = args.filter(function (arg) { return arg !== "--check"; })[0] || path.join(__dirname, "..", "src");
+
+var maxLineLength = 120;
+var indent = "        ";
+
+// Returns the index of the brace that closes the one at `open`, skipping over comments and string literals.
+function matchBrace(text, open) {
+    var depth = 0;
+    for (var i = open; i < text.length; i++) {
+        var c = text[i];
+        if (c === "/" && text[i + 1] === "/") {
+            i = text.indexOf("\n", i);
+        } else if (c === "/" && text[i + 1] === "*") {
+            i = text.indexOf("*/", i) + 1;
+        } else if (c === "\"" || c === "'" || c === "`") {
+            for (i++; i < text.length && text[i] !== c; i++) {
+                if (text[i] === "\\") {
+                    i++;
+                }
+            }
+        } else if (c === "{") {
+            depth++;
+        } else if (c === "}") {
+            depth--;
+            if (depth === 0) {
+                return i;
+            }
+        }
+    }
+    return -1;
+}
+
+// Returns the value of a property that's a double-quoted string, or a concatenation of them.
+function getStringProperty(body, property) {
+    var match = new RegExp("\\n\\s*" + property + ":\\s*((?:\"(?:[^\"\\\\]|\\\\.)*\"\\s*\\+?\\s*)+)").exec(body);
+    if (!match) {
+        return undefined;
+    }
+    var literals = match[1].match(/"(?:[^"\\]|\\.)*"/g);
+    return literals.map(function (literal) { return JSON.parse(literal); }).join("");
+}
+
+// Returns the names of the options in the policy's config schema.
+function getOptions(body) {
+    var match = /\n\s*configSchema:\s*{\s*properties:\s*{/.exec(body);
+    if (!match) {
+        return [];
+    }
+    var open = match.index + match[0].length - 1;
+    var properties = body.substring(open + 1, matchBrace(body, open));
+    var options = [];
+    var depth = 0;
+    var re = /[{}]|(\w+):/g;
+    var token;
+    while ((token = re.exec(properties)) !== null) {
+        if (token[0] === "{") {
+            depth++;
+        } else if (token[0] === "}") {
+            depth--;
+        } else if (depth === 0) {
+            options.push(token[1]);
+        }
+    }
+    return options;
+}
+
+// Returns the metadata of the policies defined in the file, by the variable they're assigned to.
+function getPolicies(text) {
+    var policies = {};
+    var re = /export const (\w+): (?:ResourceValidationPolicy|StackValidationPolicy) = {/g;
+    var match;
+    while ((match = re.exec(text)) !== null) {
+        var open = match.index + match[0].length - 1;
+        var body = text.substring(open, matchBrace(text, open) + 1);
+        policies[match[1]] = {
+            name: getStringProperty(body, "name"),
+            description: getStringProperty(body, "description"),
+            options: getOptions(body),
+        };
+    }
+    return policies;
+}
+
+// Wraps the words onto lines of the doc comment.
+function wrap(words) {
+    var lines = [];
+    var line = "";
+    words.forEach(function (word) {
+        if (line && (indent + " * " + line + " " + word).length > maxLineLength) {
+            lines.push(line);
+            line = word;
+        } else {
+            line = line ? line + " " + word : word;
+        }
+    });
+    if (line) {
+        lines.push(line);
+    }
+    return lines;
+}
+
+function getDocComment(policy) {
+    var lines = wrap(policy.description.split(/\s+/));
+    lines.push("");
+    var usage = "Enforcement level of the `" + policy.name + "` policy";
+    if (policy.options.length > 0) {
+        usage += ", or its enforcement level and options: " + policy.options.map(function (option) {
+            return "`" + option + "`";
+        }).join(", ");
+    }
+    lines = lines.concat(wrap((usage + ".").split(" ")));
+    return [indent + "/**"].concat(lines.map(function (line) {
+        return line ? indent + " * " + line : indent + " *";
+    }), [indent + " */"]).join("\n");
+}
+
+// Returns the file's text with the doc comments of its AwsGuardArgs mixin regenerated.
+function generate(file, text) {
+    var start = text.indexOf("declare module \"./awsGuard\" {");
+    if (start === -1) {
+        return text;
+    }
+    var interfaceStart = text.indexOf("interface AwsGuardArgs {", start);
+    var open = text.indexOf("{", interfaceStart);
+    var close = matchBrace(text, open);
+
+    var policies = getPolicies(text);
+    var registered = {};
+    var re = /registerPolicy\("(\w+)", (\w+)\)/g;
+    var match;
+    while ((match = re.exec(text)) !== null) {
+        registered[match[1]] = policies[match[2]];
+    }
+
+    var members = text.substring(open + 1, close)
+        .replace(/\/\*\*[\s\S]*?\*\//g, "")
+        .split("\n")
+        .map(function (line) { return line.trim(); })
+        .filter(function (line) { return line !== ""; });
+    var body = members.map(function (member) {
+        var property = /^(\w+)\?:/.exec(member);
+        var policy = property && registered[property[1]];
+        if (!policy || !policy.name || !policy.description) {
+            throw new Error(file + ": no registered policy for AwsGuardArgs property '" + member + "'");
+        }
+        return getDocComment(policy) + "\n" + indent + member;
+    }).join("\n\n");
+
+    return text.substring(0, open + 1) + "\n" + body + "\n    " + text.substring(close);
+}
+
+var outOfDate = [];
+fs.readdirSync(srcDir).filter(function (file) { return /\.ts$/.test(file); }).forEach(function (file) {
+    var filePath = path.join(srcDir, file);
+    var text = fs.readFileSync(filePath).toString("utf8");
+    var generated = generate(filePath, text);
+    if (generated !== text) {
+        outOfDate.push(filePath);
+        if (!check) {
+            fs.writeFileSync(filePath, generated);
+        }
+    }
+});
+
+if (check && outOfDate.length > 0) {
+    console.error("error: the AwsGuardArgs doc comments are out of date in " + outOfDate.join(", ") +
+        "; run `node scripts/gen-args-docs.js` to regenerate them");
+    process.exit(1);
+}
@@ -0,0 +1,68 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+
+import { Policy, wrapValidations } from "./dispatch";
+import { EnforcementLevelCallback } from "./enforcementLevel";
+
+/**
+ * The prefix of the tags used to acknowledge a policy's violations for a resource. The tag
+ * `awsguard/acknowledge/<policy name>` downgrades that policy's violations for the resource to
+ * advisory, when acknowledgements are allowed. The tag's value is the reason, which must be given.
+ */
+export const acknowledgeTagPrefix = "awsguard/acknowledge/";
+
+/**
+ * Returns the reason the resource's violations of the policy were acknowledged, if they were.
+ * @internal
+ */
+export function getAcknowledgement(props: Record<string, any>, policyName: string): string | undefined {
+    const tags = props.tags;
+    if (!tags || typeof tags !== "object") {
+        return undefined;
+    }
+    const reason = tags[acknowledgeTagPrefix + policyName];
+    return typeof reason === "string" && reason.trim() !== "" ? reason : undefined;
+}
+
+/**
+ * Returns a callback that makes the policy advisory for resources that acknowledge its violations,
+ * and otherwise defers to `callback`.
+ * @internal
+ */
+export function acknowledgingCallback(
+    policyName: string, callback: EnforcementLevelCallback): EnforcementLevelCallback {
+
+    return resource => getAcknowledgement(resource.props, policyName) !== undefined ? "advisory" : callback(resource);
+}
+
+/**
+ * Returns a copy of the policy that appends the reason to the violations of resources that
+ * acknowledge them, so the reason is recorded wherever the violation is.
+ * @internal
+ */
+export function withAcknowledgementReasons(policy: Policy): Policy {
+    const describe = (message: string, reason: string | undefined) =>
+        reason === undefined ? message : `${message} (acknowledged: ${reason})`;
+
+    return wrapValidations(policy,
+        validation => (args, reportViolation) => validation(args, (message, urn) => {
+            reportViolation(describe(message, getAcknowledgement(args.props, policy.name)), urn);
+        }),
+        validation => (args, reportViolation) => validation(args, (message, urn) => {
+            const resource = urn ? args.resources.find(r => r.urn === urn) : undefined;
+            reportViolation(describe(message, resource && getAcknowledgement(resource.props, policy.name)), urn);
+        }),
+    );
+}
@@ -0,0 +1,176 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import * as aws from "@pulumi/aws";
+
+import { EnforcementLevel, ResourceValidationPolicy, validateResourceOfType } from "@pulumi/policy";
+
+import { registerPolicy } from "./awsGuard";
+import { PolicyArgs } from "./policyArgs";
+
+// Mixin additional properties onto AwsGuardArgs.
+declare module "./awsGuard" {
+    interface AwsGuardArgs {
+        /**
+         * Checks whether AWS Glue security configurations encrypt CloudWatch logs, job bookmarks, and S3 data.
+         *
+         * Enforcement level of the `glue-security-configuration-encryption` policy.
+         */
+        glueSecurityConfigurationEncryption?: EnforcementLevel;
+
+        /**
+         * Checks whether AWS Glue jobs use a security configuration, so their data is encrypted.
+         *
+         * Enforcement level of the `glue-job-security-configuration` policy.
+         */
+        glueJobSecurityConfiguration?: EnforcementLevel;
+
+        /**
+         * Checks whether Amazon MSK clusters encrypt data at rest with a KMS key and only allow TLS between clients and
+         * brokers.
+         *
+         * Enforcement level of the `msk-cluster-encryption` policy.
+         */
+        mskClusterEncryption?: EnforcementLevel;
+
+        /**
+         * Checks whether Kinesis data streams are encrypted with a KMS key.
+         *
+         * Enforcement level of the `kinesis-stream-encryption` policy.
+         */
+        kinesisStreamEncryption?: EnforcementLevel;
+
+        /**
+         * Checks whether Kinesis Data Firehose delivery streams have server-side encryption enabled. Delivery streams
+         * whose source is a Kinesis stream are skipped, since they cannot use server-side encryption.
+         *
+         * Enforcement level of the `firehose-server-side-encryption` policy, or its enforcement level and options:
+         * `suggestSourceStreamEncryption`.
+         */
+        firehoseServerSideEncryption?: EnforcementLevel | (FirehoseServerSideEncryptionArgs & PolicyArgs);
+    }
+}
+
+/** @internal */
+export const glueSecurityConfigurationEncryption: ResourceValidationPolicy = {
+    name: "glue-security-configuration-encryption",
+    description: "Checks whether AWS Glue security configurations encrypt CloudWatch logs, job bookmarks, and S3 data.",
+    validateResource: validateResourceOfType(aws.glue.SecurityConfiguration, (securityConfig, args, reportViolation) => {
+        const encryption = securityConfig.encryptionConfiguration;
+        const disabled: string[] = [];
+        if (!encryption.cloudwatchEncryption || !encryption.cloudwatchEncryption.cloudwatchEncryptionMode ||
+            encryption.cloudwatchEncryption.cloudwatchEncryptionMode === "DISABLED") {
+            disabled.push("CloudWatch");
+        }
+        if (!encryption.jobBookmarksEncryption || !encryption.jobBookmarksEncryption.jobBookmarksEncryptionMode ||
+            encryption.jobBookmarksEncryption.jobBookmarksEncryptionMode === "DISABLED") {
+            disabled.push("job bookmark");
+        }
+        if (!encryption.s3Encryption || !encryption.s3Encryption.s3EncryptionMode ||
+            encryption.s3Encryption.s3EncryptionMode === "DISABLED") {
+            disabled.push("S3");
+        }
+        for (const kind of disabled) {
+            reportViolation(`Glue security configuration '${args.name}' must have ${kind} encryption enabled.`);
+        }
+    }),
+};
+registerPolicy("glueSecurityConfigurationEncryption", glueSecurityConfigurationEncryption);
+
+/** @internal */
+export const glueJobSecurityConfiguration: ResourceValidationPolicy = {
+    name: "glue-job-security-configuration",
+    description: "Checks whether AWS Glue jobs use a security configuration, so their data is encrypted.",
+    enforcementLevel: "advisory",
+    validateResource: validateResourceOfType(aws.glue.Job, (job, args, reportViolation) => {
+        if (!job.securityConfiguration) {
+            reportViolation(`Glue job '${args.name}' should use a security configuration.`);
+        }
+    }),
+};
+registerPolicy("glueJobSecurityConfiguration", glueJobSecurityConfiguration);
+
+/** @internal */
+export const mskClusterEncryption: ResourceValidationPolicy = {
+    name: "msk-cluster-encryption",
+    description: "Checks whether Amazon MSK clusters encrypt data at rest with a KMS key and only allow TLS between clients and brokers.",
+    validateResource: validateResourceOfType(aws.msk.Cluster, (cluster, args, reportViolation) => {
+        const encryptionInfo = cluster.encryptionInfo;
+        if (!encryptionInfo || !encryptionInfo.encryptionAtRestKmsKeyArn) {
+            reportViolation(`MSK cluster '${args.name}' must encrypt data at rest with a KMS key.`);
+        }
+        // Client to broker communication defaults to TLS if unspecified.
+        const clientBroker = encryptionInfo && encryptionInfo.encryptionInTransit && encryptionInfo.encryptionInTransit.clientBroker;
+        if (clientBroker && clientBroker !== "TLS") {
+            reportViolation(`MSK cluster '${args.name}' must use TLS for client to broker encryption in transit. '${clientBroker}' is not allowed.`);
+        }
+    }),
+};
+registerPolicy("mskClusterEncryption", mskClusterEncryption);
+
+/** @internal */
+export const kinesisStreamEncryption: ResourceValidationPolicy = {
+    name: "kinesis-stream-encryption",
+    description: "Checks whether Kinesis data streams are encrypted with a KMS key.",
+    validateResource: validateResourceOfType(aws.kinesis.Stream, (stream, args, reportViolation) => {
+        // Streams are not encrypted by default.
+        if (stream.encryptionType === undefined || stream.encryptionType === "NONE") {
+            reportViolation(`Kinesis stream '${args.name}' must be encrypted.`);
+        } else if (stream.encryptionType === "KMS" && !stream.kmsKeyId) {
+            reportViolation(`Kinesis stream '${args.name}' must specify the KMS key used for encryption.`);
+        }
+    }),
+};
+registerPolicy("kinesisStreamEncryption", kinesisStreamEncryption);
+
+export interface FirehoseServerSideEncryptionArgs {
+    /**
+     * If true, delivery streams whose source is a Kinesis stream report a violation suggesting the
+     * source stream be encrypted instead. Defaults to false.
+     */
+    suggestSourceStreamEncryption?: boolean;
+}
+
+/** @internal */
+export const firehoseServerSideEncryption: ResourceValidationPolicy = {
+    name: "firehose-server-side-encryption",
+    description: "Checks whether Kinesis Data Firehose delivery streams have server-side encryption enabled. " +
+        "Delivery streams whose source is a Kinesis stream are skipped, since they cannot use server-side encryption.",
+    configSchema: {
+        properties: {
+            suggestSourceStreamEncryption: {
+                type: "boolean",
+                default: false,
+            },
+        },
+    },
+    validateResource: validateResourceOfType(aws.kinesis.FirehoseDeliveryStream, (deliveryStream, args, reportViolation) => {
+        const { suggestSourceStreamEncryption } = args.getConfig<FirehoseServerSideEncryptionArgs>();
+
+        // Server-side encryption is not supported when the source is a Kinesis stream. The data
+        // needs to instead be encrypted by the source stream.
+        if (deliveryStream.kinesisSourceConfiguration) {
+            if (suggestSourceStreamEncryption) {
+                reportViolation(`Firehose delivery stream '${args.name}' reads from a Kinesis stream, ` +
+                    "which should be encrypted since server-side encryption is not supported for this source.");
+            }
+            return;
+        }
+
+        if (!deliveryStream.serverSideEncryption || !deliveryStream.serverSideEncryption.enabled) {
+            reportViolation(`Firehose delivery stream '${args.name}' must have server-side encryption enabled.`);
+        }
+    }),
+};
+registerPolicy("firehoseServerSideEncryption", firehoseServerSideEncryption);
@@ -14,17 +14,51 @@
 
 import * as aws from "@pulumi/aws";
 
-import { EnforcementLevel, ResourceValidationPolicy, validateResourceOfType } from "@pulumi/policy";
+import { EnforcementLevel, ResourceValidationPolicy, StackValidationPolicy, validateResourceOfType } from "@pulumi/policy";
 
 import { registerPolicy } from "./awsGuard";
 import { PolicyArgs } from "./policyArgs";
+import { isReferencedBy } from "./util";
 
 // Mixin additional properties onto AwsGuardArgs.
 declare module "./awsGuard" {
     interface AwsGuardArgs {
+        /**
+         * Checks that API Gateway Stages have a cache cluster enabled.
+         *
+         * Enforcement level of the `apigateway-stage-cached` policy.
+         */
         apiGatewayStageCached?: EnforcementLevel;
+
+        /**
+         * Checks API Gateway Methods that responses are configured to be cached and that those cached responses are
+         * encrypted.
+         *
+         * Enforcement level of the `apigateway-method-cached-and-encrypted` policy.
+         */
         apiGatewayMethodCachedAndEncrypted?: EnforcementLevel;
+
+        /**
+         * Checks API Gateway endpoint configuration is one of the allowed types. (By default, only 'EDGE' is allowed.)
+         *
+         * Enforcement level of the `apigateway-endpoint-type` policy, or its enforcement level and options:
+         * `allowEdge`, `allowRegional`, `allowPrivate`.
+         */
         apiGatewayEndpointType?: EnforcementLevel | (ApiGatewayEndpointTypeArgs & PolicyArgs);
+
+        /**
+         * Checks that API Gateway Stages are associated with an AWS WAF web ACL.
+         *
+         * Enforcement level of the `apigateway-waf-associated` policy.
+         */
+        apiGatewayWafAssociated?: EnforcementLevel;
+
+        /**
+         * Checks that the `$connect` routes of API Gateway WebSocket APIs require authorization.
+         *
+         * Enforcement level of the `apigatewayv2-websocket-connect-auth` policy.
+         */
+        apiGatewayV2WebsocketConnectAuth?: EnforcementLevel;
     }
 }
 
@@ -137,3 +171,47 @@ export const apiGatewayEndpointType: ResourceValidationPolicy = {
     }),
 };
 registerPolicy("apiGatewayEndpointType", apiGatewayEndpointType);
+
+/** @internal */
+export const apiGatewayWafAssociated: StackValidationPolicy = {
+    name: "apigateway-waf-associated",
+    description: "Checks that API Gateway Stages are associated with an AWS WAF web ACL.",
+    enforcementLevel: "advisory",
+    validateStack: (args, reportViolation) => {
+        const associations = args.resources.filter(r =>
+            r.isType(aws.wafv2.WebAclAssociation) || r.isType(aws.wafregional.WebAclAssociation));
+
+        for (const stage of args.resources.filter(r => r.isType(aws.apigateway.Stage))) {
+            // Once deployed, a stage's outputs include its web ACL, even if it was associated outside the stack.
+            if (stage.props.webAclArn || associations.some(a => isReferencedBy(stage, a, "resourceArn", ["arn"]))) {
+                continue;
+            }
+            reportViolation(
+                `API Gateway Stage '${stage.props.stageName || stage.name}' must be associated with a WAF web ACL.`, stage.urn);
+        }
+    },
+};
+registerPolicy("apiGatewayWafAssociated", apiGatewayWafAssociated);
+
+/** @internal */
+export const apiGatewayV2WebsocketConnectAuth: StackValidationPolicy = {
+    name: "apigatewayv2-websocket-connect-auth",
+    description: "Checks that the `$connect` routes of API Gateway WebSocket APIs require authorization.",
+    validateStack: (args, reportViolation) => {
+        const apis = args.resources.filter(r => r.isType(aws.apigatewayv2.Api));
+
+        for (const route of args.resources.filter(r => r.isType(aws.apigatewayv2.Route))) {
+            // Clients are authorized once, when they connect, so the other routes of a WebSocket API
+            // are only as protected as its `$connect` route. Routes are unauthorized unless set otherwise.
+            if (route.props.routeKey !== "$connect" || (route.props.authorizationType || "NONE") !== "NONE") {
+                continue;
+            }
+            const api = apis.find(a => isReferencedBy(a, route, "apiId"));
+            const apiName = api ? api.props.name || api.name : route.props.apiId;
+            reportViolation(
+                `API Gateway WebSocket API '${apiName}' must require authorization on its '$connect' route ` +
+                `'${route.name}'. Set 'authorizationType' to "AWS_IAM" or "CUSTOM".`, route.urn);
+        }
+    },
+};
+registerPolicy("apiGatewayV2WebsocketConnectAuth", apiGatewayV2WebsocketConnectAuth);
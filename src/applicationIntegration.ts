@@ -0,0 +1,265 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import * as aws from "@pulumi/aws";
+
+import {
+    EnforcementLevel,
+    PolicyResource,
+    ResourceValidationPolicy,
+    StackValidationPolicy,
+    validateResourceOfType,
+} from "@pulumi/policy";
+
+import { registerPolicy } from "./awsGuard";
+import { namePatternFormat } from "./configSchema";
+import { PolicyArgs } from "./policyArgs";
+import {
+    allowsPublicAccess,
+    describePolicyStatement,
+    getPolicyStatements,
+    hasTag,
+    isReferencedBy,
+    matchesAnyPattern,
+} from "./util";
+
+// Mixin additional properties onto AwsGuardArgs.
+declare module "./awsGuard" {
+    interface AwsGuardArgs {
+        /**
+         * Checks whether AppSync GraphQL APIs have logging configured, and that sensitive APIs do not use API key
+         * authentication.
+         *
+         * Enforcement level of the `appsync-api-logging` policy, or its enforcement level and options:
+         * `sensitiveTagKey`, `sensitiveTagValue`.
+         */
+        appSyncApiLogging?: EnforcementLevel | (AppSyncApiLoggingArgs & PolicyArgs);
+
+        /**
+         * Checks whether Amazon MQ brokers are encrypted with a customer managed KMS key, are not publicly accessible,
+         * and do not use a deprecated engine version.
+         *
+         * Enforcement level of the `mq-broker-encryption` policy, or its enforcement level and options:
+         * `deprecatedEngineVersions`.
+         */
+        mqBrokerEncryption?: EnforcementLevel | (MqBrokerEncryptionArgs & PolicyArgs);
+
+        /**
+         * Checks whether SNS topic access policies allow any principal to subscribe to or publish to the topic without
+         * a condition restricting the source.
+         *
+         * Enforcement level of the `sns-topic-access-policy` policy.
+         */
+        snsTopicAccessPolicy?: EnforcementLevel;
+
+        /**
+         * Checks whether SQS queue access policies allow any principal to access the queue without a condition
+         * restricting the source.
+         *
+         * Enforcement level of the `sqs-queue-access-policy` policy.
+         */
+        sqsQueueAccessPolicy?: EnforcementLevel;
+
+        /**
+         * Checks whether SNS topics and the SQS queues subscribed to them are both encrypted, as encrypting only one
+         * end leaves the messages unencrypted at the other.
+         *
+         * Enforcement level of the `sns-sqs-encryption-consistency` policy.
+         */
+        snsSqsEncryptionConsistency?: EnforcementLevel;
+    }
+}
+
+export interface AppSyncApiLoggingArgs {
+    /** APIs with this tag are considered sensitive, and must not use API key authentication. Defaults to "sensitive". */
+    sensitiveTagKey?: string;
+
+    /** The value the `sensitiveTagKey` tag must have for an API to be considered sensitive. Defaults to "true". */
+    sensitiveTagValue?: string;
+}
+
+/** @internal */
+export const appSyncApiLogging: ResourceValidationPolicy = {
+    name: "appsync-api-logging",
+    description: "Checks whether AppSync GraphQL APIs have logging configured, and that sensitive APIs do not use API key authentication.",
+    configSchema: {
+        properties: {
+            sensitiveTagKey: {
+                type: "string",
+                default: "sensitive",
+            },
+            sensitiveTagValue: {
+                type: "string",
+                default: "true",
+            },
+        },
+    },
+    validateResource: validateResourceOfType(aws.appsync.GraphQLApi, (api, args, reportViolation) => {
+        const { sensitiveTagKey, sensitiveTagValue } = args.getConfig<Required<AppSyncApiLoggingArgs>>();
+
+        if (!api.logConfig) {
+            reportViolation(`AppSync API '${args.name}' must have logging configured.`);
+        }
+        if (sensitiveTagKey && hasTag(api, sensitiveTagKey, sensitiveTagValue) && api.authenticationType === "API_KEY") {
+            reportViolation(`AppSync API '${args.name}' is sensitive and must not use API_KEY authentication.`);
+        }
+    }),
+};
+registerPolicy("appSyncApiLogging", appSyncApiLogging);
+
+export interface MqBrokerEncryptionArgs {
+    /**
+     * Engine versions that brokers must not use, e.g. because they are no longer supported by Amazon MQ.
+     * Each may be a glob or a `/regex/`. Defaults to ActiveMQ 5.15-5.16 and RabbitMQ 3.8-3.10.
+     */
+    deprecatedEngineVersions?: string[];
+}
+
+const defaultDeprecatedMqEngineVersions = ["5.15.*", "5.16.*", "3.8.*", "3.9.*", "3.10.*"];
+
+/** @internal */
+export const mqBrokerEncryption: ResourceValidationPolicy = {
+    name: "mq-broker-encryption",
+    description: "Checks whether Amazon MQ brokers are encrypted with a customer managed KMS key, are not publicly " +
+        "accessible, and do not use a deprecated engine version.",
+    configSchema: {
+        properties: {
+            deprecatedEngineVersions: {
+                type: "array",
+                items: { type: "string", format: namePatternFormat },
+                default: defaultDeprecatedMqEngineVersions,
+            },
+        },
+    },
+    validateResource: validateResourceOfType(aws.mq.Broker, (broker, args, reportViolation) => {
+        const { deprecatedEngineVersions } = args.getConfig<MqBrokerEncryptionArgs>();
+
+        if (!broker.encryptionOptions || !broker.encryptionOptions.kmsKeyId) {
+            reportViolation(`MQ broker '${args.name}' must be encrypted with a customer managed KMS key.`);
+        }
+        if (broker.publiclyAccessible) {
+            reportViolation(`MQ broker '${args.name}' must not be publicly accessible.`);
+        }
+        const engineVersion = broker.engineVersion;
+        const deprecated = deprecatedEngineVersions || defaultDeprecatedMqEngineVersions;
+        if (engineVersion && matchesAnyPattern(engineVersion, deprecated)) {
+            reportViolation(`MQ broker '${args.name}' must not use the deprecated engine version '${engineVersion}'.`);
+        }
+    }),
+};
+registerPolicy("mqBrokerEncryption", mqBrokerEncryption);
+
+// Returns the last segment of an ARN or URL, e.g. the name of the topic or queue it refers to.
+function lastSegment(value: any, separator: string): string | undefined {
+    return typeof value === "string" && value ? value.split(separator).pop() : undefined;
+}
+
+const snsPublicActions = ["sns:Subscribe", "sns:Publish"];
+
+function checkSnsTopicPolicy(topicName: string, document: any, reportViolation: (message: string) => void) {
+    getPolicyStatements(document).forEach((statement, index) => {
+        if (allowsPublicAccess(statement, snsPublicActions)) {
+            reportViolation(
+                `SNS topic '${topicName}' must not allow anyone to subscribe or publish: statement ` +
+                `${describePolicyStatement(statement, index)} allows any principal without a condition restricting the source.`);
+        }
+    });
+}
+
+/** @internal */
+export const snsTopicAccessPolicy: ResourceValidationPolicy = {
+    name: "sns-topic-access-policy",
+    description: "Checks whether SNS topic access policies allow any principal to subscribe to or publish to the topic " +
+        "without a condition restricting the source.",
+    validateResource: [
+        validateResourceOfType(aws.sns.Topic, (topic, args, reportViolation) => {
+            checkSnsTopicPolicy(args.name, topic.policy, reportViolation);
+        }),
+        validateResourceOfType(aws.sns.TopicPolicy, (topicPolicy, args, reportViolation) => {
+            checkSnsTopicPolicy(lastSegment(topicPolicy.arn, ":") || args.name, topicPolicy.policy, reportViolation);
+        }),
+    ],
+};
+registerPolicy("snsTopicAccessPolicy", snsTopicAccessPolicy);
+
+const sqsPublicActions = [
+    "sqs:SendMessage", "sqs:ReceiveMessage", "sqs:DeleteMessage", "sqs:PurgeQueue",
+    "sqs:ChangeMessageVisibility", "sqs:GetQueueAttributes", "sqs:SetQueueAttributes",
+];
+
+function checkSqsQueuePolicy(queueName: string, document: any, reportViolation: (message: string) => void) {
+    getPolicyStatements(document).forEach((statement, index) => {
+        if (allowsPublicAccess(statement, sqsPublicActions)) {
+            reportViolation(
+                `SQS queue '${queueName}' must not allow public access: statement ` +
+                `${describePolicyStatement(statement, index)} allows any principal without a condition restricting the source.`);
+        }
+    });
+}
+
+/** @internal */
+export const sqsQueueAccessPolicy: ResourceValidationPolicy = {
+    name: "sqs-queue-access-policy",
+    description: "Checks whether SQS queue access policies allow any principal to access the queue " +
+        "without a condition restricting the source.",
+    validateResource: [
+        validateResourceOfType(aws.sqs.Queue, (queue, args, reportViolation) => {
+            checkSqsQueuePolicy(args.name, queue.policy, reportViolation);
+        }),
+        validateResourceOfType(aws.sqs.QueuePolicy, (queuePolicy, args, reportViolation) => {
+            checkSqsQueuePolicy(lastSegment(queuePolicy.queueUrl, "/") || args.name, queuePolicy.policy, reportViolation);
+        }),
+    ],
+};
+registerPolicy("sqsQueueAccessPolicy", sqsQueueAccessPolicy);
+
+function isQueueEncrypted(queue: PolicyResource): boolean {
+    // New queues are encrypted with SQS-managed keys unless that's explicitly disabled.
+    return !!queue.props.kmsMasterKeyId || queue.props.sqsManagedSseEnabled !== false;
+}
+
+/** @internal */
+export const snsSqsEncryptionConsistency: StackValidationPolicy = {
+    name: "sns-sqs-encryption-consistency",
+    description: "Checks whether SNS topics and the SQS queues subscribed to them are both encrypted, as encrypting " +
+        "only one end leaves the messages unencrypted at the other.",
+    enforcementLevel: "advisory",
+    validateStack: (args, reportViolation) => {
+        const topics = args.resources.filter(r => r.isType(aws.sns.Topic));
+        const queues = args.resources.filter(r => r.isType(aws.sqs.Queue));
+
+        for (const subscription of args.resources.filter(r => r.isType(aws.sns.TopicSubscription))) {
+            if (subscription.props.protocol !== "sqs") {
+                continue;
+            }
+            const topic = topics.find(t => isReferencedBy(t, subscription, "topic", ["arn", "id"]));
+            const queue = queues.find(q => isReferencedBy(q, subscription, "endpoint", ["arn"]));
+            if (!topic || !queue) {
+                continue;
+            }
+
+            const unencrypted = [
+                ...(!topic.props.kmsMasterKeyId ? [`topic '${topic.name}'`] : []),
+                ...(!isQueueEncrypted(queue) ? [`queue '${queue.name}'`] : []),
+            ];
+            if (unencrypted.length > 0) {
+                reportViolation(
+                    `SNS topic '${topic.name}' delivers messages to SQS queue '${queue.name}', but ` +
+                    `${unencrypted.join(" and ")} ${unencrypted.length > 1 ? "are" : "is"} not encrypted. ` +
+                    "Both should be encrypted so that messages are protected from end to end.", subscription.urn);
+            }
+        }
+    },
+};
+registerPolicy("snsSqsEncryptionConsistency", snsSqsEncryptionConsistency);
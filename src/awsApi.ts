@@ -0,0 +1,146 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/**
+ * How policies that call the AWS API behave when the API can't be reached, e.g. the call times
+ * out, the instance metadata service is unreachable or the call is still throttled after retrying:
+ * - "fail": The error is raised, failing the preview or update.
+ * - "warn": A warning is logged and the check that needed the call is skipped.
+ * - "skip": The check that needed the call is silently skipped.
+ *
+ * Any other error, e.g. a denied request, is always raised, so that a policy doesn't silently stop
+ * checking anything.
+ */
+export type ApiErrorBehavior = "fail" | "warn" | "skip";
+
+/** @internal */
+export interface AwsApiOptions {
+    /** How to handle failed calls. Defaults to "fail". */
+    onApiError: ApiErrorBehavior;
+    /** How long to wait for a call before treating it as failed. Defaults to 30 seconds. */
+    timeoutSeconds: number;
+    /** How many times to retry a call that failed with a transient error. Defaults to 2. */
+    maxRetries: number;
+    /** How long to wait before the first retry, doubling for each subsequent retry. Defaults to 200 milliseconds. */
+    retryBaseDelayMs: number;
+}
+
+const defaultAwsApiOptions: AwsApiOptions = {
+    onApiError: "fail",
+    timeoutSeconds: 30,
+    maxRetries: 2,
+    retryBaseDelayMs: 200,
+};
+
+let awsApiOptions: AwsApiOptions = { ...defaultAwsApiOptions };
+
+/**
+ * Sets the options used by all AWS API calls made by policies. Unspecified options use their defaults.
+ * @internal
+ */
+export function configureAwsApi(options: Partial<AwsApiOptions>): void {
+    awsApiOptions = {
+        onApiError: options.onApiError || defaultAwsApiOptions.onApiError,
+        timeoutSeconds: options.timeoutSeconds !== undefined ? options.timeoutSeconds : defaultAwsApiOptions.timeoutSeconds,
+        maxRetries: options.maxRetries !== undefined ? options.maxRetries : defaultAwsApiOptions.maxRetries,
+        retryBaseDelayMs: options.retryBaseDelayMs !== undefined
+            ? options.retryBaseDelayMs : defaultAwsApiOptions.retryBaseDelayMs,
+    };
+}
+
+// Error codes from the AWS SDK that indicate the AWS API or credentials couldn't be reached,
+// rather than the request itself failing.
+const apiUnavailableErrorCodes = [
+    "CredentialsError", "NetworkingError", "TimeoutError", "UnknownEndpoint",
+    "ECONNREFUSED", "ECONNRESET", "EHOSTDOWN", "EHOSTUNREACH", "ENETUNREACH", "ENOTFOUND", "ETIMEDOUT",
+];
+
+/**
+ * Returns true if the error indicates the AWS API or credentials are unavailable, e.g. when the
+ * instance metadata service can't be reached.
+ * @internal
+ */
+export function isApiUnavailableError(err: any): boolean {
+    return err !== undefined && err !== null && apiUnavailableErrorCodes.includes(err.code);
+}
+
+// Error codes from the AWS SDK that indicate the request was throttled and may succeed if retried.
+const throttlingErrorCodes = ["Throttling", "ThrottlingException", "TooManyRequestsException", "RequestLimitExceeded"];
+
+// Returns true if the error is transient, i.e. the call may succeed if retried.
+function isRetryableError(err: any): boolean {
+    return isApiUnavailableError(err) ||
+        (err !== undefined && err !== null && (err.retryable === true || throttlingErrorCodes.includes(err.code)));
+}
+
+function sleep(ms: number): Promise<void> {
+    return new Promise(resolve => setTimeout(resolve, ms));
+}
+
+async function callWithTimeout<T>(call: () => Promise<T>, timeoutSeconds: number): Promise<T> {
+    let timer: NodeJS.Timeout | undefined;
+    const timeout = new Promise<never>((_, reject) => {
+        timer = setTimeout(() => {
+            const err: any = new Error(`AWS API call did not complete within ${timeoutSeconds} seconds`);
+            err.code = "TimeoutError";
+            reject(err);
+        }, timeoutSeconds * 1000);
+    });
+
+    try {
+        return await Promise.race([call(), timeout]);
+    } finally {
+        if (timer) {
+            clearTimeout(timer);
+        }
+    }
+}
+
+/**
+ * Calls the AWS API with the configured timeout, retrying transient failures with exponential
+ * backoff. If the call still fails with a transient error, the configured `onApiError` behavior
+ * determines whether the error is raised, or `undefined` is returned so the caller can skip the
+ * check. Other errors are always raised. `behavior` overrides the configured behavior for this call.
+ * @internal
+ */
+export async function callAwsApi<T>(
+    policyName: string, call: () => Promise<T>, behavior?: ApiErrorBehavior): Promise<T | undefined> {
+
+    const { onApiError, timeoutSeconds, maxRetries, retryBaseDelayMs } = awsApiOptions;
+    try {
+        for (let attempt = 0; ; attempt++) {
+            try {
+                return await callWithTimeout(call, timeoutSeconds);
+            } catch (err) {
+                if (attempt >= maxRetries || !isRetryableError(err)) {
+                    throw err;
+                }
+                await sleep(retryBaseDelayMs * Math.pow(2, attempt));
+            }
+        }
+    } catch (err) {
+        if (!isRetryableError(err)) {
+            throw err;
+        }
+        switch (behavior || onApiError) {
+            case "skip":
+                return undefined;
+            case "warn":
+                console.warn(`warning: skipping ${policyName}, the AWS API call failed: ${err.message}`);
+                return undefined;
+            default:
+                throw err;
+        }
+    }
+}
@@ -21,7 +21,28 @@ import {
     StackValidationPolicy,
 } from "@pulumi/policy";
 
-import { defaultEnforcementLevel } from "./enforcementLevel";
+import { acknowledgingCallback, withAcknowledgementReasons } from "./acknowledge";
+import { ApiErrorBehavior, configureAwsApi } from "./awsApi";
+import { configFileEnvVar, loadConfigFile, mergeArgs } from "./configFile";
+import { validatePolicyConfig } from "./configSchema";
+import { getControlIds } from "./controls";
+import { isResourceValidationPolicy, Policy } from "./dispatch";
+import {
+    defaultEnforcementLevel,
+    EnforcementLevelCallback,
+    isEnforcementLevel,
+    splitByEnforcementLevel,
+} from "./enforcementLevel";
+import { explainEnvVar, withExplanations } from "./explain";
+import { withFailFast } from "./failFast";
+import { withResourceUrns } from "./messages";
+import { getPolicyAvailability, withRegionAvailability } from "./region";
+import { reportFileEnvVar, withViolationRecords } from "./report";
+import { TagSelector, withTagScope } from "./scope";
+import { getSeverity, SeverityEnforcement } from "./severity";
+import { createTimingRecorder, logTimingSummary, timingEnvVar, withTiming } from "./timing";
+import { UnknownValueBehavior, withUnknownValueHandling } from "./unknown";
+import { version } from "./version";
 
 const defaultPolicyPackName = "pulumi-awsguard";
 
@@ -77,7 +98,8 @@ const registeredPolicies: Record<string, ResourceValidationPolicy | StackValidat
  * });
  * ```
  *
- * To specify configuration for policies that have it:
+ * To specify configuration for policies that have it (the configuration is checked against each
+ * policy's schema when the pack is constructed, so e.g. a misspelled property throws an error):
  *
  * ```typescript
  * const awsGuard = new AwsGuard({
@@ -87,21 +109,192 @@ const registeredPolicies: Record<string, ResourceValidationPolicy | StackValidat
  *     acmCertificateExpiration: { maxDaysUntilExpiration: 10 },
  * });
  * ```
+ *
+ * Configuration that lists names, e.g. the instances exempt from a policy, accepts exact names,
+ * globs where `*` matches any sequence of characters, and regular expressions between slashes. A
+ * backslash makes the character after it literal:
+ *
+ * ```typescript
+ * const awsGuard = new AwsGuard({
+ *     ec2NoKeyPair: { allowedInstanceNames: ["bastion", "jump-*", "/^break-glass-\\d+$/"] },
+ * });
+ * ```
+ *
+ * To load configuration from a JSON or YAML file, e.g. one shared by many policy packs, use
+ * `configFile` or set the `AWSGUARD_CONFIG_FILE` environment variable. Policies may be referred to
+ * by their property name or policy name in the file, and inline configuration takes precedence:
+ *
+ * ```typescript
+ * const awsGuard = new AwsGuard({ configFile: "awsguard.yaml", ec2InstanceNoPublicIP: "advisory" });
+ * ```
+ *
+ * Policies that call the AWS API fail the preview if the API is unreachable. To instead skip those
+ * checks with a warning:
+ *
+ * ```typescript
+ * const awsGuard = new AwsGuard({ onApiError: "warn", apiTimeoutSeconds: 10 });
+ * ```
+ *
+ * Transient failures, e.g. throttling, are retried with exponential backoff before giving up. This
+ * can be tuned with `apiMaxRetries` and `apiRetryBaseDelayMs`.
+ *
+ * Values computed from other resources may not be known during a preview, in which case the
+ * policies that check them can't run. To skip those checks, log a warning, or report a violation
+ * instead of Pulumi's advisory note, set `onUnknown`:
+ *
+ * ```typescript
+ * const awsGuard = new AwsGuard({ all: "mandatory", onUnknown: "warn" });
+ * ```
+ *
+ * To also write each violation as a line of JSON to a file, for consumption by other tools, set the
+ * `AWSGUARD_REPORT_FILE` environment variable to the path of the file.
+ *
+ * To find out which policies are slow on a large stack, set the `AWSGUARD_TIMING` environment
+ * variable. The pack then logs how long each policy took and how many resources it checked, once
+ * the stack has been analyzed.
+ *
+ * To understand why a resource was or wasn't flagged, set the `AWSGUARD_EXPLAIN` environment
+ * variable. The pack then logs which policies checked each resource, and whether they passed.
+ *
+ * To only enforce the pack on resources with a particular tag:
+ *
+ * ```typescript
+ * const awsGuard = new AwsGuard({
+ *     all: "mandatory",
+ *     onlyResourcesWithTag: { key: "Environment", value: "production" },
+ * });
+ * ```
+ *
+ * To manage enforcement levels by how serious each policy's violations are, rather than per policy,
+ * map each severity to an enforcement level. A policy's own enforcement level takes precedence over
+ * its severity's, which takes precedence over `all`:
+ *
+ * ```typescript
+ * const awsGuard = new AwsGuard({
+ *     severityEnforcement: { critical: "mandatory", high: "mandatory", medium: "advisory", low: "disabled" },
+ *     ec2VolumeInUse: "mandatory",
+ * });
+ * ```
+ *
+ * To determine a policy's enforcement level per resource, provide a callback keyed by the policy's
+ * name. The policy then reports each violation as mandatory or advisory according to the callback,
+ * taking precedence over both `all` and the policy's own enforcement level, unless the policy is
+ * disabled. Advisory violations are reported under the policy's name with an "-advisory" suffix:
+ *
+ * ```typescript
+ * const awsGuard = new AwsGuard({
+ *     enforcementLevelCallbacks: {
+ *         "encrypted-volumes": resource =>
+ *             resource.props.tags && resource.props.tags.environment === "production" ? "mandatory" : "advisory",
+ *     },
+ * });
+ * ```
+ *
+ * To run every advisory policy as mandatory, e.g. in CI, set `strict`. Conversely, to run every
+ * mandatory policy as advisory, e.g. for a dry-run audit, set `relaxed`:
+ *
+ * ```typescript
+ * const awsGuard = new AwsGuard({ all: "mandatory", relaxed: process.env.AUDIT === "true" });
+ * ```
+ *
+ * To let resources acknowledge a policy's violations, e.g. while an exception is being remediated,
+ * set `allowAcknowledgements`. A resource tagged `awsguard/acknowledge/<policy name>` then reports
+ * that policy's violations as advisory, with the tag's value, the reason, included in the message:
+ *
+ * ```typescript
+ * const awsGuard = new AwsGuard({ all: "mandatory", allowAcknowledgements: true });
+ *
+ * // In the program:
+ * const bucket = new aws.s3.Bucket("legacy", {
+ *     tags: { "awsguard/acknowledge/s3-bucket-logging-enabled": "Decommissioning in Q3, see OPS-123" },
+ * });
+ * ```
+ *
+ * To stop checking a resource once it has a mandatory violation, e.g. in CI where any mandatory
+ * violation fails the preview anyway, set `stopOnFirstViolation`:
+ *
+ * ```typescript
+ * const awsGuard = new AwsGuard({ all: "mandatory", stopOnFirstViolation: true });
+ * ```
+ *
+ * Policies that check services or settings that aren't available in every AWS partition, e.g. in
+ * GovCloud, skip resources in the partitions and regions where they don't apply, and log that they did.
+ *
+ * Violation messages end with the URN of the violating resource, when known, so that resources
+ * with the same name in different parts of a stack can be told apart.
+ *
+ * To help auditors map violations to compliance frameworks, set `includeControlIds`. Violations of
+ * policies that map to CIS AWS Foundations Benchmark or PCI DSS controls then list them, e.g.
+ * "[Controls: CIS 2.3.1, PCI 3.4]":
+ *
+ * ```typescript
+ * const awsGuard = new AwsGuard({ all: "mandatory", includeControlIds: true });
+ * ```
+ *
+ * To log the pack's version and how many policies run at each enforcement level, once when the
+ * pack starts, set `reportVersion`:
+ *
+ * ```typescript
+ * const awsGuard = new AwsGuard({ all: "mandatory", reportVersion: true });
+ * ```
  */
 export class AwsGuard extends PolicyPack {
     constructor(args?: AwsGuardArgs);
     constructor(name: string, args?: AwsGuardArgs);
     constructor(nameOrArgs?: string | AwsGuardArgs, args?: AwsGuardArgs) {
-        const [n, a] = getNameAndArgs(nameOrArgs, args);
+        const [n, inlineArgs] = getNameAndArgs(nameOrArgs, args);
+        const configFile = (inlineArgs && inlineArgs.configFile) || process.env[configFileEnvVar];
+        const a = configFile ? mergeArgs(loadConfigFile(configFile, registeredPolicies), inlineArgs) : inlineArgs;
+
+        configureAwsApi({
+            onApiError: a && a.onApiError,
+            timeoutSeconds: a && a.apiTimeoutSeconds,
+            maxRetries: a && a.apiMaxRetries,
+            retryBaseDelayMs: a && a.apiRetryBaseDelayMs,
+        });
+
+        const initialConfig = getInitialConfig(registeredPolicies, a);
+        const reportFile = process.env[reportFileEnvVar];
+        const explain = !!process.env[explainEnvVar];
+
+        const failedUrns = a && a.stopOnFirstViolation ? new Set<string>() : undefined;
 
         const policies: Policies = [];
         for (const key of Object.keys(registeredPolicies)) {
-            policies.push(registeredPolicies[key]);
+            const availability = getPolicyAvailability(registeredPolicies[key].name);
+            const controlIds = a && a.includeControlIds ? getControlIds(registeredPolicies[key].name) : [];
+            for (let policy of applyEnforcementLevelCallback(registeredPolicies[key], a, initialConfig)) {
+                // Explain the policy's own outcome, so that resources skipped by the wrappers below aren't
+                // reported as being of a type the policy doesn't check.
+                if (explain) {
+                    policy = withExplanations(policy);
+                }
+                if (availability) {
+                    policy = withRegionAvailability(policy, availability);
+                }
+                if (a && (a.onlyResourcesWithTag || a.excludeResourcesWithTag)) {
+                    policy = withTagScope(policy, a.onlyResourcesWithTag, a.excludeResourcesWithTag);
+                }
+                if (a && a.onUnknown) {
+                    policy = withUnknownValueHandling(policy, a.onUnknown);
+                }
+                if (reportFile) {
+                    policy = withViolationRecords(policy, getEnforcementLevel(policy, initialConfig), reportFile);
+                }
+                if (failedUrns) {
+                    policy = withFailFast(policy, getEnforcementLevel(policy, initialConfig), failedUrns);
+                }
+                policies.push(withResourceUrns(policy, controlIds));
+            }
         }
 
-        const initialConfig = getInitialConfig(registeredPolicies, a);
+        if (a && a.reportVersion) {
+            console.error(getVersionSummary(version, policies, initialConfig));
+        }
+
+        const timedPolicies = process.env[timingEnvVar] ? withTimings(policies, initialConfig) : policies;
 
-        super(n, { policies, enforcementLevel: defaultEnforcementLevel }, initialConfig);
+        super(n, { policies: timedPolicies, enforcementLevel: defaultEnforcementLevel }, initialConfig);
     }
 }
 
@@ -110,16 +303,127 @@ export class AwsGuard extends PolicyPack {
  */
 export interface AwsGuardArgs {
     all?: EnforcementLevel;
+
+    /**
+     * How policies that call the AWS API behave when the API can't be reached, e.g. when a call
+     * times out or the instance metadata service is unreachable. Other errors, such as denied
+     * requests, always fail. Defaults to "fail".
+     */
+    onApiError?: ApiErrorBehavior;
+
+    /** How long policies wait for an AWS API call before treating it as failed. Defaults to 30 seconds. */
+    apiTimeoutSeconds?: number;
+
+    /**
+     * How many times policies retry an AWS API call that failed with a transient error, such as a
+     * throttled request or a briefly unreachable endpoint. Defaults to 2.
+     */
+    apiMaxRetries?: number;
+
+    /**
+     * How long to wait before the first retry of an AWS API call, in milliseconds. The delay doubles
+     * for each subsequent retry. Defaults to 200.
+     */
+    apiRetryBaseDelayMs?: number;
+
+    /**
+     * How policies behave when a value they check isn't known yet during a preview. If unset,
+     * Pulumi reports an advisory note that the policy can't be run during the preview.
+     */
+    onUnknown?: UnknownValueBehavior;
+
+    /**
+     * If set, policies only check resources with this tag, e.g. `{ key: "Environment", value: "production" }`.
+     * Resources that don't support tags are not checked.
+     */
+    onlyResourcesWithTag?: TagSelector;
+
+    /** If set, policies don't check resources with this tag. */
+    excludeResourcesWithTag?: TagSelector;
+
+    /**
+     * The enforcement level to run policies with according to their severity, e.g.
+     * `{ critical: "mandatory", high: "mandatory", medium: "advisory", low: "disabled" }`. This takes
+     * precedence over `all`, but a policy's own enforcement level, when given, takes precedence over this.
+     */
+    severityEnforcement?: SeverityEnforcement;
+
+    /**
+     * Callbacks, keyed by policy name (e.g. "encrypted-volumes"), that determine the policy's
+     * enforcement level for each resource it checks.
+     */
+    enforcementLevelCallbacks?: Record<string, EnforcementLevelCallback>;
+
+    /**
+     * If true, every advisory policy runs as mandatory, including policies configured as advisory,
+     * e.g. for CI pipelines that must not ship any violations. Disabled policies stay disabled.
+     * Can't be combined with `relaxed`. Defaults to false.
+     */
+    strict?: boolean;
+
+    /**
+     * If true, every mandatory policy runs as advisory, including policies configured as mandatory,
+     * e.g. for dry-run audits that report violations without failing. Disabled policies stay disabled.
+     * Can't be combined with `strict`. Defaults to false.
+     */
+    relaxed?: boolean;
+
+    /**
+     * If true, resources may acknowledge a policy's violations with an `awsguard/acknowledge/<policy name>`
+     * tag whose value is the reason. Their violations of that policy are then advisory, and include
+     * the reason. Defaults to false.
+     */
+    allowAcknowledgements?: boolean;
+
+    /**
+     * If true, once a resource has a mandatory violation the remaining policies skip it, and stack
+     * policies are skipped, to speed up previews of large stacks where any mandatory violation fails
+     * the preview anyway. Only the first violations found are reported. Defaults to false.
+     */
+    stopOnFirstViolation?: boolean;
+
+    /**
+     * If true, a single line with the AwsGuard version and a summary of the policies' enforcement
+     * levels is logged when the pack starts, to help with triaging issues. Defaults to false.
+     */
+    reportVersion?: boolean;
+
+    /**
+     * The path of a JSON or YAML file to load configuration from, in the same form as these args.
+     * Defaults to the `AWSGUARD_CONFIG_FILE` environment variable, if set. Args given inline take
+     * precedence over the file.
+     */
+    configFile?: string;
+
+    /**
+     * If true, violation messages list the compliance control IDs the policy maps to, e.g.
+     * "[Controls: CIS 2.3.1, PCI 3.4]", to help auditors map findings to frameworks. Defaults to false.
+     */
+    includeControlIds?: boolean;
+
     // Note: Properties to configure each policy are added to this interface (mixins) by each module.
 }
 
+// AwsGuardArgs properties that configure AwsGuard itself, rather than an individual policy.
+type ReservedArgs =
+    "all" | "onApiError" | "apiTimeoutSeconds" | "apiMaxRetries" | "apiRetryBaseDelayMs" | "onUnknown" |
+    "onlyResourcesWithTag" | "excludeResourcesWithTag" | "reportVersion" | "severityEnforcement" | "strict" |
+    "relaxed" | "enforcementLevelCallbacks" | "allowAcknowledgements" | "stopOnFirstViolation" | "configFile" |
+    "includeControlIds";
+const reservedArgs: string[] = [
+    "all", "onApiError", "apiTimeoutSeconds", "apiMaxRetries", "apiRetryBaseDelayMs", "onUnknown",
+    "onlyResourcesWithTag", "excludeResourcesWithTag", "reportVersion", "severityEnforcement", "strict",
+    "relaxed", "enforcementLevelCallbacks", "allowAcknowledgements", "stopOnFirstViolation", "configFile",
+    "includeControlIds",
+];
+
 /** @internal */
 export function registerPolicy<K extends keyof AwsGuardArgs>(
-    property: Exclude<K, "all">,
+    property: Exclude<K, ReservedArgs>,
     policy: ResourceValidationPolicy | StackValidationPolicy): void {
 
-    if (property === "all") {
-        throw new Error("'all' is reserved.");
+    if (reservedArgs.includes(property)) {
+        throw new Error(`'${property}' is reserved.`);
     }
     if (property in registeredPolicies) {
         throw new Error(`${property} already exists.`);
@@ -170,7 +474,12 @@ export function getInitialConfig(
 
         // If "all", just add it to the resulting object.
         if (key === "all") {
-            result["all"] = val;
+            result["all"] = <EnforcementLevel>val;
+            continue;
+        }
+
+        // Skip any other properties that configure AwsGuard itself.
+        if (reservedArgs.includes(key)) {
             continue;
         }
 
@@ -178,8 +487,132 @@ export function getInitialConfig(
         // the resulting object.
         const policy = policyMap[key];
         if (policy) {
-            result[policy.name] = val;
+            validatePolicyConfig(policy, val);
+            result[policy.name] = <any>val;
+        }
+    }
+
+    // Apply the enforcement level for each policy's severity, unless the policy was given its own.
+    const severityEnforcement = args.severityEnforcement;
+    if (severityEnforcement) {
+        for (const key of Object.keys(policyMap)) {
+            const name = policyMap[key].name;
+            const level = severityEnforcement[getSeverity(name)];
+            const policyConfig = result[name];
+            if (!level) {
+                continue;
+            }
+            if (policyConfig === undefined) {
+                result[name] = level;
+            } else if (typeof policyConfig === "object" && policyConfig.enforcementLevel === undefined) {
+                result[name] = { ...policyConfig, enforcementLevel: level };
+            }
+        }
+    }
+
+    // Promote or demote every policy's final enforcement level.
+    if (args.strict && args.relaxed) {
+        throw new Error("'strict' and 'relaxed' can't both be set.");
+    }
+    if (args.strict || args.relaxed) {
+        for (const key of Object.keys(policyMap)) {
+            const policy = policyMap[key];
+            const level = getEnforcementLevel(policy, result);
+            const adjusted = adjustEnforcementLevel(level, args);
+            const policyConfig = result[policy.name];
+            if (adjusted === level) {
+                continue;
+            }
+            result[policy.name] = policyConfig && typeof policyConfig === "object"
+                ? { ...policyConfig, enforcementLevel: adjusted }
+                : adjusted;
         }
     }
     return result;
 }
+
+/**
+ * Returns the enforcement level the policy will run with given the pack's initial config: the
+ * policy's own config takes precedence over "all", which takes precedence over the policy's default.
+ * @internal
+ */
+export function getEnforcementLevel(policy: Policy, config?: PolicyPackConfig): EnforcementLevel {
+    const policyConfig: any = config ? config[policy.name] : undefined;
+    if (isEnforcementLevel(policyConfig)) {
+        return policyConfig;
+    }
+    if (policyConfig && isEnforcementLevel(policyConfig.enforcementLevel)) {
+        return policyConfig.enforcementLevel;
+    }
+    const all: any = config ? config["all"] : undefined;
+    if (isEnforcementLevel(all)) {
+        return all;
+    }
+    return policy.enforcementLevel || defaultEnforcementLevel;
+}
+
+/**
+ * Returns the policies to run in place of the given policy. If the policy has an enforcement level
+ * callback and isn't disabled, it's split into mandatory and advisory policies, and the config is
+ * updated so each runs at its level with the original policy's configuration.
+ * @internal
+ */
+export function applyEnforcementLevelCallback(
+    policy: Policy, args: AwsGuardArgs | undefined, config: PolicyPackConfig | undefined): Policy[] {
+
+    const userCallback = args && args.enforcementLevelCallbacks ? args.enforcementLevelCallbacks[policy.name] : undefined;
+    const level = getEnforcementLevel(policy, config);
+    // Acknowledgements only need the policy to be split if they can downgrade its violations.
+    const acknowledge = !!(args && args.allowAcknowledgements) &&
+        (level === "mandatory" || userCallback !== undefined);
+    if ((!userCallback && !acknowledge) || level === "disabled" || !config) {
+        return [policy];
+    }
+
+    let callback: EnforcementLevelCallback = userCallback
+        ? resource => adjustEnforcementLevel(userCallback(resource), args || {})
+        : () => level;
+    if (acknowledge) {
+        policy = withAcknowledgementReasons(policy);
+        callback = acknowledgingCallback(policy.name, callback);
+    }
+
+    const policyConfig: any = config[policy.name];
+    const properties = policyConfig && typeof policyConfig === "object" ? policyConfig : {};
+    const variants = splitByEnforcementLevel(policy, callback, level);
+    for (const variant of variants) {
+        config[variant.name] = { ...properties, enforcementLevel: variant.enforcementLevel };
+    }
+    return variants;
+}
+
+/**
+ * Returns a one-line summary of the pack's version and the enforcement levels its policies will
+ * run with, e.g. "awsguard v0.3.0: 40 mandatory, 12 advisory, 3 disabled (all: mandatory)".
+ * @internal
+ */
+export function getVersionSummary(packVersion: string, policies: Policy[], config?: PolicyPackConfig): string {
+    const counts: Record<EnforcementLevel, number> = { mandatory: 0, advisory: 0, disabled: 0 };
+    for (const policy of policies) {
+        counts[getEnforcementLevel(policy, config)]++;
+    }
+    const all = config && isEnforcementLevel(config["all"]) ? ` (all: ${config["all"]})` : "";
+    return `awsguard v${packVersion}: ${counts.mandatory} mandatory, ${counts.advisory} advisory, ` +
+        `${counts.disabled} disabled${all}`;
+}
+
+/**
+ * Returns copies of the policies that record their timings, logging a summary once every stack
+ * policy that runs has finished, i.e. once the stack has been analyzed. If no stack policies run,
+ * the summary is logged when the policy pack's process is about to exit instead.
+ * @internal
+ */
+export function withTimings(policies: Policy[], config?: PolicyPackConfig): Policy[] {
+    const stackValidations = policies.filter(policy =>
+        !isResourceValidationPolicy(policy) && getEnforcementLevel(policy, config) !== "disabled").length;
+    const recorder = createTimingRecorder(stackValidations);
+    if (stackValidations === 0) {
+        process.once("beforeExit", () => logTimingSummary(recorder));
+    }
+    return policies.map(policy => withTiming(policy, recorder));
+}
@@ -12,29 +12,290 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+import * as AWS from "aws-sdk";
+
 import * as aws from "@pulumi/aws";
 
-import { EnforcementLevel, ResourceValidationPolicy, validateResourceOfType } from "@pulumi/policy";
+import {
+    EnforcementLevel,
+    PolicyResource,
+    ResourceValidationArgs,
+    ResourceValidationPolicy,
+    StackValidationPolicy,
+    validateResourceOfType,
+} from "@pulumi/policy";
 
+import { callAwsApi } from "./awsApi";
 import { registerPolicy } from "./awsGuard";
+import { namePatternFormat } from "./configSchema";
+import { groupedByResource } from "./messages";
 import { PolicyArgs } from "./policyArgs";
+import {
+    allowsPublicAccess,
+    describePolicyStatement,
+    getPolicyStatements,
+    hasTag,
+    isReferencedBy,
+    isReferencedByNested,
+    matchesAnyPattern,
+} from "./util";
+
+// Retrieving the aws region
+const awsConfigRegion = aws.config.region;
 
 // Mixin additional properties onto AwsGuardArgs.
 declare module "./awsGuard" {
     interface AwsGuardArgs {
-        ec2InstanceDetailedMonitoringEnabled?: EnforcementLevel;
+        /**
+         * Checks whether detailed monitoring is enabled for EC2 instances. Optionally, only instances with a tag or
+         * particular names are checked.
+         *
+         * Enforcement level of the `ec2-instance-detailed-monitoring-enabled` policy, or its enforcement level and
+         * options: `scopeTagKey`, `scopeTagValue`, `includeInstanceNames`, `excludeInstanceNames`.
+         */
+        ec2InstanceDetailedMonitoringEnabled?: EnforcementLevel | (Ec2InstanceDetailedMonitoringEnabledArgs & PolicyArgs);
+
+        /**
+         * Checks whether Amazon EC2 instances have a public IP association. This rule applies only to IPv4.
+         *
+         * Enforcement level of the `ec2-instance-no-public-ip` policy.
+         */
         ec2InstanceNoPublicIP?: EnforcementLevel;
+
+        /**
+         * Checks whether EBS volumes are attached to EC2 instances. Optionally checks if EBS volumes are marked for
+         * deletion when an instance is terminated.
+         *
+         * Enforcement level of the `ec2-volume-inuse` policy, or its enforcement level and options: `checkDeletion`.
+         */
         ec2VolumeInUse?: EnforcementLevel | (Ec2VolumeInUseArgs & PolicyArgs);
+
+        /**
+         * Checks whether Classic, Application, and Network Load Balancers have logging enabled. Gateway Load Balancers
+         * don't support access logs and are skipped.
+         *
+         * Enforcement level of the `elb-logging-enabled` policy.
+         */
         elbAccessLoggingEnabled?: EnforcementLevel;
+
+        /**
+         * Checks whether the EBS volumes that are in an attached state are encrypted. If you specify the ID of a KMS
+         * key for encryption using the kmsId parameter, the rule checks if the EBS volumes in an attached state are
+         * encrypted with that KMS key.
+         *
+         * Enforcement level of the `encrypted-volumes` policy, or its enforcement level and options: `kmsId`.
+         */
         encryptedVolumes?: EnforcementLevel | (EncryptedVolumesArgs & PolicyArgs);
+
+        /**
+         * Checks whether EC2 instances and launch templates use AMIs from approved owners or with approved names.
+         *
+         * Enforcement level of the `ec2-approved-ami-owner` policy, or its enforcement level and options:
+         * `approvedAmiIds`, `approvedOwners`, `approvedNamePatterns`.
+         */
+        ec2ApprovedAmiOwner?: EnforcementLevel | (Ec2ApprovedAmiOwnerArgs & PolicyArgs);
+
+        /**
+         * Checks whether EC2 instances use an instance profile whose role has the AdministratorAccess policy attached.
+         *
+         * Enforcement level of the `ec2-instance-profile-least-privilege` policy.
+         */
+        ec2InstanceProfileLeastPrivilege?: EnforcementLevel;
+
+        /**
+         * Checks whether EBS volumes and EC2 instance block devices use an allowed volume type, to discourage legacy
+         * volume types such as gp2 and standard.
+         *
+         * Enforcement level of the `ebs-volume-type-allowlist` policy, or its enforcement level and options:
+         * `allowedTypes`.
+         */
+        ebsVolumeTypeAllowlist?: EnforcementLevel | (EbsVolumeTypeAllowlistArgs & PolicyArgs);
+
+        /**
+         * Checks whether the root and user volumes of Amazon WorkSpaces are encrypted.
+         *
+         * Enforcement level of the `workspaces-volume-encryption` policy.
+         */
+        workspacesVolumeEncryption?: EnforcementLevel;
+
+        /**
+         * Checks whether AWS Batch compute environments launch instances into public subnets or assign them public IP
+         * addresses.
+         *
+         * Enforcement level of the `batch-no-public-ip` policy.
+         */
+        batchNoPublicIp?: EnforcementLevel;
+
+        /**
+         * Checks whether EC2 launch templates propagate required tags to the instances and volumes they launch. Tags
+         * set on the template itself don't reach launched resources without tag specifications.
+         *
+         * Enforcement level of the `ec2-required-tags-on-launch-template` policy, or its enforcement level and options:
+         * `requiredTags`, `resourceTypes`.
+         */
+        ec2RequiredTagsOnLaunchTemplate?: EnforcementLevel | (Ec2RequiredTagsOnLaunchTemplateArgs & PolicyArgs);
+
+        /**
+         * Checks whether Lambda functions have reserved concurrency set, so that a single function can't exhaust the
+         * account's concurrency.
+         *
+         * Enforcement level of the `lambda-reserved-concurrency` policy, or its enforcement level and options:
+         * `includeFunctionNames`, `excludeFunctionNames`.
+         */
+        lambdaReservedConcurrency?: EnforcementLevel | (LambdaReservedConcurrencyArgs & PolicyArgs);
+
+        /**
+         * Checks whether EC2 instances and network interfaces have source/destination checking disabled. Only instances
+         * that route traffic, such as NAT instances, need it disabled.
+         *
+         * Enforcement level of the `ec2-source-dest-check` policy, or its enforcement level and options:
+         * `allowedNames`.
+         */
+        ec2SourceDestCheck?: EnforcementLevel | (Ec2SourceDestCheckArgs & PolicyArgs);
+
+        /**
+         * Checks whether EC2 instances, and the launch templates and launch configurations that Auto Scaling groups
+         * launch instances from, require the instance metadata service version 2 (IMDSv2).
+         *
+         * Enforcement level of the `ec2-imdsv2-required` policy.
+         */
+        ec2Imdsv2Required?: EnforcementLevel;
+
+        /**
+         * Checks whether EKS node groups run in public subnets, i.e. subnets that assign public IP addresses to the
+         * instances launched into them.
+         *
+         * Enforcement level of the `eks-nodegroup-private-subnets` policy.
+         */
+        eksNodegroupPrivateSubnets?: EnforcementLevel;
+
+        /**
+         * Checks whether EC2 instances are launched with an SSH key pair. Session Manager provides shell access without
+         * long-lived keys or open SSH ports.
+         *
+         * Enforcement level of the `ec2-no-key-pair` policy, or its enforcement level and options:
+         * `allowedInstanceNames`.
+         */
+        ec2NoKeyPair?: EnforcementLevel | (Ec2NoKeyPairArgs & PolicyArgs);
+
+        /**
+         * Checks whether EC2 instances with many inline settings use a launch template instead, so that instances are
+         * configured consistently.
+         *
+         * Enforcement level of the `ec2-prefer-launch-template` policy, or its enforcement level and options:
+         * `maxInlineSettings`, `allowedInstanceNames`.
+         */
+        ec2PreferLaunchTemplate?: EnforcementLevel | (Ec2PreferLaunchTemplateArgs & PolicyArgs);
+
+        /**
+         * Checks whether EBS volumes and EC2 instance block devices use the gp2 volume type, recommending gp3, which
+         * costs less and performs better.
+         *
+         * Enforcement level of the `ebs-gp2-deprecated` policy, or its enforcement level and options: `messageSuffix`.
+         */
+        ebsGp2Deprecated?: EnforcementLevel | (EbsGp2DeprecatedArgs & PolicyArgs);
+
+        /**
+         * Checks whether ECR repository policies allow any principal to pull or push images without a condition
+         * restricting access to particular accounts or organizations.
+         *
+         * Enforcement level of the `ecr-repository-no-public-access` policy.
+         */
+        ecrRepositoryNoPublicAccess?: EnforcementLevel;
+
+        /**
+         * Checks whether ECS clusters have CloudWatch Container Insights enabled.
+         *
+         * Enforcement level of the `ecs-cluster-container-insights` policy.
+         */
+        ecsClusterContainerInsights?: EnforcementLevel;
+
+        /**
+         * Checks whether EC2 instances have an IAM instance profile, so that applications on the instance use the
+         * role's temporary credentials rather than static access keys.
+         *
+         * Enforcement level of the `ec2-instance-profile-required` policy, or its enforcement level and options:
+         * `allowedInstanceNames`.
+         */
+        ec2InstanceProfileRequired?: EnforcementLevel | (Ec2InstanceProfileRequiredArgs & PolicyArgs);
+
+        /**
+         * Checks whether EC2 instances use a previous-generation instance type. Current-generation types usually cost
+         * less and perform better.
+         *
+         * Enforcement level of the `ec2-deprecated-instance-generation` policy, or its enforcement level and options:
+         * `deprecatedFamilies`.
+         */
+        ec2DeprecatedInstanceGeneration?: EnforcementLevel | (Ec2DeprecatedInstanceGenerationArgs & PolicyArgs);
+
+        /**
+         * Checks whether EC2 instances with hibernation enabled have an encrypted root volume. Hibernation writes the
+         * instance's memory to the root volume, and AWS requires it to be encrypted.
+         *
+         * Enforcement level of the `ec2-hibernation-requires-encryption` policy.
+         */
+        ec2HibernationRequiresEncryption?: EnforcementLevel;
+
+        /**
+         * Checks whether stacks that create EBS volumes or EC2 instances also enable EBS encryption by default for the
+         * account, so that volumes are encrypted even if they aren't configured to be.
+         *
+         * Enforcement level of the `ebs-account-default-encryption` policy.
+         */
+        ebsAccountDefaultEncryption?: EnforcementLevel;
     }
 }
 
+export interface Ec2InstanceDetailedMonitoringEnabledArgs {
+    /** If set, only instances with this tag are checked. */
+    scopeTagKey?: string;
+
+    /** If set along with `scopeTagKey`, the tag must also have this value. */
+    scopeTagValue?: string;
+
+    /** If non-empty, only instances with these resource names (or name patterns) are checked. */
+    includeInstanceNames?: string[];
+
+    /** Instances with these resource names (or name patterns) are not checked. */
+    excludeInstanceNames?: string[];
+}
+
 /** @internal */
 export const ec2InstanceDetailedMonitoringEnabled: ResourceValidationPolicy = {
     name: "ec2-instance-detailed-monitoring-enabled",
-    description: "Checks whether detailed monitoring is enabled for EC2 instances.",
-    validateResource: validateResourceOfType(aws.ec2.Instance, (instance, _, reportViolation) => {
+    description: "Checks whether detailed monitoring is enabled for EC2 instances. " +
+        "Optionally, only instances with a tag or particular names are checked.",
+    configSchema: {
+        properties: {
+            scopeTagKey: { type: "string" },
+            scopeTagValue: { type: "string" },
+            includeInstanceNames: {
+                type: "array",
+                items: { type: "string", format: namePatternFormat },
+                default: [],
+            },
+            excludeInstanceNames: {
+                type: "array",
+                items: { type: "string", format: namePatternFormat },
+                default: [],
+            },
+        },
+    },
+    validateResource: validateResourceOfType(aws.ec2.Instance, (instance, args, reportViolation) => {
+        const { scopeTagKey, scopeTagValue, includeInstanceNames, excludeInstanceNames } =
+            args.getConfig<Ec2InstanceDetailedMonitoringEnabledArgs>();
+
+        if (scopeTagKey && !hasTag(args.props, scopeTagKey, scopeTagValue)) {
+            return;
+        }
+        if (includeInstanceNames && includeInstanceNames.length > 0 &&
+            !matchesAnyPattern(args.name, includeInstanceNames)) {
+            return;
+        }
+        if (matchesAnyPattern(args.name, excludeInstanceNames)) {
+            return;
+        }
+
         if (!instance.monitoring) {
             reportViolation("EC2 instances must have detailed monitoring enabled.");
         }
@@ -89,26 +350,37 @@ export const ec2VolumeInUse: ResourceValidationPolicy = {
 };
 registerPolicy("ec2VolumeInUse", ec2VolumeInUse);
 
+// Checks the access logs of an Application, Network, or Gateway Load Balancer. Gateway Load Balancers
+// don't support access logs, so they are skipped.
+function checkLoadBalancerAccessLogs(
+    loadBalancer: { loadBalancerType?: string, accessLogs?: { enabled?: boolean } },
+    args: ResourceValidationArgs,
+    reportViolation: (message: string) => void) {
+
+    const type = loadBalancer.loadBalancerType || "application";
+    if (type === "gateway") {
+        return;
+    }
+    if (loadBalancer.accessLogs === undefined || !loadBalancer.accessLogs.enabled) {
+        reportViolation(`Load balancer '${args.name}' of type '${type}' must have access logs enabled.`);
+    }
+}
+
 /** @internal */
 export const elbAccessLoggingEnabled: ResourceValidationPolicy = {
     name: "elb-logging-enabled",
-    description: "Checks whether the Application Load Balancers and the Classic Load Balancers have logging enabled.",
+    description: "Checks whether Classic, Application, and Network Load Balancers have logging enabled. " +
+        "Gateway Load Balancers don't support access logs and are skipped.",
     validateResource: [
         validateResourceOfType(aws.elasticloadbalancing.LoadBalancer, (loadBalancer, args, reportViolation) => {
             if (loadBalancer.accessLogs === undefined || !loadBalancer.accessLogs.enabled) {
                 reportViolation("Elastic Load Balancer must have access logs enabled.");
             }
         }),
-        validateResourceOfType(aws.elasticloadbalancingv2.LoadBalancer, (loadBalancer, args, reportViolation) => {
-            if (loadBalancer.accessLogs === undefined || !loadBalancer.accessLogs.enabled) {
-                reportViolation("Elastic Load Balancer must have access logs enabled.");
-            }
-        }),
-        validateResourceOfType(aws.applicationloadbalancing.LoadBalancer, (loadBalancer, args, reportViolation) => {
-            if (loadBalancer.accessLogs === undefined || !loadBalancer.accessLogs.enabled) {
-                reportViolation("Application Load Balancer must have access logs enabled.");
-            }
-        }),
+        validateResourceOfType(aws.elasticloadbalancingv2.LoadBalancer, checkLoadBalancerAccessLogs),
+        validateResourceOfType(aws.applicationloadbalancing.LoadBalancer, checkLoadBalancerAccessLogs),
+        validateResourceOfType(aws.lb.LoadBalancer, checkLoadBalancerAccessLogs),
+        validateResourceOfType(aws.alb.LoadBalancer, checkLoadBalancerAccessLogs),
     ],
 };
 registerPolicy("elbAccessLoggingEnabled", elbAccessLoggingEnabled);
@@ -158,3 +430,798 @@ export const encryptedVolumes: ResourceValidationPolicy = {
     }),
 };
 registerPolicy("encryptedVolumes", encryptedVolumes);
+
+export interface Ec2ApprovedAmiOwnerArgs {
+    /** AMI IDs that are always approved, e.g. the IDs returned by `aws.ec2.getAmi` for approved images. */
+    approvedAmiIds?: string[];
+
+    /** AWS account IDs (or aliases such as "amazon") of approved AMI owners. */
+    approvedOwners?: string[];
+
+    /** Approved AMI name patterns, e.g. `amzn2-ami-hvm-*` or `/^ubuntu\/images\/.*-22\.04-/`. */
+    approvedNamePatterns?: string[];
+}
+
+// Error codes DescribeImages fails with when an AMI can't be described, e.g. because it's private,
+// deregistered or in another region.
+const invalidAmiErrorCodes = ["InvalidAMIID.NotFound", "InvalidAMIID.Malformed", "InvalidAMIID.Unavailable"];
+
+// Describes the given AMIs, omitting those that can't be described. DescribeImages fails the whole
+// request if any of the AMIs can't be described, so then each AMI is described on its own.
+async function describeImages(ec2: AWS.EC2, imageIds: string[]): Promise<AWS.EC2.Image[]> {
+    try {
+        const resp = await ec2.describeImages({ ImageIds: imageIds }).promise();
+        return resp.Images || [];
+    } catch (err) {
+        if (!invalidAmiErrorCodes.includes(err.code)) {
+            throw err;
+        }
+        if (imageIds.length === 1) {
+            return [];
+        }
+    }
+    const images = await Promise.all(imageIds.map(imageId => describeImages(ec2, [imageId])));
+    return ([] as AWS.EC2.Image[]).concat(...images);
+}
+
+/** @internal */
+export const ec2ApprovedAmiOwner: StackValidationPolicy = {
+    name: "ec2-approved-ami-owner",
+    description: "Checks whether EC2 instances and launch templates use AMIs from approved owners or with approved names.",
+    enforcementLevel: "advisory",
+    configSchema: {
+        properties: {
+            approvedAmiIds: {
+                type: "array",
+                items: { type: "string" },
+                default: [],
+            },
+            approvedOwners: {
+                type: "array",
+                items: { type: "string" },
+                default: [],
+            },
+            approvedNamePatterns: {
+                type: "array",
+                items: { type: "string", format: namePatternFormat },
+                default: [],
+            },
+        },
+    },
+    validateStack: async (args, reportViolation) => {
+        const { approvedAmiIds, approvedOwners, approvedNamePatterns } = args.getConfig<Required<Ec2ApprovedAmiOwnerArgs>>();
+        const checkSource = (approvedOwners || []).length > 0 || (approvedNamePatterns || []).length > 0;
+        if ((approvedAmiIds || []).length === 0 && !checkSource) {
+            // Nothing has been approved, so there's nothing to check against.
+            return;
+        }
+
+        // Collect the AMIs referenced by the stack. AMIs that are unknown during a preview are skipped.
+        const references: { kind: string, name: string, urn: string, ami: string }[] = [];
+        for (const resource of args.resources) {
+            if (resource.isType(aws.ec2.Instance) && resource.props.ami) {
+                references.push({ kind: "EC2 instance", name: resource.name, urn: resource.urn, ami: resource.props.ami });
+            } else if (resource.isType(aws.ec2.LaunchTemplate) && resource.props.imageId) {
+                references.push({ kind: "EC2 launch template", name: resource.name, urn: resource.urn, ami: resource.props.imageId });
+            }
+        }
+
+        const unapproved = references.filter(ref => !(approvedAmiIds || []).includes(ref.ami));
+        if (unapproved.length === 0) {
+            return;
+        }
+
+        // Use the AWS SDK to look up the owner and name of the remaining AMIs.
+        const images: Record<string, AWS.EC2.Image> = {};
+        if (checkSource) {
+            const ec2 = new AWS.EC2({ region: awsConfigRegion });
+            const imageIds = Array.from(new Set(unapproved.map(ref => ref.ami)));
+            const described = await callAwsApi("ec2-approved-ami-owner", () => describeImages(ec2, imageIds));
+            if (!described) {
+                return;
+            }
+            for (const image of described) {
+                if (image.ImageId) {
+                    images[image.ImageId] = image;
+                }
+            }
+        }
+
+        for (const ref of unapproved) {
+            const image = images[ref.ami];
+            if (checkSource && image === undefined) {
+                reportViolation(`${ref.kind} '${ref.name}' references AMI '${ref.ami}', which could not be found, so ` +
+                    "its source could not be checked. It may be private, deregistered or in another region.",
+                    ref.urn);
+                continue;
+            }
+            const approved = image !== undefined && (
+                (approvedOwners || []).some(owner => owner === image.OwnerId || owner === image.ImageOwnerAlias) ||
+                matchesAnyPattern([image.Name], approvedNamePatterns));
+            if (!approved) {
+                reportViolation(`${ref.kind} '${ref.name}' references AMI '${ref.ami}', which is not from an approved source.`, ref.urn);
+            }
+        }
+    },
+};
+registerPolicy("ec2ApprovedAmiOwner", ec2ApprovedAmiOwner);
+
+// Matches the ARN of the AWS managed AdministratorAccess policy, in any partition.
+const administratorAccessArnRE = /^arn:aws[a-z-]*:iam::aws:policy\/AdministratorAccess$/;
+
+/** @internal */
+export const ec2InstanceProfileLeastPrivilege: StackValidationPolicy = {
+    name: "ec2-instance-profile-least-privilege",
+    description: "Checks whether EC2 instances use an instance profile whose role has the AdministratorAccess policy attached.",
+    enforcementLevel: "advisory",
+    validateStack: (args, reportViolation) => {
+        const profiles = args.resources.filter(r => r.isType(aws.iam.InstanceProfile));
+        const roles = args.resources.filter(r => r.isType(aws.iam.Role));
+        const attachments = args.resources.filter(r => r.isType(aws.iam.RolePolicyAttachment));
+        const policyAttachments = args.resources.filter(r => r.isType(aws.iam.PolicyAttachment));
+
+        const isAdministratorAccess = (arn: string | undefined) =>
+            arn !== undefined && administratorAccessArnRE.test(arn);
+        const isAdministrator = (role: PolicyResource) =>
+            (role.props.managedPolicyArns || []).some(isAdministratorAccess) ||
+            attachments.some(a => isAdministratorAccess(a.props.policyArn) &&
+                isReferencedBy(role, a, "role", ["id", "name"])) ||
+            policyAttachments.some(a => isAdministratorAccess(a.props.policyArn) &&
+                (((a.propertyDependencies || {})["roles"] || []).some(dep => dep.urn === role.urn) ||
+                    (a.props.roles || []).includes(role.props.name)));
+
+        for (const instance of args.resources.filter(r => r.isType(aws.ec2.Instance))) {
+            for (const profile of profiles.filter(p => isReferencedBy(p, instance, "iamInstanceProfile", ["id", "name"]))) {
+                for (const role of roles.filter(r => isReferencedBy(r, profile, "role", ["id", "name"]))) {
+                    if (isAdministrator(role)) {
+                        reportViolation(
+                            `EC2 instance '${instance.name}' uses an instance profile with role '${role.name}', ` +
+                            "which has the AdministratorAccess policy attached.", instance.urn);
+                    }
+                }
+            }
+        }
+    },
+};
+registerPolicy("ec2InstanceProfileLeastPrivilege", ec2InstanceProfileLeastPrivilege);
+
+export interface EbsVolumeTypeAllowlistArgs {
+    /** The EBS volume types that may be used. Defaults to "gp3" and "io2". */
+    allowedTypes?: string[];
+}
+
+const defaultAllowedVolumeTypes = ["gp3", "io2"];
+
+// EBS volumes and block devices are gp2 unless a type is specified.
+const defaultVolumeType = "gp2";
+
+/** @internal */
+export const ebsVolumeTypeAllowlist: ResourceValidationPolicy = {
+    name: "ebs-volume-type-allowlist",
+    description: "Checks whether EBS volumes and EC2 instance block devices use an allowed volume type, " +
+        "to discourage legacy volume types such as gp2 and standard.",
+    configSchema: {
+        properties: {
+            allowedTypes: {
+                type: "array",
+                items: { type: "string" },
+                default: defaultAllowedVolumeTypes,
+            },
+        },
+    },
+    validateResource: [
+        validateResourceOfType(aws.ebs.Volume, (volume, args, reportViolation) => {
+            const { allowedTypes } = args.getConfig<EbsVolumeTypeAllowlistArgs>();
+            const type = volume.type || defaultVolumeType;
+            if (!(allowedTypes || defaultAllowedVolumeTypes).includes(type)) {
+                reportViolation(`EBS volume '${args.name}' has type '${type}', which is not an allowed volume type.`);
+            }
+        }),
+        validateResourceOfType(aws.ec2.Instance, (instance, args, reportViolation) => {
+            const { allowedTypes } = args.getConfig<EbsVolumeTypeAllowlistArgs>();
+            const devices: { deviceName: string, volumeType?: string }[] = [
+                ...(instance.rootBlockDevice ? [{ deviceName: "root", volumeType: instance.rootBlockDevice.volumeType }] : []),
+                ...(instance.ebsBlockDevices || []),
+            ];
+            for (const { deviceName, volumeType } of devices) {
+                const type = volumeType || defaultVolumeType;
+                if (!(allowedTypes || defaultAllowedVolumeTypes).includes(type)) {
+                    reportViolation(
+                        `EC2 instance '${args.name}' block device '${deviceName}' has type '${type}', ` +
+                        "which is not an allowed volume type.");
+                }
+            }
+        }),
+    ],
+};
+registerPolicy("ebsVolumeTypeAllowlist", ebsVolumeTypeAllowlist);
+
+/** @internal */
+export const workspacesVolumeEncryption: ResourceValidationPolicy = {
+    name: "workspaces-volume-encryption",
+    description: "Checks whether the root and user volumes of Amazon WorkSpaces are encrypted.",
+    validateResource: validateResourceOfType(aws.workspaces.Workspace, (workspace, args, reportViolation) => {
+        // Volumes are unencrypted unless encryption is explicitly enabled.
+        if (!workspace.rootVolumeEncryptionEnabled) {
+            reportViolation(`WorkSpace '${args.name}' must have its root volume encrypted.`);
+        }
+        if (!workspace.userVolumeEncryptionEnabled) {
+            reportViolation(`WorkSpace '${args.name}' must have its user volume encrypted.`);
+        }
+    }),
+};
+registerPolicy("workspacesVolumeEncryption", workspacesVolumeEncryption);
+
+/** @internal */
+export const batchNoPublicIp: StackValidationPolicy = {
+    name: "batch-no-public-ip",
+    description: "Checks whether AWS Batch compute environments launch instances into public subnets " +
+        "or assign them public IP addresses.",
+    validateStack: (args, reportViolation) => {
+        const subnets = args.resources.filter(r => r.isType(aws.ec2.Subnet));
+        const launchTemplates = args.resources.filter(r => r.isType(aws.ec2.LaunchTemplate));
+
+        for (const environment of args.resources.filter(r => r.isType(aws.batch.ComputeEnvironment))) {
+            const computeResources = environment.props.computeResources;
+            if (!computeResources) {
+                continue;
+            }
+
+            for (const subnet of subnets) {
+                if (subnet.props.mapPublicIpOnLaunch &&
+                    isReferencedByNested(subnet, environment, "computeResources", computeResources.subnets, ["id"])) {
+                    reportViolation(
+                        `Batch compute environment '${environment.name}' must not launch instances into public ` +
+                        `subnet '${subnet.name}'.`, environment.urn);
+                }
+            }
+
+            const launchTemplate = computeResources.launchTemplate;
+            if (!launchTemplate) {
+                continue;
+            }
+            for (const template of launchTemplates) {
+                const referenced = launchTemplate.launchTemplateId
+                    ? isReferencedByNested(template, environment, "computeResources", launchTemplate.launchTemplateId, ["id"])
+                    : isReferencedByNested(template, environment, "computeResources", launchTemplate.launchTemplateName, ["name"]);
+                const assignsPublicIp = (template.props.networkInterfaces || []).some((ni: any) =>
+                    ni.associatePublicIpAddress === true || ni.associatePublicIpAddress === "true");
+                if (referenced && assignsPublicIp) {
+                    reportViolation(
+                        `Batch compute environment '${environment.name}' must not assign public IP addresses to instances, ` +
+                        `but launch template '${template.name}' does.`, environment.urn);
+                }
+            }
+        }
+    },
+};
+registerPolicy("batchNoPublicIp", batchNoPublicIp);
+
+export interface Ec2RequiredTagsOnLaunchTemplateArgs {
+    /** Tag keys that must be propagated to the resources launched from a template. */
+    requiredTags?: string[];
+
+    /** The types of launched resources that must be tagged. Defaults to "instance" and "volume". */
+    resourceTypes?: string[];
+}
+
+/** @internal */
+export const ec2RequiredTagsOnLaunchTemplate: ResourceValidationPolicy = {
+    name: "ec2-required-tags-on-launch-template",
+    description: "Checks whether EC2 launch templates propagate required tags to the instances and volumes they launch. " +
+        "Tags set on the template itself don't reach launched resources without tag specifications.",
+    enforcementLevel: "advisory",
+    configSchema: {
+        properties: {
+            requiredTags: {
+                type: "array",
+                items: { type: "string" },
+                default: [],
+            },
+            resourceTypes: {
+                type: "array",
+                items: { type: "string" },
+                default: ["instance", "volume"],
+            },
+        },
+    },
+    validateResource: validateResourceOfType(aws.ec2.LaunchTemplate, (launchTemplate, args, reportViolation) => {
+        const { requiredTags, resourceTypes } = args.getConfig<Ec2RequiredTagsOnLaunchTemplateArgs>();
+
+        const specifications = launchTemplate.tagSpecifications || [];
+        const untagged = (resourceTypes || ["instance", "volume"]).filter(resourceType => {
+            const tags = specifications
+                .filter(spec => spec.resourceType === resourceType)
+                .reduce((all, spec) => ({ ...all, ...spec.tags }), <Record<string, string>>{});
+            return specifications.every(spec => spec.resourceType !== resourceType) ||
+                (requiredTags || []).some(key => !(key in tags));
+        });
+
+        if (untagged.length > 0) {
+            reportViolation(
+                `EC2 launch template '${args.name}' must have tag specifications with the required tags ` +
+                `for resource type(s): ${untagged.join(", ")}.`);
+        }
+    }),
+};
+registerPolicy("ec2RequiredTagsOnLaunchTemplate", ec2RequiredTagsOnLaunchTemplate);
+
+export interface LambdaReservedConcurrencyArgs {
+    /** If non-empty, only functions with these resource names (or name patterns) are checked. */
+    includeFunctionNames?: string[];
+
+    /** Functions with these resource names (or name patterns) are not checked. */
+    excludeFunctionNames?: string[];
+}
+
+/** @internal */
+export const lambdaReservedConcurrency: ResourceValidationPolicy = {
+    name: "lambda-reserved-concurrency",
+    description: "Checks whether Lambda functions have reserved concurrency set, so that a single function " +
+        "can't exhaust the account's concurrency.",
+    enforcementLevel: "advisory",
+    configSchema: {
+        properties: {
+            includeFunctionNames: {
+                type: "array",
+                items: { type: "string", format: namePatternFormat },
+                default: [],
+            },
+            excludeFunctionNames: {
+                type: "array",
+                items: { type: "string", format: namePatternFormat },
+                default: [],
+            },
+        },
+    },
+    validateResource: validateResourceOfType(aws.lambda.Function, (lambdaFunction, args, reportViolation) => {
+        const { includeFunctionNames, excludeFunctionNames } = args.getConfig<LambdaReservedConcurrencyArgs>();
+
+        if (includeFunctionNames && includeFunctionNames.length > 0 &&
+            !matchesAnyPattern(args.name, includeFunctionNames)) {
+            return;
+        }
+        if (matchesAnyPattern(args.name, excludeFunctionNames)) {
+            return;
+        }
+
+        // -1, the default, means the function has no reserved concurrency.
+        const reserved = lambdaFunction.reservedConcurrentExecutions;
+        if (reserved === undefined || reserved === -1) {
+            reportViolation(`Lambda function '${args.name}' should have reserved concurrency set.`);
+        }
+    }),
+};
+registerPolicy("lambdaReservedConcurrency", lambdaReservedConcurrency);
+
+export interface Ec2SourceDestCheckArgs {
+    /**
+     * Resource names of instances and network interfaces that may disable source/destination
+     * checking, e.g. NAT instances. Globs such as `nat-*` and `/regex/` patterns are supported.
+     */
+    allowedNames?: string[];
+}
+
+/** @internal */
+export const ec2SourceDestCheck: ResourceValidationPolicy = {
+    name: "ec2-source-dest-check",
+    description: "Checks whether EC2 instances and network interfaces have source/destination checking disabled. " +
+        "Only instances that route traffic, such as NAT instances, need it disabled.",
+    enforcementLevel: "advisory",
+    configSchema: {
+        properties: {
+            allowedNames: {
+                type: "array",
+                items: { type: "string", format: namePatternFormat },
+                default: [],
+            },
+        },
+    },
+    validateResource: [
+        validateResourceOfType(aws.ec2.Instance, (instance, args, reportViolation) => {
+            checkSourceDestCheck("EC2 instance", instance.sourceDestCheck, args, reportViolation);
+        }),
+        validateResourceOfType(aws.ec2.NetworkInterface, (networkInterface, args, reportViolation) => {
+            checkSourceDestCheck("Network interface", networkInterface.sourceDestCheck, args, reportViolation);
+        }),
+    ],
+};
+registerPolicy("ec2SourceDestCheck", ec2SourceDestCheck);
+
+function checkSourceDestCheck(
+    kind: string,
+    sourceDestCheck: boolean | undefined,
+    args: ResourceValidationArgs,
+    reportViolation: (message: string) => void) {
+
+    // Source/destination checking is enabled unless explicitly disabled.
+    if (sourceDestCheck !== false) {
+        return;
+    }
+    const { allowedNames } = args.getConfig<Ec2SourceDestCheckArgs>();
+    if (matchesAnyPattern(args.name, allowedNames)) {
+        return;
+    }
+    reportViolation(`${kind} '${args.name}' should not disable source/destination checking unless it routes traffic.`);
+}
+
+/** @internal */
+export const ec2Imdsv2Required: ResourceValidationPolicy = {
+    name: "ec2-imdsv2-required",
+    description: "Checks whether EC2 instances, and the launch templates and launch configurations that " +
+        "Auto Scaling groups launch instances from, require the instance metadata service version 2 (IMDSv2).",
+    validateResource: [
+        validateResourceOfType(aws.ec2.Instance, (instance, args, reportViolation) => {
+            checkImdsv2Required("EC2 instance", instance.metadataOptions, args, reportViolation);
+        }),
+        validateResourceOfType(aws.ec2.LaunchTemplate, (launchTemplate, args, reportViolation) => {
+            checkImdsv2Required("Launch template", launchTemplate.metadataOptions, args, reportViolation);
+        }),
+        validateResourceOfType(aws.ec2.LaunchConfiguration, (launchConfiguration, args, reportViolation) => {
+            checkImdsv2Required("Launch configuration", launchConfiguration.metadataOptions, args, reportViolation);
+        }),
+    ],
+};
+registerPolicy("ec2Imdsv2Required", ec2Imdsv2Required);
+
+function checkImdsv2Required(
+    kind: string,
+    metadataOptions: { httpEndpoint?: string, httpTokens?: string } | undefined,
+    args: ResourceValidationArgs,
+    reportViolation: (message: string) => void) {
+
+    const options = metadataOptions || {};
+    // Nothing can be retrieved from the metadata service if it is disabled.
+    if (options.httpEndpoint === "disabled") {
+        return;
+    }
+    // Session tokens, i.e. IMDSv2, are optional unless required.
+    if (options.httpTokens !== "required") {
+        reportViolation(`${kind} '${args.name}' must require IMDSv2 by setting metadataOptions.httpTokens to "required".`);
+    }
+}
+
+/** @internal */
+export const eksNodegroupPrivateSubnets: StackValidationPolicy = {
+    name: "eks-nodegroup-private-subnets",
+    description: "Checks whether EKS node groups run in public subnets, i.e. subnets that assign public IP " +
+        "addresses to the instances launched into them.",
+    enforcementLevel: "advisory",
+    validateStack: groupedByResource((args, reportViolation) => {
+        const publicSubnets = args.resources.filter(r => r.isType(aws.ec2.Subnet) && r.props.mapPublicIpOnLaunch);
+
+        for (const nodeGroup of args.resources.filter(r => r.isType(aws.eks.NodeGroup))) {
+            for (const subnet of publicSubnets) {
+                if (isReferencedByNested(subnet, nodeGroup, "subnetIds", nodeGroup.props.subnetIds, ["id"])) {
+                    reportViolation(
+                        `EKS node group '${nodeGroup.name}' should not run in public subnet '${subnet.name}'.`, nodeGroup.urn);
+                }
+            }
+        }
+    }),
+};
+registerPolicy("eksNodegroupPrivateSubnets", eksNodegroupPrivateSubnets);
+
+export interface Ec2NoKeyPairArgs {
+    /** Resource names or name patterns of instances that may use a key pair, e.g. for break-glass SSH access. */
+    allowedInstanceNames?: string[];
+}
+
+/** @internal */
+export const ec2NoKeyPair: ResourceValidationPolicy = {
+    name: "ec2-no-key-pair",
+    description: "Checks whether EC2 instances are launched with an SSH key pair. Session Manager provides shell " +
+        "access without long-lived keys or open SSH ports.",
+    enforcementLevel: "advisory",
+    configSchema: {
+        properties: {
+            allowedInstanceNames: {
+                type: "array",
+                items: { type: "string", format: namePatternFormat },
+                default: [],
+            },
+        },
+    },
+    validateResource: validateResourceOfType(aws.ec2.Instance, (instance, args, reportViolation) => {
+        const { allowedInstanceNames } = args.getConfig<Ec2NoKeyPairArgs>();
+
+        if (instance.keyName && !matchesAnyPattern(args.name, allowedInstanceNames)) {
+            reportViolation(
+                `EC2 instance '${args.name}' should use Session Manager for access rather than the key pair '${instance.keyName}'.`);
+        }
+    }),
+};
+registerPolicy("ec2NoKeyPair", ec2NoKeyPair);
+
+export interface Ec2PreferLaunchTemplateArgs {
+    /**
+     * The number of optional settings a launch template could provide that an instance may set inline
+     * before it should use a launch template instead. Defaults to 5.
+     */
+    maxInlineSettings?: number;
+
+    /** Resource names or name patterns of instances that may be configured inline. */
+    allowedInstanceNames?: string[];
+}
+
+const defaultMaxInlineSettings = 5;
+
+// Optional properties of aws.ec2.Instance that an aws.ec2.LaunchTemplate can provide instead. The AMI
+// and instance type are left out, as every instance without a launch template has to set them.
+const launchTemplateSettings = [
+    "keyName", "iamInstanceProfile", "vpcSecurityGroupIds", "securityGroups",
+    "userData", "userDataBase64", "rootBlockDevice", "ebsBlockDevices", "metadataOptions", "monitoring",
+    "ebsOptimized", "creditSpecification", "placementGroup", "disableApiTermination",
+];
+
+/** @internal */
+export const ec2PreferLaunchTemplate: ResourceValidationPolicy = {
+    name: "ec2-prefer-launch-template",
+    description: "Checks whether EC2 instances with many inline settings use a launch template instead, so " +
+        "that instances are configured consistently.",
+    enforcementLevel: "advisory",
+    configSchema: {
+        properties: {
+            maxInlineSettings: {
+                type: "number",
+                default: defaultMaxInlineSettings,
+            },
+            allowedInstanceNames: {
+                type: "array",
+                items: { type: "string", format: namePatternFormat },
+                default: [],
+            },
+        },
+    },
+    validateResource: validateResourceOfType(aws.ec2.Instance, (instance, args, reportViolation) => {
+        const { maxInlineSettings, allowedInstanceNames } = args.getConfig<Ec2PreferLaunchTemplateArgs>();
+        const max = maxInlineSettings !== undefined ? maxInlineSettings : defaultMaxInlineSettings;
+
+        if (instance.launchTemplate || matchesAnyPattern(args.name, allowedInstanceNames)) {
+            return;
+        }
+        const props: Record<string, any> = instance;
+        const inline = launchTemplateSettings.filter(setting => {
+            const value = props[setting];
+            return value !== undefined && value !== null && !(Array.isArray(value) && value.length === 0);
+        });
+        if (inline.length > max) {
+            reportViolation(`EC2 instance '${args.name}' sets ${inline.length} launch settings inline ` +
+                `(${inline.join(", ")}) and should use an aws.ec2.LaunchTemplate instead.`);
+        }
+    }),
+};
+registerPolicy("ec2PreferLaunchTemplate", ec2PreferLaunchTemplate);
+
+export interface EbsGp2DeprecatedArgs {
+    /**
+     * Text appended to each violation message, e.g. a link to your organization's migration guidance.
+     * Defaults to a link to AWS's guide to migrating from gp2 to gp3.
+     */
+    messageSuffix?: string;
+}
+
+const defaultGp2MessageSuffix = "See https://aws.amazon.com/blogs/storage/" +
+    "migrate-your-amazon-ebs-volumes-from-gp2-to-gp3-and-save-up-to-20-on-costs/ for how to migrate.";
+
+/** @internal */
+export const ebsGp2Deprecated: ResourceValidationPolicy = {
+    name: "ebs-gp2-deprecated",
+    description: "Checks whether EBS volumes and EC2 instance block devices use the gp2 volume type, " +
+        "recommending gp3, which costs less and performs better.",
+    enforcementLevel: "advisory",
+    configSchema: {
+        properties: {
+            messageSuffix: {
+                type: "string",
+                default: defaultGp2MessageSuffix,
+            },
+        },
+    },
+    validateResource: [
+        validateResourceOfType(aws.ebs.Volume, (volume, args, reportViolation) => {
+            const { messageSuffix } = args.getConfig<EbsGp2DeprecatedArgs>();
+            if ((volume.type || defaultVolumeType) === "gp2") {
+                reportViolation(withGp2MessageSuffix(`EBS volume '${args.name}' uses gp2 and should use gp3 instead.`, messageSuffix));
+            }
+        }),
+        validateResourceOfType(aws.ec2.Instance, (instance, args, reportViolation) => {
+            const { messageSuffix } = args.getConfig<EbsGp2DeprecatedArgs>();
+            const devices: { deviceName: string, volumeType?: string }[] = [
+                ...(instance.rootBlockDevice ? [{ deviceName: "root", volumeType: instance.rootBlockDevice.volumeType }] : []),
+                ...(instance.ebsBlockDevices || []),
+            ];
+            for (const { deviceName, volumeType } of devices) {
+                if ((volumeType || defaultVolumeType) === "gp2") {
+                    reportViolation(withGp2MessageSuffix(
+                        `EC2 instance '${args.name}' block device '${deviceName}' uses gp2 and should use gp3 instead.`,
+                        messageSuffix));
+                }
+            }
+        }),
+    ],
+};
+registerPolicy("ebsGp2Deprecated", ebsGp2Deprecated);
+
+function withGp2MessageSuffix(message: string, suffix: string | undefined): string {
+    const text = suffix !== undefined ? suffix : defaultGp2MessageSuffix;
+    return text ? `${message} ${text}` : message;
+}
+
+const ecrPublicActions = [
+    // Pulling images.
+    "ecr:BatchGetImage", "ecr:GetDownloadUrlForLayer", "ecr:BatchCheckLayerAvailability",
+    // Pushing images.
+    "ecr:PutImage", "ecr:InitiateLayerUpload", "ecr:UploadLayerPart", "ecr:CompleteLayerUpload",
+];
+
+// Condition keys that restrict a statement to principals in particular AWS accounts or organizations.
+const ecrAccountConditionKeys = [
+    "aws:principalaccount", "aws:principalorgid", "aws:principalorgpaths", "aws:sourceaccount", "aws:sourceorgid",
+];
+
+function hasAccountCondition(condition: any): boolean {
+    if (!condition || typeof condition !== "object") {
+        return false;
+    }
+    return Object.keys(condition).some(operator => {
+        const keys = condition[operator];
+        return keys && typeof keys === "object" &&
+            Object.keys(keys).some(key => ecrAccountConditionKeys.includes(key.toLowerCase()));
+    });
+}
+
+/** @internal */
+export const ecrRepositoryNoPublicAccess: ResourceValidationPolicy = {
+    name: "ecr-repository-no-public-access",
+    description: "Checks whether ECR repository policies allow any principal to pull or push images without a " +
+        "condition restricting access to particular accounts or organizations.",
+    validateResource: validateResourceOfType(aws.ecr.RepositoryPolicy, (repositoryPolicy, args, reportViolation) => {
+        const repositoryName = repositoryPolicy.repository || args.name;
+        getPolicyStatements(repositoryPolicy.policy).forEach((statement, index) => {
+            // Other conditions, e.g. on the source IP, still leave the repository open to any AWS account.
+            if (allowsPublicAccess({ ...statement, Condition: undefined }, ecrPublicActions) &&
+                !hasAccountCondition(statement.Condition)) {
+                reportViolation(
+                    `ECR repository '${repositoryName}' must not allow public access: statement ` +
+                    `${describePolicyStatement(statement, index)} allows any principal to pull or push images ` +
+                    "without a condition restricting the account or organization.");
+            }
+        });
+    }),
+};
+registerPolicy("ecrRepositoryNoPublicAccess", ecrRepositoryNoPublicAccess);
+
+/** @internal */
+export const ecsClusterContainerInsights: ResourceValidationPolicy = {
+    name: "ecs-cluster-container-insights",
+    description: "Checks whether ECS clusters have CloudWatch Container Insights enabled.",
+    enforcementLevel: "advisory",
+    validateResource: validateResourceOfType(aws.ecs.Cluster, (cluster, args, reportViolation) => {
+        // "enhanced" observability includes everything "enabled" does.
+        const enabled = (cluster.settings || []).some(setting =>
+            setting.name === "containerInsights" && (setting.value === "enabled" || setting.value === "enhanced"));
+        if (!enabled) {
+            reportViolation(`ECS cluster '${args.name}' should have Container Insights enabled.`);
+        }
+    }),
+};
+registerPolicy("ecsClusterContainerInsights", ecsClusterContainerInsights);
+
+export interface Ec2InstanceProfileRequiredArgs {
+    /**
+     * Resource names or name patterns of instances that may run without an instance profile, e.g. ones
+     * that don't call AWS APIs.
+     */
+    allowedInstanceNames?: string[];
+}
+
+/** @internal */
+export const ec2InstanceProfileRequired: ResourceValidationPolicy = {
+    name: "ec2-instance-profile-required",
+    description: "Checks whether EC2 instances have an IAM instance profile, so that applications on the instance " +
+        "use the role's temporary credentials rather than static access keys.",
+    enforcementLevel: "advisory",
+    configSchema: {
+        properties: {
+            allowedInstanceNames: {
+                type: "array",
+                items: { type: "string", format: namePatternFormat },
+                default: [],
+            },
+        },
+    },
+    validateResource: validateResourceOfType(aws.ec2.Instance, (instance, args, reportViolation) => {
+        const { allowedInstanceNames } = args.getConfig<Ec2InstanceProfileRequiredArgs>();
+
+        if (!instance.iamInstanceProfile && !matchesAnyPattern(args.name, allowedInstanceNames)) {
+            reportViolation(`EC2 instance '${args.name}' should have an IAM instance profile, so that it uses a role ` +
+                "rather than static credentials.");
+        }
+    }),
+};
+registerPolicy("ec2InstanceProfileRequired", ec2InstanceProfileRequired);
+
+export interface Ec2DeprecatedInstanceGenerationArgs {
+    /**
+     * Previous-generation instance families, e.g. "m3", that instances should no longer use. Defaults to
+     * the families AWS lists as previous generation.
+     */
+    deprecatedFamilies?: string[];
+}
+
+// Current-generation equivalents of previous-generation instance families, to suggest in violations.
+const currentGenerationFamilies: Record<string, string> = {
+    c1: "c6i", c3: "c6i", cc2: "c6i", cg1: "g5", cr1: "r6i", g2: "g5", hi1: "i4i", hs1: "d3", i2: "i4i",
+    m1: "m6i", m2: "r6i", m3: "m6i", r3: "r6i", t1: "t3",
+};
+
+const defaultDeprecatedFamilies = Object.keys(currentGenerationFamilies);
+
+/** @internal */
+export const ec2DeprecatedInstanceGeneration: ResourceValidationPolicy = {
+    name: "ec2-deprecated-instance-generation",
+    description: "Checks whether EC2 instances use a previous-generation instance type. Current-generation types " +
+        "usually cost less and perform better.",
+    enforcementLevel: "advisory",
+    configSchema: {
+        properties: {
+            deprecatedFamilies: {
+                type: "array",
+                items: { type: "string" },
+                default: defaultDeprecatedFamilies,
+            },
+        },
+    },
+    validateResource: validateResourceOfType(aws.ec2.Instance, (instance, args, reportViolation) => {
+        const { deprecatedFamilies } = args.getConfig<Ec2DeprecatedInstanceGenerationArgs>();
+
+        if (typeof instance.instanceType !== "string") {
+            return;
+        }
+        const [family, size] = instance.instanceType.split(".");
+        if (!(deprecatedFamilies || defaultDeprecatedFamilies).includes(family)) {
+            return;
+        }
+        const replacement = currentGenerationFamilies[family];
+        const suggestion = replacement && size ? `, e.g. '${replacement}.${size}'` : "";
+        reportViolation(`EC2 instance '${args.name}' uses the previous-generation instance type ` +
+            `'${instance.instanceType}' and should use a current-generation type instead${suggestion}.`);
+    }),
+};
+registerPolicy("ec2DeprecatedInstanceGeneration", ec2DeprecatedInstanceGeneration);
+
+/** @internal */
+export const ec2HibernationRequiresEncryption: ResourceValidationPolicy = {
+    name: "ec2-hibernation-requires-encryption",
+    description: "Checks whether EC2 instances with hibernation enabled have an encrypted root volume. Hibernation " +
+        "writes the instance's memory to the root volume, and AWS requires it to be encrypted.",
+    validateResource: validateResourceOfType(aws.ec2.Instance, (instance, args, reportViolation) => {
+        if (instance.hibernation === true && !(instance.rootBlockDevice && instance.rootBlockDevice.encrypted === true)) {
+            reportViolation(`EC2 instance '${args.name}' has hibernation enabled, so its root block device must be encrypted.`);
+        }
+    }),
+};
+registerPolicy("ec2HibernationRequiresEncryption", ec2HibernationRequiresEncryption);
+
+/** @internal */
+export const ebsAccountDefaultEncryption: StackValidationPolicy = {
+    name: "ebs-account-default-encryption",
+    description: "Checks whether stacks that create EBS volumes or EC2 instances also enable EBS encryption by default " +
+        "for the account, so that volumes are encrypted even if they aren't configured to be.",
+    enforcementLevel: "advisory",
+    validateStack: (args, reportViolation) => {
+        const volumeResources = args.resources.filter(r => r.isType(aws.ebs.Volume) || r.isType(aws.ec2.Instance));
+        if (volumeResources.length === 0) {
+            return;
+        }
+        // EBS encryption by default is enabled unless `enabled` is explicitly false.
+        if (args.resources.some(r => r.isType(aws.ebs.EncryptionByDefault) && r.props.enabled !== false)) {
+            return;
+        }
+        reportViolation(`The stack creates ${volumeResources.length} EBS volume(s) or EC2 instance(s), but doesn't ` +
+            "enable EBS encryption by default. Add an 'aws.ebs.EncryptionByDefault' resource so that every new volume " +
+            "in the account and region is encrypted, in addition to encrypting each volume.");
+    },
+};
+registerPolicy("ebsAccountDefaultEncryption", ebsAccountDefaultEncryption);
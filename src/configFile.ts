@@ -0,0 +1,127 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import * as fs from "fs";
+import * as path from "path";
+
+import * as yaml from "js-yaml";
+
+import { ResourceValidationPolicy, StackValidationPolicy } from "@pulumi/policy";
+
+import { AwsGuardArgs } from "./awsGuard";
+import { isEnforcementLevel } from "./enforcementLevel";
+import { isSeverity } from "./severity";
+
+/**
+ * The environment variable used to specify a file that AwsGuard loads its configuration from, when
+ * the `configFile` option isn't given.
+ */
+export const configFileEnvVar = "AWSGUARD_CONFIG_FILE";
+
+// AwsGuardArgs properties, other than policies, that may be set in a config file.
+const fileOptions = [
+    "all", "onApiError", "apiTimeoutSeconds", "apiMaxRetries", "apiRetryBaseDelayMs", "onUnknown",
+    "onlyResourcesWithTag", "excludeResourcesWithTag", "reportVersion", "severityEnforcement", "allowAcknowledgements",
+    "stopOnFirstViolation", "strict", "relaxed", "includeControlIds",
+];
+
+/**
+ * Loads AwsGuardArgs from a JSON or YAML file. Files with a ".yaml" or ".yml" extension are parsed
+ * as YAML; anything else as JSON. Policies may be referred to by their AwsGuardArgs property (e.g.
+ * "ec2VolumeInUse") or their name (e.g. "ec2-volume-inuse"). Throws if the file refers to an
+ * unknown policy or contains an invalid enforcement level.
+ * @internal
+ */
+export function loadConfigFile(
+    filePath: string,
+    policyMap: Record<string, ResourceValidationPolicy | StackValidationPolicy>): AwsGuardArgs {
+
+    const contents = fs.readFileSync(filePath, "utf8");
+    const extension = path.extname(filePath).toLowerCase();
+    const parsed: any = extension === ".yaml" || extension === ".yml" ? yaml.safeLoad(contents) : JSON.parse(contents);
+    if (parsed === undefined || parsed === null) {
+        return {};
+    }
+    if (typeof parsed !== "object" || Array.isArray(parsed)) {
+        throw new Error(`${filePath}: AwsGuard config must be an object.`);
+    }
+
+    const propertiesByName: Record<string, string> = {};
+    for (const property of Object.keys(policyMap)) {
+        propertiesByName[policyMap[property].name] = property;
+    }
+
+    const result: Record<string, any> = {};
+    for (const key of Object.keys(parsed)) {
+        const value = parsed[key];
+        if (fileOptions.includes(key)) {
+            if (key === "all" && !isEnforcementLevel(value)) {
+                throw new Error(`${filePath}: '${value}' is not a valid enforcement level for 'all'.`);
+            }
+            if (key === "severityEnforcement") {
+                validateSeverityEnforcement(filePath, value);
+            }
+            result[key] = value;
+            continue;
+        }
+
+        const property = key in policyMap ? key : propertiesByName[key];
+        if (!property) {
+            throw new Error(`${filePath}: '${key}' is not a known policy.`);
+        }
+        const isObject = typeof value === "object" && value !== null && !Array.isArray(value);
+        const enforcementLevel = isObject ? value.enforcementLevel : value;
+        if (isObject ? enforcementLevel !== undefined && !isEnforcementLevel(enforcementLevel) : !isEnforcementLevel(value)) {
+            throw new Error(`${filePath}: '${enforcementLevel}' is not a valid enforcement level for '${key}'.`);
+        }
+        result[property] = value;
+    }
+    return result;
+}
+
+function validateSeverityEnforcement(filePath: string, value: any): void {
+    if (typeof value !== "object" || value === null || Array.isArray(value)) {
+        throw new Error(`${filePath}: 'severityEnforcement' must be an object.`);
+    }
+    for (const severity of Object.keys(value)) {
+        if (!isSeverity(severity)) {
+            throw new Error(`${filePath}: '${severity}' is not a valid severity.`);
+        }
+        if (!isEnforcementLevel(value[severity])) {
+            throw new Error(`${filePath}: '${value[severity]}' is not a valid enforcement level for severity '${severity}'.`);
+        }
+    }
+}
+
+/**
+ * Merges args loaded from a config file with args given inline, with inline args taking precedence.
+ * A policy configured in both has its settings merged, so e.g. an inline enforcement level doesn't
+ * discard the policy's settings from the file.
+ * @internal
+ */
+export function mergeArgs(fileArgs: AwsGuardArgs, inlineArgs?: AwsGuardArgs): AwsGuardArgs {
+    const result: Record<string, any> = { ...fileArgs };
+    const inline: Record<string, any> = inlineArgs || {};
+    const isPolicyConfig = (value: any) => typeof value === "string" || (typeof value === "object" && value !== null);
+    const toObject = (value: any) => typeof value === "string" ? { enforcementLevel: value } : value;
+
+    for (const key of Object.keys(inline)) {
+        const fileValue = result[key];
+        const inlineValue = inline[key];
+        const merge = !fileOptions.includes(key) && isPolicyConfig(fileValue) && isPolicyConfig(inlineValue) &&
+            (typeof fileValue === "object" || typeof inlineValue === "object");
+        result[key] = merge ? { ...toObject(fileValue), ...toObject(inlineValue) } : inlineValue;
+    }
+    return result;
+}
@@ -0,0 +1,151 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import { Policy } from "./dispatch";
+import { isEnforcementLevel } from "./enforcementLevel";
+import { describeInvalidPattern } from "./util";
+
+/**
+ * The `format` of string config properties that are patterns for `matchesPattern`, e.g. the items of
+ * name-based allow-lists. Invalid regular expressions are then caught when the pack is constructed,
+ * rather than when a policy first uses them during a preview.
+ * @internal
+ */
+export const namePatternFormat = "name-pattern";
+
+/**
+ * Validates a policy's config, as given in AwsGuardArgs, against the policy's config schema, so that
+ * mistakes such as a misspelled property or a string where a number is expected are caught when the
+ * pack is constructed rather than silently ignored. Config given as just an enforcement level, and
+ * config for policies without a schema, is only checked for a valid enforcement level.
+ * @internal
+ */
+export function validatePolicyConfig(policy: Policy, config: any): void {
+    const fail = (message: string): never => {
+        throw new Error(`Invalid config for policy '${policy.name}': ${message}`);
+    };
+
+    if (typeof config !== "object" || config === null || Array.isArray(config)) {
+        if (!isEnforcementLevel(config)) {
+            fail(`'${config}' is not a valid enforcement level.`);
+        }
+        return;
+    }
+    if (config.enforcementLevel !== undefined && !isEnforcementLevel(config.enforcementLevel)) {
+        fail(`'${config.enforcementLevel}' is not a valid enforcement level.`);
+    }
+
+    const schema = policy.configSchema;
+    if (!schema) {
+        return;
+    }
+    const { enforcementLevel, ...properties } = config;
+    const error = validateProperties(properties, schema.properties || {}, "");
+    if (error) {
+        fail(error);
+    }
+    for (const property of schema.required || []) {
+        if (properties[property] === undefined) {
+            fail(`'${property}' is required.`);
+        }
+    }
+}
+
+// Returns a description of the first property of `value` that doesn't match `properties`, if any.
+function validateProperties(value: Record<string, any>, properties: Record<string, any>, prefix: string): string | undefined {
+    const known = Object.keys(properties);
+    for (const key of Object.keys(value)) {
+        const path = prefix + key;
+        if (!(key in properties)) {
+            const expected = known.length > 0 ? ` Expected one of: ${known.map(k => `'${prefix + k}'`).join(", ")}.` : "";
+            return `'${path}' is not a known property.${expected}`;
+        }
+        if (value[key] === undefined) {
+            continue;
+        }
+        const error = validateValue(value[key], properties[key], path);
+        if (error) {
+            return error;
+        }
+    }
+    return undefined;
+}
+
+// Returns a description of how `value` doesn't match `schema`, if it doesn't.
+function validateValue(value: any, schema: any, path: string): string | undefined {
+    if (!schema || typeof schema !== "object") {
+        return undefined;
+    }
+
+    const type: string | undefined = schema.type;
+    if (type && !hasType(value, type)) {
+        return `'${path}' must be ${type === "array" || type === "integer" || type === "object" ? "an" : "a"} ${type}, ` +
+            `but got ${describeValue(value)}.`;
+    }
+    if (schema.enum && !schema.enum.includes(value)) {
+        return `'${path}' must be one of ${schema.enum.map((v: any) => JSON.stringify(v)).join(", ")}, ` +
+            `but got ${JSON.stringify(value)}.`;
+    }
+    if (typeof value === "string" && schema.format === namePatternFormat) {
+        const error = describeInvalidPattern(value);
+        if (error) {
+            return `'${path}' must be a valid pattern: ${error}`;
+        }
+    }
+    if (typeof value === "number") {
+        if (schema.minimum !== undefined && value < schema.minimum) {
+            return `'${path}' must be at least ${schema.minimum}, but got ${value}.`;
+        }
+        if (schema.maximum !== undefined && value > schema.maximum) {
+            return `'${path}' must be at most ${schema.maximum}, but got ${value}.`;
+        }
+    }
+    if (Array.isArray(value) && schema.items) {
+        for (let i = 0; i < value.length; i++) {
+            const error = validateValue(value[i], schema.items, `${path}[${i}]`);
+            if (error) {
+                return error;
+            }
+        }
+    }
+    if (type === "object" && schema.properties) {
+        return validateProperties(value, schema.properties, `${path}.`);
+    }
+    return undefined;
+}
+
+function hasType(value: any, type: string): boolean {
+    switch (type) {
+        case "array":
+            return Array.isArray(value);
+        case "object":
+            return typeof value === "object" && value !== null && !Array.isArray(value);
+        case "integer":
+            return typeof value === "number" && Number.isInteger(value);
+        case "null":
+            return value === null;
+        default:
+            return typeof value === type;
+    }
+}
+
+function describeValue(value: any): string {
+    if (value === null) {
+        return "null";
+    }
+    if (Array.isArray(value)) {
+        return "an array";
+    }
+    return typeof value === "string" ? `the string ${JSON.stringify(value)}` : `${typeof value} ${JSON.stringify(value)}`;
+}
@@ -0,0 +1,49 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The compliance controls each policy maps to, by policy name, so that auditors can map violations
+// to the frameworks they report against. "CIS" controls are from the CIS Amazon Web Services
+// Foundations Benchmark v2.0.0 and "PCI" requirements from PCI DSS v3.2.1. Policies that aren't
+// listed don't map to a control of these frameworks.
+const policyControlIds: Record<string, string[]> = {
+    "access-keys-rotated": ["CIS 1.14", "PCI 8.2.4"],
+    "alb-http-to-https-redirection": ["PCI 4.1"],
+    "cmk-backing-key-rotation-enabled": ["CIS 3.8", "PCI 3.6.4"],
+    "dynamodb-table-encryption-enabled": ["PCI 3.4"],
+    "ebs-account-default-encryption": ["CIS 2.2.1", "PCI 3.4"],
+    "ec2-imdsv2-required": ["CIS 5.6"],
+    "ec2-instance-no-public-ip": ["PCI 1.3.1"],
+    "efs-encrypted": ["CIS 2.4.1", "PCI 3.4"],
+    "elasticsearch-encrypted-at-rest": ["PCI 3.4"],
+    "elb-logging-enabled": ["PCI 10.1"],
+    "encrypted-volumes": ["PCI 3.4"],
+    "mfa-enabled-for-iam-console-access": ["CIS 1.10", "PCI 8.3.1"],
+    "minimum-tls-version": ["PCI 4.1"],
+    "rds-instance-public-access": ["CIS 2.3.3", "PCI 1.3.1"],
+    "rds-storage-encrypted": ["CIS 2.3.1", "PCI 3.4"],
+    "redshift-cluster-public-access": ["PCI 1.3.1"],
+    "s3-bucket-acl-no-public": ["PCI 1.3.1"],
+    "s3-bucket-logging-enabled": ["PCI 10.1"],
+    "s3-bucket-mfa-delete": ["CIS 2.1.2"],
+    "security-group-restricted-ingress": ["CIS 5.2", "PCI 1.2.1"],
+};
+
+/**
+ * Returns the IDs of the compliance controls the policy with the given name maps to, e.g.
+ * `["CIS 2.3.1", "PCI 3.4"]`, or an empty array if it doesn't map to any.
+ * @internal
+ */
+export function getControlIds(policyName: string): string[] {
+    return policyControlIds[policyName] || [];
+}
@@ -14,7 +14,7 @@
 
 import * as aws from "@pulumi/aws";
 
-import { EnforcementLevel, ResourceValidationPolicy, validateResourceOfType } from "@pulumi/policy";
+import { EnforcementLevel, ResourceValidationArgs, ResourceValidationPolicy, validateResourceOfType } from "@pulumi/policy";
 
 import { registerPolicy } from "./awsGuard";
 import { PolicyArgs } from "./policyArgs";
@@ -22,14 +22,130 @@ import { PolicyArgs } from "./policyArgs";
 // Mixin additional properties onto AwsGuardArgs.
 declare module "./awsGuard" {
     interface AwsGuardArgs {
+        /**
+         * Checks whether Amazon Redshift clusters have the specified settings.
+         *
+         * Enforcement level of the `redshift-cluster-configuration` policy, or its enforcement level and options:
+         * `clusterDbEncrypted`, `loggingEnabled`, `nodeTypes`, `checks`.
+         */
         redshiftClusterConfiguration?: EnforcementLevel | (RedshiftClusterConfigurationArgs & PolicyArgs);
+
+        /**
+         * Checks whether Amazon Redshift clusters have the specified maintenance settings.
+         *
+         * Enforcement level of the `redshift-cluster-maintenance-settings` policy, or its enforcement level and
+         * options: `allowVersionUpgrade`, `preferredMaintenanceWindow`, `automatedSnapshotRetentionPeriod`.
+         */
         redshiftClusterMaintenanceSettings?: EnforcementLevel | (RedshiftClusterMaintenanceSettingsArgs & PolicyArgs);
+
+        /**
+         * Checks whether Amazon Redshift clusters are not publicly accessible.
+         *
+         * Enforcement level of the `redshift-cluster-public-access` policy.
+         */
         redshiftClusterPublicAccess?: EnforcementLevel;
+
+        /**
+         * Checks whether Amazon Redshift Serverless namespaces are encrypted with a customer managed KMS key and export
+         * their audit logs.
+         *
+         * Enforcement level of the `redshift-serverless-encryption` policy, or its enforcement level and options:
+         * `requiredLogExports`.
+         */
+        redshiftServerlessEncryption?: EnforcementLevel | (RedshiftServerlessEncryptionArgs & PolicyArgs);
+
+        /**
+         * Checks whether the Amazon DynamoDB tables are encrypted.
+         *
+         * Enforcement level of the `dynamodb-table-encryption-enabled` policy.
+         */
         dynamodbTableEncryptionEnabled?: EnforcementLevel;
+
+        /**
+         * Checks whether DynamoDB Accelerator (DAX) clusters have server-side encryption enabled.
+         *
+         * Enforcement level of the `dax-cluster-encryption` policy.
+         */
+        daxClusterEncryption?: EnforcementLevel;
+
+        /**
+         * Checks whether MemoryDB for Redis clusters use TLS and are encrypted at rest with a customer managed KMS key.
+         *
+         * Enforcement level of the `memorydb-cluster-encryption` policy.
+         */
+        memorydbClusterEncryption?: EnforcementLevel;
+
+        /**
+         * Checks whether RDS DB instances and Aurora clusters have backups enabled. Optionally, the rule checks the
+         * backup retention period and the backup window.
+         *
+         * Enforcement level of the `rds-instance-backup-enabled` policy, or its enforcement level and options:
+         * `backupRetentionPeriod`, `preferredBackupWindow`, `checkReadReplicas`, `minBackupRetentionDays`.
+         */
         rdsInstanceBackupEnabled?: EnforcementLevel | (RdsInstanceBackupEnabledArgs & PolicyArgs);
+
+        /**
+         * Check whether high availability is enabled for Amazon Relational Database Service instances.
+         *
+         * Enforcement level of the `rds-instance-multi-az-enabled` policy.
+         */
         rdsInstanceMultiAZEnabled?: EnforcementLevel;
+
+        /**
+         * Check whether the Amazon Relational Database Service instances, including the instances of Aurora clusters,
+         * are not publicly accessible.
+         *
+         * Enforcement level of the `rds-instance-public-access` policy.
+         */
         rdsInstancePublicAccess?: EnforcementLevel;
+
+        /**
+         * Checks whether storage encryption is enabled for your RDS DB instances and Aurora clusters.
+         *
+         * Enforcement level of the `rds-storage-encrypted` policy, or its enforcement level and options: `kmsKeyId`.
+         */
         rdsStorageEncrypted?: EnforcementLevel | (RdsStorageEncryptedArgs & PolicyArgs);
+
+        /**
+         * Checks whether RDS DB instances and clusters with Performance Insights enabled encrypt the Performance
+         * Insights data with a KMS key.
+         *
+         * Enforcement level of the `rds-performance-insights-encrypted` policy.
+         */
+        rdsPerformanceInsightsEncrypted?: EnforcementLevel;
+
+        /**
+         * Checks whether RDS DB instances use a current CA certificate, rather than an expiring or deprecated one that
+         * would break TLS connections when it expires.
+         *
+         * Enforcement level of the `rds-ca-certificate-current` policy, or its enforcement level and options:
+         * `allowedCaCertIdentifiers`.
+         */
+        rdsCaCertificateCurrent?: EnforcementLevel | (RdsCaCertificateCurrentArgs & PolicyArgs);
+
+        /**
+         * Checks whether Amazon Timestream databases are encrypted with a customer managed KMS key.
+         *
+         * Enforcement level of the `timestream-database-kms-key` policy.
+         */
+        timestreamDatabaseKmsKey?: EnforcementLevel;
+
+        /**
+         * Checks whether Amazon Timestream tables that allow magnetic store writes store rejected records in S3
+         * encrypted with a KMS key.
+         *
+         * Enforcement level of the `timestream-magnetic-store-rejected-data-encrypted` policy.
+         */
+        timestreamMagneticStoreRejectedDataEncrypted?: EnforcementLevel;
+
+        /**
+         * Checks whether Amazon ElastiCache for Redis replication groups and clusters have automatic backups enabled,
+         * retaining snapshots for at least the specified number of days.
+         *
+         * Enforcement level of the `elasticache-backup-retention` policy, or its enforcement level and options:
+         * `minSnapshotRetentionDays`.
+         */
+        elasticacheBackupRetention?: EnforcementLevel | (ElasticacheBackupRetentionArgs & PolicyArgs);
     }
 }
 
@@ -42,6 +158,23 @@ export interface RedshiftClusterConfigurationArgs {
 
     /** List of allowed node types. */
     nodeTypes?: string[];
+
+    /**
+     * Turns individual checks on or off, so that one can be disabled without disabling the others.
+     * All checks are on by default.
+     */
+    checks?: RedshiftClusterConfigurationChecks;
+}
+
+export interface RedshiftClusterConfigurationChecks {
+    /** If false, the cluster's encryption isn't checked. */
+    encryption?: boolean;
+
+    /** If false, the cluster's node type isn't checked. */
+    nodeType?: boolean;
+
+    /** If false, the cluster's logging isn't checked. */
+    logging?: boolean;
 }
 
 /** @internal */
@@ -63,29 +196,43 @@ export const redshiftClusterConfiguration: ResourceValidationPolicy = {
                 items: { type: "string" },
                 default: [],
             },
+            checks: {
+                type: "object",
+                properties: {
+                    encryption: { type: "boolean", default: true },
+                    nodeType: { type: "boolean", default: true },
+                    logging: { type: "boolean", default: true },
+                },
+                default: {},
+            },
         },
     },
     validateResource: validateResourceOfType(aws.redshift.Cluster, (cluster, args, reportViolation) => {
-        const { clusterDbEncrypted, loggingEnabled, nodeTypes } =
+        const { clusterDbEncrypted, loggingEnabled, nodeTypes, checks } =
             args.getConfig<Required<RedshiftClusterConfigurationArgs>>();
+        const { encryption, nodeType, logging } = checks || {};
 
         // Check the cluster's encryption configuration.
-        if (clusterDbEncrypted && (cluster.encrypted === undefined || cluster.encrypted === false)) {
-            reportViolation("Redshift cluster must be encrypted.");
-        } else if (!clusterDbEncrypted && cluster.encrypted === true) {
-            reportViolation("Redshift cluster must not be encrypted.");
+        if (encryption !== false) {
+            if (clusterDbEncrypted && (cluster.encrypted === undefined || cluster.encrypted === false)) {
+                reportViolation("Redshift cluster must be encrypted.");
+            } else if (!clusterDbEncrypted && cluster.encrypted === true) {
+                reportViolation("Redshift cluster must not be encrypted.");
+            }
         }
 
         // Check the cluster's node type.
-        if (nodeTypes.length > 0 && !nodeTypes.includes(cluster.nodeType)) {
+        if (nodeType !== false && nodeTypes.length > 0 && !nodeTypes.includes(cluster.nodeType)) {
             reportViolation(`Redshift cluster node type must be one of the following: ${nodeTypes.toString()}`);
         }
 
         // Check the cluster's logging configuration.
-        if (loggingEnabled && (cluster.logging === undefined || cluster.logging.enable === false)) {
-            reportViolation(`Redshift cluster must have logging enabled.`);
-        } else if (!loggingEnabled && cluster.logging && cluster.logging.enable === true) {
-            reportViolation(`Redshift cluster must not have logging enabled.`);
+        if (logging !== false) {
+            if (loggingEnabled && (cluster.logging === undefined || cluster.logging.enable === false)) {
+                reportViolation(`Redshift cluster must have logging enabled.`);
+            } else if (!loggingEnabled && cluster.logging && cluster.logging.enable === true) {
+                reportViolation(`Redshift cluster must not have logging enabled.`);
+            }
         }
     }),
 };
@@ -159,6 +306,51 @@ export const redshiftClusterPublicAccess: ResourceValidationPolicy = {
 };
 registerPolicy("redshiftClusterPublicAccess", redshiftClusterPublicAccess);
 
+export interface RedshiftServerlessEncryptionArgs {
+    /**
+     * The logs namespaces must export to CloudWatch Logs. Defaults to the audit logs: "connectionlog",
+     * "userlog" and "useractivitylog".
+     */
+    requiredLogExports?: string[];
+}
+
+const defaultRequiredRedshiftServerlessLogExports = ["connectionlog", "userlog", "useractivitylog"];
+
+/** @internal */
+export const redshiftServerlessEncryption: ResourceValidationPolicy = {
+    name: "redshift-serverless-encryption",
+    description: "Checks whether Amazon Redshift Serverless namespaces are encrypted with a customer managed KMS key and " +
+        "export their audit logs.",
+    configSchema: {
+        properties: {
+            requiredLogExports: {
+                type: "array",
+                items: {
+                    type: "string",
+                    enum: defaultRequiredRedshiftServerlessLogExports,
+                },
+                default: defaultRequiredRedshiftServerlessLogExports,
+            },
+        },
+    },
+    validateResource: validateResourceOfType(aws.redshiftserverless.Namespace, (namespace, args, reportViolation) => {
+        const { requiredLogExports } = args.getConfig<RedshiftServerlessEncryptionArgs>();
+        const name = namespace.namespaceName || args.name;
+
+        // Without a KMS key, the namespace is encrypted with an AWS owned key.
+        if (!namespace.kmsKeyId) {
+            reportViolation(`Redshift Serverless namespace '${name}' must be encrypted with a customer managed KMS key.`);
+        }
+        const missing = (requiredLogExports || defaultRequiredRedshiftServerlessLogExports).filter(
+            log => !(namespace.logExports || []).includes(log));
+        if (missing.length > 0) {
+            reportViolation(`Redshift Serverless namespace '${name}' must export its audit logs. ` +
+                `Add ${missing.map(log => `"${log}"`).join(", ")} to 'logExports'.`);
+        }
+    }),
+};
+registerPolicy("redshiftServerlessEncryption", redshiftServerlessEncryption);
+
 
 /** @internal */
 export const dynamodbTableEncryptionEnabled: ResourceValidationPolicy = {
@@ -172,6 +364,37 @@ export const dynamodbTableEncryptionEnabled: ResourceValidationPolicy = {
 };
 registerPolicy("dynamodbTableEncryptionEnabled", dynamodbTableEncryptionEnabled);
 
+/** @internal */
+export const daxClusterEncryption: ResourceValidationPolicy = {
+    name: "dax-cluster-encryption",
+    description: "Checks whether DynamoDB Accelerator (DAX) clusters have server-side encryption enabled.",
+    validateResource: validateResourceOfType(aws.dax.Cluster, (cluster, args, reportViolation) => {
+        // DAX clusters are unencrypted unless server-side encryption is enabled.
+        if (!cluster.serverSideEncryption || !cluster.serverSideEncryption.enabled) {
+            reportViolation(`DAX cluster '${args.name}' must have server-side encryption enabled.`);
+        }
+    }),
+};
+registerPolicy("daxClusterEncryption", daxClusterEncryption);
+
+/** @internal */
+export const memorydbClusterEncryption: ResourceValidationPolicy = {
+    name: "memorydb-cluster-encryption",
+    description: "Checks whether MemoryDB for Redis clusters use TLS and are encrypted at rest with a customer managed " +
+        "KMS key.",
+    validateResource: validateResourceOfType(aws.memorydb.Cluster, (cluster, args, reportViolation) => {
+        const name = cluster.name || args.name;
+        // TLS is enabled unless `tlsEnabled` is explicitly false.
+        if (cluster.tlsEnabled === false) {
+            reportViolation(`MemoryDB cluster '${name}' must have TLS enabled.`);
+        }
+        if (!cluster.kmsKeyArn) {
+            reportViolation(`MemoryDB cluster '${name}' must be encrypted at rest with a customer managed KMS key.`);
+        }
+    }),
+};
+registerPolicy("memorydbClusterEncryption", memorydbClusterEncryption);
+
 export interface RdsInstanceBackupEnabledArgs {
     /** Retention period for backups. Must be greater than 0. */
     backupRetentionPeriod?: number;
@@ -181,12 +404,20 @@ export interface RdsInstanceBackupEnabledArgs {
 
     /** Checks whether RDS DB instances have backups enabled for read replicas. Defaults to true. */
     checkReadReplicas?: boolean;
+
+    /**
+     * The minimum number of days backups must be retained for. Defaults to 7 for DB instances.
+     * Aurora clusters, whose backups are retained for 1 day by default, are only checked if set.
+     */
+    minBackupRetentionDays?: number;
 }
 
+const defaultMinBackupRetentionDays = 7;
+
 /** @internal */
 export const rdsInstanceBackupEnabled: ResourceValidationPolicy = {
     name: "rds-instance-backup-enabled",
-    description: "Checks whether RDS DB instances have backups enabled. " +
+    description: "Checks whether RDS DB instances and Aurora clusters have backups enabled. " +
         "Optionally, the rule checks the backup retention period and the backup window.",
     configSchema: {
         properties: {
@@ -201,30 +432,61 @@ export const rdsInstanceBackupEnabled: ResourceValidationPolicy = {
                 type: "boolean",
                 default: true,
             },
+            // No default, so that we can tell whether the minimum should also apply to clusters.
+            minBackupRetentionDays: {
+                type: "number",
+                minimum: 1,
+            },
         },
     },
-    validateResource: validateResourceOfType(aws.rds.Instance, (instance, args, reportViolation) => {
-        const { backupRetentionPeriod, preferredBackupWindow, checkReadReplicas } = args.getConfig<RdsInstanceBackupEnabledArgs>();
-        // Run checks if the instance is not a read replica or if check read replicas is true.
-        if (!instance.replicateSourceDb || checkReadReplicas) {
-            if (instance.backupRetentionPeriod !== undefined && instance.backupRetentionPeriod === 0) {
-                reportViolation("RDS Instances must have backups enabled.");
+    validateResource: [
+        validateResourceOfType(aws.rds.Instance, (instance, args, reportViolation) => {
+            const { backupRetentionPeriod, preferredBackupWindow, checkReadReplicas, minBackupRetentionDays } =
+                args.getConfig<RdsInstanceBackupEnabledArgs>();
+            // Run checks if the instance is not a read replica or if check read replicas is true.
+            if (!instance.replicateSourceDb || checkReadReplicas) {
+                const minDays = minBackupRetentionDays !== undefined ? minBackupRetentionDays : defaultMinBackupRetentionDays;
+                // The backupRetentionPeriod of an instance defaults to 7 days.
+                const retention = instance.backupRetentionPeriod !== undefined ? instance.backupRetentionPeriod : 7;
+                if (retention === 0) {
+                    reportViolation("RDS Instances must have backups enabled.");
+                } else if (retention < minDays) {
+                    reportViolation(
+                        `RDS Instances must retain backups for at least ${minDays} days, but retain them for ${retention}.`);
+                }
             }
-        }
-        // Check the backup retention period. The backupRetentionPeriod of an instance defaults to 7 days.
-        if (backupRetentionPeriod) {
-            if ((!instance.backupRetentionPeriod && backupRetentionPeriod !== 7) ||
-                (instance.backupRetentionPeriod && backupRetentionPeriod !== instance.backupRetentionPeriod)) {
-                reportViolation(`RDS Instances must have a backup retention period of: ${backupRetentionPeriod}.`);
+            // Check the backup retention period. The backupRetentionPeriod of an instance defaults to 7 days.
+            if (backupRetentionPeriod) {
+                if ((!instance.backupRetentionPeriod && backupRetentionPeriod !== 7) ||
+                    (instance.backupRetentionPeriod && backupRetentionPeriod !== instance.backupRetentionPeriod)) {
+                    reportViolation(`RDS Instances must have a backup retention period of: ${backupRetentionPeriod}.`);
+                }
             }
-        }
-        // Check the preferred backup window.
-        if (preferredBackupWindow) {
-            if (!instance.backupWindow || preferredBackupWindow !== instance.backupWindow) {
-                reportViolation(`RDS Instances must have a backup preferred back up window of: ${preferredBackupWindow}.`);
+            // Check the preferred backup window.
+            if (preferredBackupWindow) {
+                if (!instance.backupWindow || preferredBackupWindow !== instance.backupWindow) {
+                    reportViolation(`RDS Instances must have a backup preferred back up window of: ${preferredBackupWindow}.`);
+                }
             }
-        }
-    }),
+        }),
+        // The instances of an Aurora cluster are backed up according to the cluster's settings.
+        validateResourceOfType(aws.rds.Cluster, (cluster, args, reportViolation) => {
+            const { backupRetentionPeriod, preferredBackupWindow, minBackupRetentionDays } =
+                args.getConfig<RdsInstanceBackupEnabledArgs>();
+            // The backupRetentionPeriod of a cluster defaults to 1 day, and backups can't be disabled.
+            const retention = cluster.backupRetentionPeriod !== undefined ? cluster.backupRetentionPeriod : 1;
+            if (minBackupRetentionDays !== undefined && retention < minBackupRetentionDays) {
+                reportViolation(`RDS Cluster '${args.name}' must retain backups for at least ${minBackupRetentionDays} ` +
+                    `days, but retains them for ${retention}.`);
+            }
+            if (backupRetentionPeriod && backupRetentionPeriod !== retention) {
+                reportViolation(`RDS Cluster '${args.name}' must have a backup retention period of: ${backupRetentionPeriod}.`);
+            }
+            if (preferredBackupWindow && preferredBackupWindow !== cluster.preferredBackupWindow) {
+                reportViolation(`RDS Cluster '${args.name}' must have a preferred backup window of: ${preferredBackupWindow}.`);
+            }
+        }),
+    ],
 };
 registerPolicy("rdsInstanceBackupEnabled", rdsInstanceBackupEnabled);
 
@@ -245,12 +507,21 @@ registerPolicy("rdsInstanceMultiAZEnabled", rdsInstanceMultiAZEnabled);
 /** @internal */
 export const rdsInstancePublicAccess: ResourceValidationPolicy = {
     name: "rds-instance-public-access",
-    description: "Check whether the Amazon Relational Database Service instances are not publicly accessible.",
-    validateResource: validateResourceOfType(aws.rds.Instance, (instance, _, reportViolation) => {
-        if (instance.publiclyAccessible) {
-            reportViolation("RDS Instance must not be publicly accessible.");
-        }
-    }),
+    description: "Check whether the Amazon Relational Database Service instances, including the instances of " +
+        "Aurora clusters, are not publicly accessible.",
+    validateResource: [
+        validateResourceOfType(aws.rds.Instance, (instance, _, reportViolation) => {
+            if (instance.publiclyAccessible) {
+                reportViolation("RDS Instance must not be publicly accessible.");
+            }
+        }),
+        // Aurora clusters are reached through their instances, which determine whether they are public.
+        validateResourceOfType(aws.rds.ClusterInstance, (instance, args, reportViolation) => {
+            if (instance.publiclyAccessible) {
+                reportViolation(`RDS Cluster Instance '${args.name}' must not be publicly accessible.`);
+            }
+        }),
+    ],
 };
 registerPolicy("rdsInstancePublicAccess", rdsInstancePublicAccess);
 
@@ -263,7 +534,7 @@ export interface RdsStorageEncryptedArgs {
 /** @internal */
 export const rdsStorageEncrypted: ResourceValidationPolicy = {
     name: "rds-storage-encrypted",
-    description: "Checks whether storage encryption is enabled for your RDS DB instances.",
+    description: "Checks whether storage encryption is enabled for your RDS DB instances and Aurora clusters.",
     configSchema: {
         properties: {
             kmsKeyId: {
@@ -271,18 +542,175 @@ export const rdsStorageEncrypted: ResourceValidationPolicy = {
             },
         },
     },
-    validateResource: validateResourceOfType(aws.rds.Instance, (instance, args, reportViolation) => {
-        const { kmsKeyId } = args.getConfig<RdsStorageEncryptedArgs>();
-        // Read replicas ignore this field and instead use the kmsId, so we will only check this
-        // if its not a read replica.
-        if (!instance.replicateSourceDb) {
-            if (instance.storageEncrypted === undefined || instance.storageEncrypted === false) {
-                reportViolation("RDS Instance must have storage encryption enabled.");
+    validateResource: [
+        validateResourceOfType(aws.rds.Instance, (instance, args, reportViolation) => {
+            const { kmsKeyId } = args.getConfig<RdsStorageEncryptedArgs>();
+            // Read replicas ignore this field and instead use the kmsId, so we will only check this
+            // if its not a read replica.
+            if (!instance.replicateSourceDb) {
+                if (instance.storageEncrypted === undefined || instance.storageEncrypted === false) {
+                    reportViolation("RDS Instance must have storage encryption enabled.");
+                }
+            }
+            if (kmsKeyId && (instance.kmsKeyId === undefined || instance.kmsKeyId !== kmsKeyId)) {
+                reportViolation(`RDS Instance must be encrypted with kms key id: ${kmsKeyId}.`);
+            }
+        }),
+        // The storage of an Aurora cluster's instances is encrypted according to the cluster's settings.
+        validateResourceOfType(aws.rds.Cluster, (cluster, args, reportViolation) => {
+            const { kmsKeyId } = args.getConfig<RdsStorageEncryptedArgs>();
+            // Serverless v1 clusters are always encrypted.
+            if (!cluster.storageEncrypted && cluster.engineMode !== "serverless") {
+                reportViolation(`RDS Cluster '${args.name}' must have storage encryption enabled.`);
+            }
+            if (kmsKeyId && cluster.kmsKeyId !== kmsKeyId) {
+                reportViolation(`RDS Cluster '${args.name}' must be encrypted with kms key id: ${kmsKeyId}.`);
+            }
+        }),
+    ],
+};
+registerPolicy("rdsStorageEncrypted", rdsStorageEncrypted);
+
+/** @internal */
+export const rdsPerformanceInsightsEncrypted: ResourceValidationPolicy = {
+    name: "rds-performance-insights-encrypted",
+    description: "Checks whether RDS DB instances and clusters with Performance Insights enabled encrypt the Performance Insights data with a KMS key.",
+    validateResource: [
+        validateResourceOfType(aws.rds.Instance, (instance, args, reportViolation) => {
+            if (instance.performanceInsightsEnabled && !instance.performanceInsightsKmsKeyId) {
+                reportViolation(`RDS Instance '${args.name}' must encrypt Performance Insights data with a KMS key.`);
             }
+        }),
+        validateResourceOfType(aws.rds.Cluster, (cluster, args, reportViolation) => {
+            if (cluster.performanceInsightsEnabled && !cluster.performanceInsightsKmsKeyId) {
+                reportViolation(`RDS Cluster '${args.name}' must encrypt Performance Insights data with a KMS key.`);
+            }
+        }),
+    ],
+};
+registerPolicy("rdsPerformanceInsightsEncrypted", rdsPerformanceInsightsEncrypted);
+
+export interface RdsCaCertificateCurrentArgs {
+    /**
+     * The CA certificate identifiers RDS DB instances may use. Defaults to the current CA bundles:
+     * "rds-ca-rsa2048-g1", "rds-ca-rsa4096-g1" and "rds-ca-ecc384-g1".
+     */
+    allowedCaCertIdentifiers?: string[];
+}
+
+const defaultAllowedCaCertIdentifiers = ["rds-ca-rsa2048-g1", "rds-ca-rsa4096-g1", "rds-ca-ecc384-g1"];
+
+/** @internal */
+export const rdsCaCertificateCurrent: ResourceValidationPolicy = {
+    name: "rds-ca-certificate-current",
+    description: "Checks whether RDS DB instances use a current CA certificate, rather than an expiring or deprecated " +
+        "one that would break TLS connections when it expires.",
+    configSchema: {
+        properties: {
+            allowedCaCertIdentifiers: {
+                type: "array",
+                items: { type: "string" },
+                default: defaultAllowedCaCertIdentifiers,
+            },
+        },
+    },
+    validateResource: validateResourceOfType(aws.rds.Instance, (instance, args, reportViolation) => {
+        const { allowedCaCertIdentifiers } = args.getConfig<RdsCaCertificateCurrentArgs>();
+
+        // Without an identifier, RDS uses its default CA, which is a current one for new instances.
+        const identifier = instance.caCertIdentifier;
+        if (identifier && !(allowedCaCertIdentifiers || defaultAllowedCaCertIdentifiers).includes(identifier)) {
+            reportViolation(`RDS Instance '${args.name}' uses the CA certificate '${identifier}', which is not a ` +
+                "current CA certificate. Rotate it to a current one to avoid TLS connection failures when it expires.");
         }
-        if (kmsKeyId && (instance.kmsKeyId === undefined || instance.kmsKeyId !== kmsKeyId)) {
-            reportViolation(`RDS Instance must be encrypted with kms key id: ${kmsKeyId}.`);
+    }),
+};
+registerPolicy("rdsCaCertificateCurrent", rdsCaCertificateCurrent);
+
+/** @internal */
+export const timestreamDatabaseKmsKey: ResourceValidationPolicy = {
+    name: "timestream-database-kms-key",
+    description: "Checks whether Amazon Timestream databases are encrypted with a customer managed KMS key.",
+    validateResource: validateResourceOfType(aws.timestreamwrite.Database, (database, args, reportViolation) => {
+        if (!database.kmsKeyId) {
+            reportViolation(`Timestream database '${args.name}' must be encrypted with a customer managed KMS key.`);
         }
     }),
 };
-registerPolicy("rdsStorageEncrypted", rdsStorageEncrypted);
+registerPolicy("timestreamDatabaseKmsKey", timestreamDatabaseKmsKey);
+
+/** @internal */
+export const timestreamMagneticStoreRejectedDataEncrypted: ResourceValidationPolicy = {
+    name: "timestream-magnetic-store-rejected-data-encrypted",
+    description: "Checks whether Amazon Timestream tables that allow magnetic store writes store rejected records " +
+        "in S3 encrypted with a KMS key.",
+    validateResource: validateResourceOfType(aws.timestreamwrite.Table, (table, args, reportViolation) => {
+        const properties = table.magneticStoreWriteProperties;
+        if (!properties || !properties.enableMagneticStoreWrites) {
+            return;
+        }
+        const location = properties.magneticStoreRejectedDataLocation;
+        const s3Configuration = location && location.s3Configuration;
+        if (!s3Configuration || s3Configuration.encryptionOption !== "SSE_KMS") {
+            reportViolation(
+                `Timestream table '${args.name}' allows magnetic store writes and must store rejected records ` +
+                "in S3 encrypted with a KMS key.");
+        }
+    }),
+};
+registerPolicy("timestreamMagneticStoreRejectedDataEncrypted", timestreamMagneticStoreRejectedDataEncrypted);
+
+export interface ElasticacheBackupRetentionArgs {
+    /** The minimum number of days snapshots must be retained for. Defaults to 1. */
+    minSnapshotRetentionDays?: number;
+}
+
+const defaultMinSnapshotRetentionDays = 1;
+
+/** @internal */
+export const elasticacheBackupRetention: ResourceValidationPolicy = {
+    name: "elasticache-backup-retention",
+    description: "Checks whether Amazon ElastiCache for Redis replication groups and clusters have automatic backups enabled, " +
+        "retaining snapshots for at least the specified number of days.",
+    configSchema: {
+        properties: {
+            minSnapshotRetentionDays: {
+                type: "number",
+                minimum: 1,
+                default: defaultMinSnapshotRetentionDays,
+            },
+        },
+    },
+    validateResource: [
+        validateResourceOfType(aws.elasticache.ReplicationGroup, (group, args, reportViolation) => {
+            checkSnapshotRetention("ElastiCache replication group", group.snapshotRetentionLimit, args, reportViolation);
+        }),
+        validateResourceOfType(aws.elasticache.Cluster, (cluster, args, reportViolation) => {
+            // Memcached doesn't support snapshots, and the snapshots of clusters that belong to a
+            // replication group are configured by the group.
+            if (cluster.engine === "memcached" || cluster.replicationGroupId) {
+                return;
+            }
+            checkSnapshotRetention("ElastiCache cluster", cluster.snapshotRetentionLimit, args, reportViolation);
+        }),
+    ],
+};
+registerPolicy("elasticacheBackupRetention", elasticacheBackupRetention);
+
+function checkSnapshotRetention(
+    kind: string,
+    snapshotRetentionLimit: number | undefined,
+    args: ResourceValidationArgs,
+    reportViolation: (message: string) => void) {
+
+    const { minSnapshotRetentionDays } = args.getConfig<ElasticacheBackupRetentionArgs>();
+    const minDays = minSnapshotRetentionDays !== undefined ? minSnapshotRetentionDays : defaultMinSnapshotRetentionDays;
+    // Automatic backups are disabled unless a snapshot retention limit is set.
+    const retention = snapshotRetentionLimit || 0;
+    if (retention === 0) {
+        reportViolation(`${kind} '${args.name}' must have automatic backups enabled.`);
+    } else if (retention < minDays) {
+        reportViolation(
+            `${kind} '${args.name}' must retain snapshots for at least ${minDays} days, but retains them for ${retention}.`);
+    }
+}
@@ -0,0 +1,236 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import * as aws from "@pulumi/aws";
+
+import {
+    EnforcementLevel,
+    ResourceValidationPolicy,
+    StackValidationPolicy,
+    validateResourceOfType,
+} from "@pulumi/policy";
+
+import { registerPolicy } from "./awsGuard";
+import { namePatternFormat } from "./configSchema";
+import { PolicyArgs } from "./policyArgs";
+import { isReferencedBy, matchesAnyPattern } from "./util";
+
+// Mixin additional properties onto AwsGuardArgs.
+declare module "./awsGuard" {
+    interface AwsGuardArgs {
+        /**
+         * Checks whether CodeBuild projects store credentials in plaintext environment variables rather than in Secrets
+         * Manager or Parameter Store.
+         *
+         * Enforcement level of the `codebuild-no-plaintext-credentials` policy, or its enforcement level and options:
+         * `secretNamePattern`.
+         */
+        codebuildNoPlaintextCredentials?: EnforcementLevel | (CodebuildNoPlaintextCredentialsArgs & PolicyArgs);
+
+        /**
+         * Checks whether CodeBuild projects run their build containers in privileged mode.
+         *
+         * Enforcement level of the `codebuild-privileged-mode` policy, or its enforcement level and options:
+         * `allowedProjectNames`.
+         */
+        codebuildPrivilegedMode?: EnforcementLevel | (CodebuildPrivilegedModeArgs & PolicyArgs);
+
+        /**
+         * Checks whether Amplify branches other than production branches require basic auth, rather than being publicly
+         * accessible.
+         *
+         * Enforcement level of the `amplify-branch-protection` policy, or its enforcement level and options:
+         * `publicBranchNames`.
+         */
+        amplifyBranchProtection?: EnforcementLevel | (AmplifyBranchProtectionArgs & PolicyArgs);
+
+        /**
+         * Checks whether CodePipeline artifact stores are encrypted with a customer managed KMS key.
+         *
+         * Enforcement level of the `codepipeline-artifact-encryption` policy, or its enforcement level and options:
+         * `requireCustomerManagedKey`.
+         */
+        codepipelineArtifactEncryption?: EnforcementLevel | (CodepipelineArtifactEncryptionArgs & PolicyArgs);
+    }
+}
+
+export interface CodebuildNoPlaintextCredentialsArgs {
+    /**
+     * A regular expression, matched case-insensitively, for the names of environment variables that hold
+     * credentials. Defaults to "PASSWORD|SECRET|TOKEN|KEY".
+     */
+    secretNamePattern?: string;
+}
+
+const defaultSecretNamePattern = "PASSWORD|SECRET|TOKEN|KEY";
+
+/** @internal */
+export const codebuildNoPlaintextCredentials: ResourceValidationPolicy = {
+    name: "codebuild-no-plaintext-credentials",
+    description: "Checks whether CodeBuild projects store credentials in plaintext environment variables " +
+        "rather than in Secrets Manager or Parameter Store.",
+    configSchema: {
+        properties: {
+            secretNamePattern: {
+                type: "string",
+                default: defaultSecretNamePattern,
+            },
+        },
+    },
+    validateResource: validateResourceOfType(aws.codebuild.Project, (project, args, reportViolation) => {
+        const { secretNamePattern } = args.getConfig<CodebuildNoPlaintextCredentialsArgs>();
+        const secretNameRE = new RegExp(secretNamePattern || defaultSecretNamePattern, "i");
+
+        const variables = (project.environment && project.environment.environmentVariables) || [];
+        for (const variable of variables) {
+            // Environment variables are PLAINTEXT unless a type is specified.
+            const type = variable.type || "PLAINTEXT";
+            if (type === "PLAINTEXT" && secretNameRE.test(variable.name)) {
+                reportViolation(
+                    `CodeBuild project '${args.name}' must not store credentials in the plaintext environment variable ` +
+                    `'${variable.name}'. Use the SECRETS_MANAGER or PARAMETER_STORE type instead.`);
+            }
+        }
+    }),
+};
+registerPolicy("codebuildNoPlaintextCredentials", codebuildNoPlaintextCredentials);
+
+export interface CodebuildPrivilegedModeArgs {
+    /**
+     * Names or name patterns of projects (resource names or project names) that may run in privileged
+     * mode, e.g. projects that build Docker images.
+     */
+    allowedProjectNames?: string[];
+}
+
+/** @internal */
+export const codebuildPrivilegedMode: ResourceValidationPolicy = {
+    name: "codebuild-privileged-mode",
+    description: "Checks whether CodeBuild projects run their build containers in privileged mode.",
+    enforcementLevel: "advisory",
+    configSchema: {
+        properties: {
+            allowedProjectNames: {
+                type: "array",
+                items: { type: "string", format: namePatternFormat },
+                default: [],
+            },
+        },
+    },
+    validateResource: validateResourceOfType(aws.codebuild.Project, (project, args, reportViolation) => {
+        const { allowedProjectNames } = args.getConfig<CodebuildPrivilegedModeArgs>();
+
+        if (!project.environment || !project.environment.privilegedMode) {
+            return;
+        }
+        if (matchesAnyPattern([args.name, project.name], allowedProjectNames)) {
+            return;
+        }
+        reportViolation(`CodeBuild project '${args.name}' must not run in privileged mode.`);
+    }),
+};
+registerPolicy("codebuildPrivilegedMode", codebuildPrivilegedMode);
+
+export interface AmplifyBranchProtectionArgs {
+    /**
+     * Names or name patterns of branches that are meant to be publicly accessible, e.g. production branches.
+     * Defaults to "main", "master", "prod" and "production".
+     */
+    publicBranchNames?: string[];
+}
+
+const defaultPublicBranchNames = ["main", "master", "prod", "production"];
+
+/** @internal */
+export const amplifyBranchProtection: StackValidationPolicy = {
+    name: "amplify-branch-protection",
+    description: "Checks whether Amplify branches other than production branches require basic auth, " +
+        "rather than being publicly accessible.",
+    enforcementLevel: "advisory",
+    configSchema: {
+        properties: {
+            publicBranchNames: {
+                type: "array",
+                items: { type: "string", format: namePatternFormat },
+                default: defaultPublicBranchNames,
+            },
+        },
+    },
+    validateStack: (args, reportViolation) => {
+        const { publicBranchNames } = args.getConfig<AmplifyBranchProtectionArgs>();
+        const publicNames = publicBranchNames || defaultPublicBranchNames;
+
+        const apps = args.resources.filter(r => r.isType(aws.amplify.App));
+        for (const branch of args.resources.filter(r => r.isType(aws.amplify.Branch))) {
+            const branchName = branch.props.branchName || branch.name;
+            if (matchesAnyPattern(branchName, publicNames) || branch.props.enableBasicAuth) {
+                continue;
+            }
+            // Basic auth enabled for the app applies to all of its branches.
+            const app = apps.find(a => isReferencedBy(a, branch, "appId", ["id", "appId"]));
+            if (app && app.props.enableBasicAuth) {
+                continue;
+            }
+            reportViolation(`Amplify branch '${branchName}' is publicly accessible and should require basic auth.`, branch.urn);
+        }
+
+        for (const app of apps) {
+            const autoBranch = app.props.autoBranchCreationConfig;
+            if (app.props.enableAutoBranchCreation && !app.props.enableBasicAuth &&
+                !(autoBranch && autoBranch.enableBasicAuth)) {
+                reportViolation(
+                    `Amplify app '${app.name}' automatically creates publicly accessible branches and should ` +
+                    "require basic auth for them.", app.urn);
+            }
+        }
+    },
+};
+registerPolicy("amplifyBranchProtection", amplifyBranchProtection);
+
+export interface CodepipelineArtifactEncryptionArgs {
+    /**
+     * If true, pipeline artifact stores must be encrypted with a customer managed KMS key rather
+     * than the AWS managed key they use by default. Defaults to true.
+     */
+    requireCustomerManagedKey?: boolean;
+}
+
+/** @internal */
+export const codepipelineArtifactEncryption: ResourceValidationPolicy = {
+    name: "codepipeline-artifact-encryption",
+    description: "Checks whether CodePipeline artifact stores are encrypted with a customer managed KMS key.",
+    configSchema: {
+        properties: {
+            requireCustomerManagedKey: {
+                type: "boolean",
+                default: true,
+            },
+        },
+    },
+    validateResource: validateResourceOfType(aws.codepipeline.Pipeline, (pipeline, args, reportViolation) => {
+        const { requireCustomerManagedKey } = args.getConfig<CodepipelineArtifactEncryptionArgs>();
+        if (requireCustomerManagedKey === false) {
+            return;
+        }
+
+        for (const store of pipeline.artifactStores || []) {
+            if (!store.encryptionKey || !store.encryptionKey.id) {
+                reportViolation(
+                    `CodePipeline pipeline '${args.name}' must encrypt its artifact store '${store.location}' ` +
+                    "with a customer managed KMS key.");
+            }
+        }
+    }),
+};
+registerPolicy("codepipelineArtifactEncryption", codepipelineArtifactEncryption);
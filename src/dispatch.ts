@@ -0,0 +1,48 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import {
+    ResourceValidation,
+    ResourceValidationPolicy,
+    StackValidation,
+    StackValidationPolicy,
+} from "@pulumi/policy";
+
+/** @internal */
+export type Policy = ResourceValidationPolicy | StackValidationPolicy;
+
+/** @internal */
+export function isResourceValidationPolicy(policy: Policy): policy is ResourceValidationPolicy {
+    return (<ResourceValidationPolicy>policy).validateResource !== undefined;
+}
+
+/**
+ * Wraps the validation functions of a policy. This is how AwsGuard hooks into the dispatch of
+ * every policy uniformly, without the policies themselves needing to know about it. Returns a
+ * copy of the policy; the original is left unmodified.
+ * @internal
+ */
+export function wrapValidations(
+    policy: Policy,
+    wrapResource: (validation: ResourceValidation) => ResourceValidation,
+    wrapStack: (validation: StackValidation) => StackValidation): Policy {
+
+    if (isResourceValidationPolicy(policy)) {
+        const validateResource = Array.isArray(policy.validateResource)
+            ? policy.validateResource.map(wrapResource)
+            : wrapResource(policy.validateResource);
+        return { ...policy, validateResource };
+    }
+    return { ...policy, validateStack: wrapStack(policy.validateStack) };
+}
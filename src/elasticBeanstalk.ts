@@ -0,0 +1,131 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+
+import * as aws from "@pulumi/aws";
+
+import { EnforcementLevel, ResourceValidationPolicy, validateResourceOfType } from "@pulumi/policy";
+
+import { registerPolicy } from "./awsGuard";
+import { namePatternFormat } from "./configSchema";
+import { PolicyArgs } from "./policyArgs";
+import { matchesAnyPattern } from "./util";
+
+// Elastic Beanstalk policies are kept here, all advisory and named "elasticbeanstalk-*", so they
+// can be disabled as a set.
+
+// Mixin additional properties onto AwsGuardArgs.
+declare module "./awsGuard" {
+    interface AwsGuardArgs {
+        /**
+         * Checks whether Elastic Beanstalk environments have managed platform updates enabled, so that they receive
+         * patches and minor platform updates during a maintenance window.
+         *
+         * Enforcement level of the `elasticbeanstalk-managed-updates` policy.
+         */
+        elasticbeanstalkManagedUpdates?: EnforcementLevel;
+
+        /**
+         * Checks whether Elastic Beanstalk environments use a deprecated platform version.
+         *
+         * Enforcement level of the `elasticbeanstalk-platform-version` policy, or its enforcement level and options:
+         * `deprecatedPlatforms`.
+         */
+        elasticbeanstalkPlatformVersion?: EnforcementLevel | (ElasticbeanstalkPlatformVersionArgs & PolicyArgs);
+    }
+}
+
+interface Setting {
+    namespace: string;
+    name: string;
+    value: string;
+}
+
+function getSetting(settings: Setting[] | undefined, namespace: string, name: string): string | undefined {
+    const setting = (settings || []).find(s => s.namespace === namespace && s.name === name);
+    return setting ? setting.value : undefined;
+}
+
+function checkManagedUpdates(
+    kind: string, name: string, settings: Setting[] | undefined, reportViolation: (message: string) => void) {
+
+    const enabled = getSetting(settings, "aws:elasticbeanstalk:managedactions", "ManagedActionsEnabled");
+    if (!enabled || enabled.toLowerCase() !== "true") {
+        reportViolation(`Elastic Beanstalk ${kind} '${name}' should have managed platform updates enabled.`);
+    }
+}
+
+/** @internal */
+export const elasticbeanstalkManagedUpdates: ResourceValidationPolicy = {
+    name: "elasticbeanstalk-managed-updates",
+    description: "Checks whether Elastic Beanstalk environments have managed platform updates enabled, so that " +
+        "they receive patches and minor platform updates during a maintenance window.",
+    enforcementLevel: "advisory",
+    validateResource: [
+        validateResourceOfType(aws.elasticbeanstalk.Environment, (environment, args, reportViolation) => {
+            // Environments created from a configuration template get their settings from it, which is checked instead.
+            if (environment.templateName) {
+                return;
+            }
+            checkManagedUpdates("environment", args.name, environment.settings, reportViolation);
+        }),
+        validateResourceOfType(aws.elasticbeanstalk.ConfigurationTemplate, (template, args, reportViolation) => {
+            checkManagedUpdates("configuration template", args.name, template.settings, reportViolation);
+        }),
+    ],
+};
+registerPolicy("elasticbeanstalkManagedUpdates", elasticbeanstalkManagedUpdates);
+
+export interface ElasticbeanstalkPlatformVersionArgs {
+    /**
+     * Glob or `/regex/` patterns for the solution stack names and platform ARNs of deprecated
+     * platforms. Defaults to retired platforms such as the Amazon Linux AMI, Windows Server 2012 and
+     * end-of-life language runtimes.
+     */
+    deprecatedPlatforms?: string[];
+}
+
+const defaultDeprecatedPlatforms = [
+    "*Amazon Linux AMI*", "*Amazon Linux 201*", "*Windows Server 2012*",
+    "*Node.js 10*", "*Node.js 12*", "*Node.js 14*", "*Python 3.6*", "*Python 3.7*", "*PHP 7.*", "*Ruby 2.*",
+];
+
+/** @internal */
+export const elasticbeanstalkPlatformVersion: ResourceValidationPolicy = {
+    name: "elasticbeanstalk-platform-version",
+    description: "Checks whether Elastic Beanstalk environments use a deprecated platform version.",
+    enforcementLevel: "advisory",
+    configSchema: {
+        properties: {
+            deprecatedPlatforms: {
+                type: "array",
+                items: { type: "string", format: namePatternFormat },
+                default: defaultDeprecatedPlatforms,
+            },
+        },
+    },
+    validateResource: validateResourceOfType(aws.elasticbeanstalk.Environment, (environment, args, reportViolation) => {
+        const { deprecatedPlatforms } = args.getConfig<ElasticbeanstalkPlatformVersionArgs>();
+        const platform = environment.solutionStackName || environment.platformArn;
+        if (!platform) {
+            return;
+        }
+        if (matchesAnyPattern(platform, deprecatedPlatforms || defaultDeprecatedPlatforms)) {
+            reportViolation(
+                `Elastic Beanstalk environment '${args.name}' uses the deprecated platform '${platform}'. ` +
+                "Upgrade it to a supported platform version.");
+        }
+    }),
+};
+registerPolicy("elasticbeanstalkPlatformVersion", elasticbeanstalkPlatformVersion);
@@ -21,8 +21,27 @@ import { registerPolicy } from "./awsGuard";
 // Mixin additional properties onto AwsGuardArgs.
 declare module "./awsGuard" {
     interface AwsGuardArgs {
+        /**
+         * Checks if the Elasticsearch Service domains have encryption at rest enabled.
+         *
+         * Enforcement level of the `elasticsearch-encrypted-at-rest` policy.
+         */
         elasticsearchEncryptedAtRest?: EnforcementLevel;
+
+        /**
+         * Checks that the Elasticsearch domain is only available within a VPC, and not accessible via a public
+         * endpoint.
+         *
+         * Enforcement level of the `elasticsearch-in-vpc-only` policy.
+         */
         elasticsearchInVpcOnly?: EnforcementLevel;
+
+        /**
+         * Checks that Elasticsearch and OpenSearch domains publish audit logs.
+         *
+         * Enforcement level of the `elasticsearch-audit-logs-enabled` policy.
+         */
+        elasticsearchAuditLogsEnabled?: EnforcementLevel;
     }
 }
 
@@ -49,3 +68,28 @@ export const elasticsearchInVpcOnly: ResourceValidationPolicy = {
     }),
 };
 registerPolicy("elasticsearchInVpcOnly", elasticsearchInVpcOnly);
+
+// Returns true if the log publishing options include enabled audit logs. Options are enabled unless
+// explicitly disabled.
+function hasAuditLogs(logPublishingOptions: { logType: string, enabled?: boolean }[] | undefined): boolean {
+    return (logPublishingOptions || []).some(option => option.logType === "AUDIT_LOGS" && option.enabled !== false);
+}
+
+/** @internal */
+export const elasticsearchAuditLogsEnabled: ResourceValidationPolicy = {
+    name: "elasticsearch-audit-logs-enabled",
+    description: "Checks that Elasticsearch and OpenSearch domains publish audit logs.",
+    validateResource: [
+        validateResourceOfType(aws.elasticsearch.Domain, (domain, args, reportViolation) => {
+            if (!hasAuditLogs(domain.logPublishingOptions)) {
+                reportViolation(`Elasticsearch domain ${domain.domainName || args.name} must have audit logs enabled.`);
+            }
+        }),
+        validateResourceOfType(aws.opensearch.Domain, (domain, args, reportViolation) => {
+            if (!hasAuditLogs(domain.logPublishingOptions)) {
+                reportViolation(`OpenSearch domain ${domain.domainName || args.name} must have audit logs enabled.`);
+            }
+        }),
+    ],
+};
+registerPolicy("elasticsearchAuditLogsEnabled", elasticsearchAuditLogsEnabled);
@@ -12,7 +12,9 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-import { EnforcementLevel } from "@pulumi/policy";
+import { EnforcementLevel, PolicyResource } from "@pulumi/policy";
+
+import { Policy, wrapValidations } from "./dispatch";
 
 /** @internal */
 export const defaultEnforcementLevel: EnforcementLevel = "advisory";
@@ -35,3 +37,59 @@ export function isEnforcementLevel(o: any): o is EnforcementLevel {
     }
     return false;
 }
+
+/**
+ * Determines the enforcement level of a policy for a particular resource, e.g. to make a policy
+ * mandatory for resources tagged for production, but advisory elsewhere.
+ */
+export type EnforcementLevelCallback =
+    (resource: Pick<PolicyResource, "type" | "name" | "urn" | "props">) => EnforcementLevel;
+
+/**
+ * Returns the enforcement level a policy runs with after applying AwsGuard's `strict` option, which
+ * promotes advisory policies to mandatory, or its `relaxed` option, which demotes mandatory policies
+ * to advisory. Disabled policies stay disabled.
+ * @internal
+ */
+export function adjustEnforcementLevel(
+    level: EnforcementLevel, options: { strict?: boolean, relaxed?: boolean }): EnforcementLevel {
+
+    if (options.strict && level === "advisory") {
+        return "mandatory";
+    }
+    if (options.relaxed && level === "mandatory") {
+        return "advisory";
+    }
+    return level;
+}
+
+/** @internal */
+export const advisoryPolicySuffix = "-advisory";
+
+/**
+ * Splits a policy into a mandatory policy with the original name and an advisory companion policy
+ * with `advisoryPolicySuffix` appended to the name. A violation is reported by whichever of the
+ * two matches the callback's enforcement level for the violating resource, and by neither if the
+ * callback returns "disabled". Violations of stack policies that aren't associated with a resource
+ * use `defaultLevel`.
+ * @internal
+ */
+export function splitByEnforcementLevel(
+    policy: Policy, callback: EnforcementLevelCallback, defaultLevel: EnforcementLevel): [Policy, Policy] {
+
+    const only = (level: EnforcementLevel): Policy => wrapValidations(policy,
+        validation => (args, reportViolation) =>
+            callback(args) === level ? validation(args, reportViolation) : undefined,
+        validation => (args, reportViolation) => validation(args, (message, urn) => {
+            const resource = urn ? args.resources.find(r => r.urn === urn) : undefined;
+            if ((resource ? callback(resource) : defaultLevel) === level) {
+                reportViolation(message, urn);
+            }
+        }),
+    );
+
+    return [
+        { ...only("mandatory"), enforcementLevel: "mandatory" },
+        { ...only("advisory"), name: policy.name + advisoryPolicySuffix, enforcementLevel: "advisory" },
+    ];
+}
@@ -0,0 +1,84 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import { ResourceValidation } from "@pulumi/policy";
+
+import { isResourceValidationPolicy, Policy, wrapValidations } from "./dispatch";
+
+/**
+ * The environment variable used to enable explain mode. When set, the pack logs, for each resource
+ * analyzed, whether each policy checked it and whether it passed or failed. This doesn't change
+ * which violations are reported or how they are enforced. Resources a policy skips because it doesn't
+ * apply in their region, they are out of the tag scope, or their values are unknown aren't logged.
+ */
+export const explainEnvVar = "AWSGUARD_EXPLAIN";
+
+// Combines a policy's resource validations into one, so that the outcome of the policy as a whole
+// can be logged rather than that of each of its validations.
+function combineResourceValidations(validations: ResourceValidation[]): ResourceValidation {
+    return async (args, reportViolation) => {
+        for (const validation of validations) {
+            await validation(args, reportViolation);
+        }
+    };
+}
+
+/**
+ * Returns a copy of the policy that logs its outcome for each resource, or the stack, with `log`.
+ * A resource policy is considered to have checked a resource if any of its validations asked
+ * whether the resource is of the type it validates, and the answer was yes; otherwise, the
+ * resource was skipped.
+ * @internal
+ */
+export function withExplanations(policy: Policy, log: (message: string) => void = console.error): Policy {
+    if (isResourceValidationPolicy(policy) && Array.isArray(policy.validateResource)) {
+        policy = { ...policy, validateResource: combineResourceValidations(policy.validateResource) };
+    }
+
+    const outcome = (checked: boolean, violations: number) => !checked
+        ? "skipped (resource type not checked by this policy)"
+        : violations === 0 ? "passed" : `failed (${violations} violation(s))`;
+
+    return wrapValidations(policy,
+        validation => async (args, reportViolation) => {
+            let checked = false;
+            let violations = 0;
+            await validation({
+                ...args,
+                isType: cls => {
+                    const result = args.isType(cls);
+                    checked = checked || result;
+                    return result;
+                },
+                asType: cls => {
+                    const result: any = args.asType(cls);
+                    checked = checked || result !== undefined;
+                    return result;
+                },
+            }, (message, urn) => {
+                violations++;
+                reportViolation(message, urn);
+            });
+            log(`awsguard explain: ${args.urn}: ${policy.name} ${outcome(checked, violations)}`);
+        },
+        validation => async (args, reportViolation) => {
+            let violations = 0;
+            await validation(args, (message, urn) => {
+                violations++;
+                reportViolation(message, urn);
+            });
+            log(`awsguard explain: stack: ${policy.name} ${outcome(true, violations)}`);
+        },
+    );
+}
@@ -0,0 +1,55 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+
+import { EnforcementLevel } from "@pulumi/policy";
+
+import { Policy, wrapValidations } from "./dispatch";
+
+/**
+ * Returns a copy of the policy that skips resources in `failedUrns`, the resources that already have a
+ * mandatory violation. If the policy is mandatory, resources it reports violations for are added to
+ * `failedUrns`. Stack validations check every resource at once, so they're skipped once any resource
+ * has a mandatory violation. Policies run concurrently, so this is best effort: policies that have
+ * already started checking a resource still report their violations.
+ * @internal
+ */
+export function withFailFast(policy: Policy, level: EnforcementLevel, failedUrns: Set<string>): Policy {
+    const fail = (urn: string | undefined) => {
+        if (level === "mandatory" && urn) {
+            failedUrns.add(urn);
+        }
+    };
+
+    return wrapValidations(policy,
+        validation => (args, reportViolation) => {
+            if (failedUrns.has(args.urn)) {
+                return;
+            }
+            return validation(args, (message, urn) => {
+                fail(urn || args.urn);
+                reportViolation(message, urn);
+            });
+        },
+        validation => (args, reportViolation) => {
+            if (failedUrns.size > 0) {
+                return;
+            }
+            return validation(args, (message, urn) => {
+                fail(urn);
+                reportViolation(message, urn);
+            });
+        },
+    );
+}
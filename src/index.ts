@@ -15,10 +15,17 @@
 import { AwsGuard, AwsGuardArgs } from "./awsGuard";
 
 // Import each area to add AwsGuardArgs mixins and register policies.
+import "./analytics";
 import "./apiGateway";
+import "./applicationIntegration";
 import "./compute";
 import "./database";
+import "./developerTools";
+import "./elasticBeanstalk";
 import "./elasticsearch";
+import "./lightsail";
+import "./machineLearning";
+import "./management";
 import "./network";
 import "./security";
 import "./storage";
@@ -0,0 +1,152 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+
+import * as aws from "@pulumi/aws";
+
+import {
+    EnforcementLevel,
+    ResourceValidationPolicy,
+    StackValidationPolicy,
+    validateResourceOfType,
+} from "@pulumi/policy";
+
+import { registerPolicy } from "./awsGuard";
+import { groupedByResource } from "./messages";
+import { PolicyArgs } from "./policyArgs";
+import { isReferencedBy } from "./util";
+
+// Lightsail is often used outside of the governance applied to the rest of an AWS account. Its
+// policies are kept here, all advisory and named "lightsail-*", so they can be disabled as a set.
+
+// Mixin additional properties onto AwsGuardArgs.
+declare module "./awsGuard" {
+    interface AwsGuardArgs {
+        /**
+         * Checks whether Lightsail instances open ports other than HTTP and HTTPS to any address, and whether Lightsail
+         * databases are publicly accessible.
+         *
+         * Enforcement level of the `lightsail-public-access` policy, or its enforcement level and options:
+         * `allowedPublicPorts`.
+         */
+        lightsailPublicAccess?: EnforcementLevel | (LightsailPublicAccessArgs & PolicyArgs);
+
+        /**
+         * Checks whether Lightsail instances have automatic snapshots enabled, and Lightsail databases retain automated
+         * backups.
+         *
+         * Enforcement level of the `lightsail-automatic-snapshots` policy.
+         */
+        lightsailAutomaticSnapshots?: EnforcementLevel;
+    }
+}
+
+export interface LightsailPublicAccessArgs {
+    /**
+     * Ports that instances may open to any address. Defaults to 80 and 443.
+     */
+    allowedPublicPorts?: number[];
+}
+
+const defaultAllowedPublicPorts = [80, 443];
+
+// Returns true if the port info of an aws.lightsail.InstancePublicPorts resource opens its ports
+// to any address. Ports without any CIDR blocks or aliases are open to any address.
+function isOpenToAnyAddress(portInfo: any): boolean {
+    const cidrs: string[] = portInfo.cidrs || [];
+    const ipv6Cidrs: string[] = portInfo.ipv6Cidrs || [];
+    const aliases: string[] = portInfo.cidrListAliases || [];
+    if (cidrs.length === 0 && ipv6Cidrs.length === 0 && aliases.length === 0) {
+        return true;
+    }
+    return cidrs.includes("0.0.0.0/0") || ipv6Cidrs.includes("::/0");
+}
+
+/** @internal */
+export const lightsailPublicAccess: StackValidationPolicy = {
+    name: "lightsail-public-access",
+    description: "Checks whether Lightsail instances open ports other than HTTP and HTTPS to any address, " +
+        "and whether Lightsail databases are publicly accessible.",
+    enforcementLevel: "advisory",
+    configSchema: {
+        properties: {
+            allowedPublicPorts: {
+                type: "array",
+                items: { type: "number" },
+                default: defaultAllowedPublicPorts,
+            },
+        },
+    },
+    validateStack: groupedByResource((args, reportViolation) => {
+        const { allowedPublicPorts } = args.getConfig<LightsailPublicAccessArgs>();
+        const allowed = allowedPublicPorts || defaultAllowedPublicPorts;
+
+        const publicPorts = args.resources.filter(r => r.isType(aws.lightsail.InstancePublicPorts));
+        for (const instance of args.resources.filter(r => r.isType(aws.lightsail.Instance))) {
+            const ports = publicPorts.filter(p => isReferencedBy(instance, p, "instanceName", ["name"]));
+            if (ports.length === 0) {
+                reportViolation(`Lightsail instance '${instance.name}' uses the default firewall, which allows ` +
+                    "SSH from any address. Manage its ports with an aws.lightsail.InstancePublicPorts resource.",
+                    instance.urn);
+                continue;
+            }
+
+            for (const portInfo of ports.map(p => p.props.portInfos || []).reduce((a, b) => a.concat(b), [])) {
+                const from: number = portInfo.fromPort;
+                const to: number = portInfo.toPort;
+                if (!isOpenToAnyAddress(portInfo) || typeof from !== "number" || typeof to !== "number") {
+                    continue;
+                }
+                // The range is only allowed if every port in it is.
+                const allowedInRange = allowed.filter(port => port >= from && port <= to).length;
+                if (allowedInRange < to - from + 1) {
+                    const range = from === to ? `port ${from}` : `ports ${from}-${to}`;
+                    reportViolation(`Lightsail instance '${instance.name}' should not open ${range} to any address.`,
+                        instance.urn);
+                }
+            }
+        }
+
+        for (const database of args.resources.filter(r => r.isType(aws.lightsail.Database))) {
+            if (database.props.publiclyAccessible) {
+                reportViolation(`Lightsail database '${database.name}' should not be publicly accessible.`,
+                    database.urn);
+            }
+        }
+    }),
+};
+registerPolicy("lightsailPublicAccess", lightsailPublicAccess);
+
+/** @internal */
+export const lightsailAutomaticSnapshots: ResourceValidationPolicy = {
+    name: "lightsail-automatic-snapshots",
+    description: "Checks whether Lightsail instances have automatic snapshots enabled, and Lightsail databases " +
+        "retain automated backups.",
+    enforcementLevel: "advisory",
+    validateResource: [
+        validateResourceOfType(aws.lightsail.Instance, (instance, args, reportViolation) => {
+            const addOn = instance.addOn;
+            if (!addOn || addOn.type !== "AutoSnapshot" || addOn.status !== "Enabled") {
+                reportViolation(`Lightsail instance '${args.name}' should have automatic snapshots enabled.`);
+            }
+        }),
+        validateResourceOfType(aws.lightsail.Database, (database, args, reportViolation) => {
+            // Automated backups are retained unless explicitly disabled.
+            if (database.backupRetentionEnabled === false) {
+                reportViolation(`Lightsail database '${args.name}' should retain automated backups.`);
+            }
+        }),
+    ],
+};
+registerPolicy("lightsailAutomaticSnapshots", lightsailAutomaticSnapshots);
@@ -0,0 +1,96 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import * as aws from "@pulumi/aws";
+
+import { EnforcementLevel, ResourceValidationPolicy, validateResourceOfType } from "@pulumi/policy";
+
+import { registerPolicy } from "./awsGuard";
+import { PolicyArgs } from "./policyArgs";
+
+// Mixin additional properties onto AwsGuardArgs.
+declare module "./awsGuard" {
+    interface AwsGuardArgs {
+        /**
+         * Checks whether SageMaker notebook instances have direct internet access disabled. Optionally checks that root
+         * access is disabled and that a KMS key is used for encryption.
+         *
+         * Enforcement level of the `sagemaker-notebook-no-direct-internet` policy, or its enforcement level and
+         * options: `requireRootAccessDisabled`, `requireKmsKey`.
+         */
+        sagemakerNotebookNoDirectInternet?: EnforcementLevel | (SagemakerNotebookNoDirectInternetArgs & PolicyArgs);
+
+        /**
+         * Checks whether SageMaker endpoint configurations specify a KMS key to encrypt the storage attached to their
+         * instances.
+         *
+         * Enforcement level of the `sagemaker-endpoint-config-encryption` policy.
+         */
+        sagemakerEndpointConfigEncryption?: EnforcementLevel;
+    }
+}
+
+export interface SagemakerNotebookNoDirectInternetArgs {
+    /** If true, notebook instances must have root access disabled. Defaults to false. */
+    requireRootAccessDisabled?: boolean;
+
+    /** If true, notebook instances must specify a KMS key to encrypt their storage. Defaults to false. */
+    requireKmsKey?: boolean;
+}
+
+/** @internal */
+export const sagemakerNotebookNoDirectInternet: ResourceValidationPolicy = {
+    name: "sagemaker-notebook-no-direct-internet",
+    description: "Checks whether SageMaker notebook instances have direct internet access disabled. " +
+        "Optionally checks that root access is disabled and that a KMS key is used for encryption.",
+    configSchema: {
+        properties: {
+            requireRootAccessDisabled: {
+                type: "boolean",
+                default: false,
+            },
+            requireKmsKey: {
+                type: "boolean",
+                default: false,
+            },
+        },
+    },
+    validateResource: validateResourceOfType(aws.sagemaker.NotebookInstance, (instance, args, reportViolation) => {
+        const { requireRootAccessDisabled, requireKmsKey } = args.getConfig<SagemakerNotebookNoDirectInternetArgs>();
+
+        // Both direct internet access and root access are enabled by default.
+        if (instance.directInternetAccess === undefined || instance.directInternetAccess === "Enabled") {
+            reportViolation(`SageMaker notebook instance '${args.name}' must not have direct internet access.`);
+        }
+        if (requireRootAccessDisabled && instance.rootAccess !== "Disabled") {
+            reportViolation(`SageMaker notebook instance '${args.name}' must have root access disabled.`);
+        }
+        if (requireKmsKey && !instance.kmsKeyId) {
+            reportViolation(`SageMaker notebook instance '${args.name}' must be encrypted with a KMS key.`);
+        }
+    }),
+};
+registerPolicy("sagemakerNotebookNoDirectInternet", sagemakerNotebookNoDirectInternet);
+
+/** @internal */
+export const sagemakerEndpointConfigEncryption: ResourceValidationPolicy = {
+    name: "sagemaker-endpoint-config-encryption",
+    description: "Checks whether SageMaker endpoint configurations specify a KMS key to encrypt the storage attached to their instances.",
+    validateResource: validateResourceOfType(aws.sagemaker.EndpointConfiguration, (endpointConfig, args, reportViolation) => {
+        if (!endpointConfig.kmsKeyArn) {
+            reportViolation(`SageMaker endpoint configuration '${args.name}' must be encrypted with a KMS key.`);
+        }
+    }),
+};
+registerPolicy("sagemakerEndpointConfigEncryption", sagemakerEndpointConfigEncryption);
@@ -0,0 +1,184 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+
+import * as aws from "@pulumi/aws";
+
+import {
+    EnforcementLevel,
+    PolicyProviderResource,
+    PolicyResource,
+    ResourceValidationPolicy,
+    StackValidationPolicy,
+    validateResourceOfType,
+} from "@pulumi/policy";
+import { Resource } from "@pulumi/pulumi";
+
+import { registerPolicy } from "./awsGuard";
+import { PolicyArgs } from "./policyArgs";
+
+// Mixin additional properties onto AwsGuardArgs.
+declare module "./awsGuard" {
+    interface AwsGuardArgs {
+        /**
+         * Checks whether CloudFormation stacks are deployed from the Pulumi program. Mixing infrastructure as code
+         * tools makes it harder to audit what is deployed and how.
+         *
+         * Enforcement level of the `no-inline-cloudformation` policy.
+         */
+        noInlineCloudformation?: EnforcementLevel;
+
+        /**
+         * Checks whether resources of the configured types have deletion or termination protection enabled.
+         *
+         * Enforcement level of the `deletion-protection-required` policy, or its enforcement level and options:
+         * `resourceTypes`.
+         */
+        deletionProtectionRequired?: EnforcementLevel | (DeletionProtectionRequiredArgs & PolicyArgs);
+
+        /**
+         * Checks whether AWS resources are deployed with a provider that sets its region explicitly, rather than one
+         * that picks up the region from the environment.
+         *
+         * Enforcement level of the `explicit-provider-region` policy.
+         */
+        explicitProviderRegion?: EnforcementLevel;
+    }
+}
+
+/** @internal */
+export const noInlineCloudformation: ResourceValidationPolicy = {
+    name: "no-inline-cloudformation",
+    description: "Checks whether CloudFormation stacks are deployed from the Pulumi program. Mixing infrastructure " +
+        "as code tools makes it harder to audit what is deployed and how.",
+    enforcementLevel: "advisory",
+    validateResource: validateResourceOfType(aws.cloudformation.Stack, (_, args, reportViolation) => {
+        reportViolation(`CloudFormation stack '${args.name}' should be managed directly with Pulumi resources ` +
+            "rather than deployed from the Pulumi program.");
+    }),
+};
+registerPolicy("noInlineCloudformation", noInlineCloudformation);
+
+export interface DeletionProtectionRequiredArgs {
+    /**
+     * The types of resources that must have deletion protection enabled: "rds" (DB instances and clusters),
+     * "dynamodb" (tables), "elb" (load balancers), "docdb" (clusters), "neptune" (clusters) or "ec2"
+     * (instance termination protection). CloudFront distributions and EKS clusters have no deletion
+     * protection setting, so they can't be required to have it. Defaults to none.
+     */
+    resourceTypes?: string[];
+}
+
+interface DeletionProtectionCheck {
+    resourceClass: { new(...rest: any[]): Resource };
+    description: string;
+    attribute: string;
+}
+
+// The resources checked for each of the resource types that may be configured, and the attribute
+// that enables their deletion protection.
+const deletionProtectionChecks: Record<string, DeletionProtectionCheck[]> = {
+    rds: [
+        { resourceClass: aws.rds.Instance, description: "RDS DB instance", attribute: "deletionProtection" },
+        { resourceClass: aws.rds.Cluster, description: "RDS cluster", attribute: "deletionProtection" },
+    ],
+    dynamodb: [
+        { resourceClass: aws.dynamodb.Table, description: "DynamoDB table", attribute: "deletionProtectionEnabled" },
+    ],
+    elb: [
+        { resourceClass: aws.lb.LoadBalancer, description: "Load balancer", attribute: "enableDeletionProtection" },
+        { resourceClass: aws.alb.LoadBalancer, description: "Load balancer", attribute: "enableDeletionProtection" },
+        {
+            resourceClass: aws.elasticloadbalancingv2.LoadBalancer,
+            description: "Load balancer",
+            attribute: "enableDeletionProtection",
+        },
+        {
+            resourceClass: aws.applicationloadbalancing.LoadBalancer,
+            description: "Load balancer",
+            attribute: "enableDeletionProtection",
+        },
+    ],
+    docdb: [
+        { resourceClass: aws.docdb.Cluster, description: "DocumentDB cluster", attribute: "deletionProtection" },
+    ],
+    neptune: [
+        { resourceClass: aws.neptune.Cluster, description: "Neptune cluster", attribute: "deletionProtection" },
+    ],
+    ec2: [
+        { resourceClass: aws.ec2.Instance, description: "EC2 instance", attribute: "disableApiTermination" },
+    ],
+};
+
+/** @internal */
+export const deletionProtectionRequired: ResourceValidationPolicy = {
+    name: "deletion-protection-required",
+    description: "Checks whether resources of the configured types have deletion or termination protection enabled.",
+    configSchema: {
+        properties: {
+            resourceTypes: {
+                type: "array",
+                items: { type: "string", enum: Object.keys(deletionProtectionChecks) },
+                default: [],
+            },
+        },
+    },
+    validateResource: (args, reportViolation) => {
+        const { resourceTypes } = args.getConfig<DeletionProtectionRequiredArgs>();
+
+        for (const resourceType of resourceTypes || []) {
+            for (const check of deletionProtectionChecks[resourceType] || []) {
+                if (args.isType(check.resourceClass) && args.props[check.attribute] !== true) {
+                    reportViolation(`${check.description} '${args.name}' (${args.type}) must have deletion ` +
+                        `protection enabled by setting '${check.attribute}' to true.`);
+                }
+            }
+        }
+    },
+};
+registerPolicy("deletionProtectionRequired", deletionProtectionRequired);
+
+/** @internal */
+export const explicitProviderRegion: StackValidationPolicy = {
+    name: "explicit-provider-region",
+    description: "Checks whether AWS resources are deployed with a provider that sets its region explicitly, rather " +
+        "than one that picks up the region from the environment.",
+    enforcementLevel: "advisory",
+    validateStack: (args, reportViolation) => {
+        // Group the resources by provider, so that each provider is only reported once.
+        const providers = new Map<string, { provider: PolicyProviderResource, resources: PolicyResource[] }>();
+        for (const resource of args.resources) {
+            const provider = resource.provider;
+            if (!resource.type.startsWith("aws:") || !provider || provider.type !== "pulumi:providers:aws" ||
+                provider.props.region) {
+                continue;
+            }
+            const entry = providers.get(provider.urn) || { provider, resources: [] };
+            entry.resources.push(resource);
+            providers.set(provider.urn, entry);
+        }
+
+        providers.forEach(({ provider, resources }) => {
+            // The default provider is named after its version, e.g. "default_5_0_0".
+            const description = provider.name === "default" || provider.name.startsWith("default_")
+                ? "the default AWS provider"
+                : `the AWS provider '${provider.name}'`;
+            reportViolation(`${resources.length} resource(s), e.g. '${resources[0].name}', use ${description}, which ` +
+                "doesn't set a region. They're deployed to whichever region the environment selects, e.g. with " +
+                "AWS_REGION or the AWS profile, so running the update from another environment could deploy them to " +
+                "another region. Set 'aws:region' in the stack's config, or 'region' on the provider.", resources[0].urn);
+        });
+    },
+};
+registerPolicy("explicitProviderRegion", explicitProviderRegion);
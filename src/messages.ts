@@ -0,0 +1,71 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import { StackValidation } from "@pulumi/policy";
+
+import { Policy, wrapValidations } from "./dispatch";
+
+/**
+ * Appends the compliance control IDs of the policy, if any, and the URN of the violating resource
+ * to a violation message. Resource names alone are often ambiguous in large stacks, whereas the URN
+ * includes the resource's type and parent path. Both are added as suffixes so the message itself
+ * reads the same as before.
+ * @internal
+ */
+export function formatViolationMessage(message: string, urn?: string, controlIds: string[] = []): string {
+    let formatted = message;
+    if (controlIds.length > 0) {
+        formatted += ` [Controls: ${controlIds.join(", ")}]`;
+    }
+    if (urn && !message.includes(urn)) {
+        formatted += ` (URN: ${urn})`;
+    }
+    return formatted;
+}
+
+/**
+ * Returns a copy of the policy that formats each violation it reports with `formatViolationMessage`,
+ * including the given compliance control IDs.
+ * @internal
+ */
+export function withResourceUrns(policy: Policy, controlIds: string[] = []): Policy {
+    return wrapValidations(policy,
+        validation => (args, reportViolation) => validation(args, (message, urn) =>
+            reportViolation(formatViolationMessage(message, urn || args.urn, controlIds), urn)),
+        validation => (args, reportViolation) => validation(args, (message, urn) =>
+            reportViolation(formatViolationMessage(message, urn, controlIds), urn)),
+    );
+}
+
+/**
+ * Wraps a stack validation so that all of the violations it reports for a resource are combined
+ * into a single violation, rather than one per problem, which gets noisy in large stacks. The
+ * messages are joined in the order they were reported. Violations without a URN are reported as is.
+ * @internal
+ */
+export function groupedByResource(validation: StackValidation): StackValidation {
+    return async (args, reportViolation) => {
+        const messagesByUrn = new Map<string, string[]>();
+        await validation(args, (message, urn) => {
+            if (!urn) {
+                reportViolation(message);
+                return;
+            }
+            const messages = messagesByUrn.get(urn) || [];
+            messages.push(message);
+            messagesByUrn.set(urn, messages);
+        });
+        messagesByUrn.forEach((messages, urn) => reportViolation(messages.join(" "), urn));
+    };
+}
@@ -13,15 +13,79 @@
 // limitations under the License.
 
 import * as aws from "@pulumi/aws";
-import { EnforcementLevel, ResourceValidationPolicy, validateResourceOfType } from "@pulumi/policy";
+import {
+    EnforcementLevel,
+    PolicyResource,
+    ResourceValidationPolicy,
+    StackValidationPolicy,
+    validateResourceOfType,
+} from "@pulumi/policy";
 
 import { registerPolicy } from "./awsGuard";
+import { namePatternFormat } from "./configSchema";
+import { groupedByResource } from "./messages";
+import { PolicyArgs } from "./policyArgs";
+import { cidrContains, isReferencedBy, matchesAnyPattern } from "./util";
 
 
 // Mixin additional properties onto AwsGuardArgs.
 declare module "./awsGuard" {
     interface AwsGuardArgs {
+        /**
+         * Checks that the default action for all HTTP listeners is to redirect to HTTPS.
+         *
+         * Enforcement level of the `alb-http-to-https-redirection` policy.
+         */
         albHttpToHttpsRedirection?: EnforcementLevel;
+
+        /**
+         * Checks that security groups only allow ingress on restricted ports, such as SSH and RDP, from allowed CIDR
+         * blocks.
+         *
+         * Enforcement level of the `security-group-restricted-ingress` policy, or its enforcement level and options:
+         * `restrictedPorts`, `allowedCidrs`.
+         */
+        securityGroupRestrictedIngress?: EnforcementLevel | (SecurityGroupRestrictedIngressArgs & PolicyArgs);
+
+        /**
+         * Checks whether a stack has more NAT gateways than a threshold, as they are a major cost driver, and
+         * optionally whether they are spread across Availability Zones for high availability.
+         *
+         * Enforcement level of the `nat-gateway-cost` policy, or its enforcement level and options: `maxNatGateways`,
+         * `requireMultipleAzs`.
+         */
+        natGatewayCost?: EnforcementLevel | (NatGatewayCostArgs & PolicyArgs);
+
+        /**
+         * Checks whether Elastic IP addresses are associated with an instance, network interface, or NAT gateway, as
+         * unassociated Elastic IPs incur charges.
+         *
+         * Enforcement level of the `eip-attached` policy.
+         */
+        eipAttached?: EnforcementLevel;
+
+        /**
+         * Checks whether Global Accelerator accelerators have flow logs enabled.
+         *
+         * Enforcement level of the `globalaccelerator-flow-logs` policy.
+         */
+        globalacceleratorFlowLogs?: EnforcementLevel;
+
+        /**
+         * Checks that security groups with inline rules aren't also managed by standalone security group rules, as the
+         * two overwrite each other's rules on every update.
+         *
+         * Enforcement level of the `security-group-no-rule-management-conflicts` policy, or its enforcement level and
+         * options: `allowSeparateDirections`, `securityGroupNames`.
+         */
+        securityGroupNoRuleManagementConflicts?: EnforcementLevel | (SecurityGroupNoRuleManagementConflictsArgs & PolicyArgs);
+
+        /**
+         * Checks whether public Route 53 hosted zones have DNSSEC signing enabled.
+         *
+         * Enforcement level of the `route53-dnssec-enabled` policy.
+         */
+        route53DnssecEnabled?: EnforcementLevel;
     }
 }
 
@@ -56,3 +120,265 @@ export const albHttpToHttpsRedirection: ResourceValidationPolicy = {
         }),
     };
 registerPolicy("albHttpToHttpsRedirection", albHttpToHttpsRedirection);
+
+export interface SecurityGroupRestrictedIngressArgs {
+    /** Ports that may only be reached from allowed CIDR blocks. Defaults to SSH (22) and RDP (3389). */
+    restrictedPorts?: number[];
+
+    /**
+     * Trusted CIDR blocks (e.g. corporate egress ranges) that may reach restricted ports. Any CIDR block
+     * contained within an allowed block is also allowed. Defaults to the private IPv4 address ranges.
+     */
+    allowedCidrs?: string[];
+}
+
+// The subset of ingress rule properties shared by inline rules and standalone rules.
+interface IngressRule {
+    protocol: string;
+    fromPort: number;
+    toPort: number;
+    cidrBlocks?: string[];
+    ipv6CidrBlocks?: string[];
+}
+
+function checkIngressRule(
+    rule: IngressRule, config: Required<SecurityGroupRestrictedIngressArgs>, description: string,
+    reportViolation: (message: string) => void) {
+
+    const allPorts = rule.protocol === "-1" || rule.protocol === "all";
+    const ports = (config.restrictedPorts || []).filter(port => allPorts || (rule.fromPort <= port && port <= rule.toPort));
+    if (ports.length === 0) {
+        return;
+    }
+
+    const cidrs = [...(rule.cidrBlocks || []), ...(rule.ipv6CidrBlocks || [])];
+    for (const cidr of cidrs) {
+        if (!(config.allowedCidrs || []).some(allowed => cidrContains(allowed, cidr))) {
+            reportViolation(`${description} must not allow ingress on port(s) ${ports.join(", ")} from '${cidr}', ` +
+                "which is not an allowed CIDR block.");
+        }
+    }
+}
+
+/** @internal */
+export const securityGroupRestrictedIngress: ResourceValidationPolicy = {
+        name: "security-group-restricted-ingress",
+        description: "Checks that security groups only allow ingress on restricted ports, such as SSH and RDP, from allowed CIDR blocks.",
+        configSchema: {
+            properties: {
+                restrictedPorts: {
+                    type: "array",
+                    items: { type: "number" },
+                    default: [22, 3389],
+                },
+                allowedCidrs: {
+                    type: "array",
+                    items: { type: "string" },
+                    default: ["10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16"],
+                },
+            },
+        },
+        validateResource: [
+            validateResourceOfType(aws.ec2.SecurityGroup, (securityGroup, args, reportViolation) => {
+                const config = args.getConfig<Required<SecurityGroupRestrictedIngressArgs>>();
+                for (const rule of securityGroup.ingress || []) {
+                    checkIngressRule(rule, config, `Security group '${args.name}'`, reportViolation);
+                }
+            }),
+            validateResourceOfType(aws.ec2.SecurityGroupRule, (rule, args, reportViolation) => {
+                if (rule.type !== "ingress") {
+                    return;
+                }
+                const config = args.getConfig<Required<SecurityGroupRestrictedIngressArgs>>();
+                checkIngressRule(rule, config, `Security group rule '${args.name}'`, reportViolation);
+            }),
+        ],
+    };
+registerPolicy("securityGroupRestrictedIngress", securityGroupRestrictedIngress);
+
+export interface NatGatewayCostArgs {
+    /** The maximum number of NAT gateways a stack may have. Defaults to 3. */
+    maxNatGateways?: number;
+
+    /**
+     * If true, a stack's NAT gateways must be spread across more than one Availability Zone, so
+     * that an outage in one zone doesn't take down egress for the others. This adds a NAT gateway,
+     * and its cost, to stacks with only one, so it defaults to false.
+     */
+    requireMultipleAzs?: boolean;
+}
+
+/** @internal */
+export const natGatewayCost: StackValidationPolicy = {
+        name: "nat-gateway-cost",
+        description: "Checks whether a stack has more NAT gateways than a threshold, as they are a major cost driver, " +
+            "and optionally whether they are spread across Availability Zones for high availability.",
+        enforcementLevel: "advisory",
+        configSchema: {
+            properties: {
+                maxNatGateways: {
+                    type: "number",
+                    default: 3,
+                },
+                requireMultipleAzs: {
+                    type: "boolean",
+                    default: false,
+                },
+            },
+        },
+        validateStack: (args, reportViolation) => {
+            const { maxNatGateways, requireMultipleAzs } = args.getConfig<NatGatewayCostArgs>();
+
+            const natGateways = args.resources.filter(r => r.isType(aws.ec2.NatGateway));
+            if (natGateways.length === 0) {
+                return;
+            }
+            if (maxNatGateways !== undefined && natGateways.length > maxNatGateways) {
+                reportViolation(
+                    `Stack has ${natGateways.length} NAT gateways, which exceeds the threshold of ${maxNatGateways}.`);
+            }
+
+            if (requireMultipleAzs) {
+                // A NAT gateway's Availability Zone is that of its subnet. Only consider the zones we can
+                // determine from subnets in the stack; otherwise we can't say the gateways aren't spread.
+                const subnets = args.resources.filter(r => r.isType(aws.ec2.Subnet));
+                const zones = natGateways.map(natGateway => {
+                    const subnet = subnets.find(s => isReferencedBy(s, natGateway, "subnetId"));
+                    return subnet && (subnet.props.availabilityZone || subnet.props.availabilityZoneId);
+                });
+                if (zones.every(zone => zone !== undefined) && new Set(zones).size < 2) {
+                    reportViolation(
+                        `Stack has ${natGateways.length} NAT gateway(s), all in Availability Zone '${zones[0]}'. ` +
+                        "NAT gateways should be spread across Availability Zones for high availability.",
+                        natGateways[0].urn);
+                }
+            }
+        },
+    };
+registerPolicy("natGatewayCost", natGatewayCost);
+
+/** @internal */
+export const eipAttached: StackValidationPolicy = {
+        name: "eip-attached",
+        description: "Checks whether Elastic IP addresses are associated with an instance, network interface, or NAT gateway, " +
+            "as unassociated Elastic IPs incur charges.",
+        enforcementLevel: "advisory",
+        validateStack: (args, reportViolation) => {
+            const associations = args.resources.filter(r => r.isType(aws.ec2.EipAssociation));
+            const natGateways = args.resources.filter(r => r.isType(aws.ec2.NatGateway));
+
+            for (const eip of args.resources.filter(r => r.isType(aws.ec2.Eip))) {
+                const attached = eip.props.instance || eip.props.networkInterface ||
+                    associations.some(a => isReferencedBy(eip, a, "allocationId", ["id", "allocationId"]) ||
+                        isReferencedBy(eip, a, "publicIp", ["publicIp"])) ||
+                    natGateways.some(n => isReferencedBy(eip, n, "allocationId", ["id", "allocationId"]));
+                if (!attached) {
+                    reportViolation(
+                        `Elastic IP '${eip.name}' is not associated with an instance, network interface, or NAT gateway ` +
+                        "in the stack. Unassociated Elastic IPs incur charges.", eip.urn);
+                }
+            }
+        },
+    };
+registerPolicy("eipAttached", eipAttached);
+
+/** @internal */
+export const globalacceleratorFlowLogs: ResourceValidationPolicy = {
+        name: "globalaccelerator-flow-logs",
+        description: "Checks whether Global Accelerator accelerators have flow logs enabled.",
+        enforcementLevel: "advisory",
+        validateResource: validateResourceOfType(aws.globalaccelerator.Accelerator, (accelerator, args, reportViolation) => {
+            const attributes = accelerator.attributes;
+            if (!attributes || !attributes.flowLogsEnabled) {
+                reportViolation(`Global Accelerator '${args.name}' should have flow logs enabled.`);
+            } else if (!attributes.flowLogsS3Bucket) {
+                reportViolation(`Global Accelerator '${args.name}' has flow logs enabled but no S3 bucket to deliver them to.`);
+            }
+        }),
+    };
+registerPolicy("globalacceleratorFlowLogs", globalacceleratorFlowLogs);
+
+export interface SecurityGroupNoRuleManagementConflictsArgs {
+    /**
+     * If true, a security group may have inline rules in one direction and standalone rules in the other,
+     * e.g. inline ingress rules and standalone egress rules. This is safe as long as the group doesn't
+     * declare any inline rules in the standalone rules' direction. Defaults to false.
+     */
+    allowSeparateDirections?: boolean;
+
+    /** If non-empty, only security groups with these names or name patterns (resource or group names) are checked. */
+    securityGroupNames?: string[];
+}
+
+/** @internal */
+export const securityGroupNoRuleManagementConflicts: StackValidationPolicy = {
+        name: "security-group-no-rule-management-conflicts",
+        description: "Checks that security groups with inline rules aren't also managed by standalone security group " +
+            "rules, as the two overwrite each other's rules on every update.",
+        configSchema: {
+            properties: {
+                allowSeparateDirections: {
+                    type: "boolean",
+                    default: false,
+                },
+                securityGroupNames: {
+                    type: "array",
+                    items: { type: "string", format: namePatternFormat },
+                    default: [],
+                },
+            },
+        },
+        validateStack: groupedByResource((args, reportViolation) => {
+            const { allowSeparateDirections, securityGroupNames } =
+                args.getConfig<SecurityGroupNoRuleManagementConflictsArgs>();
+
+            const rules = args.resources.filter(r => r.isType(aws.ec2.SecurityGroupRule));
+            for (const securityGroup of args.resources.filter(r => r.isType(aws.ec2.SecurityGroup))) {
+                const names = securityGroupNames || [];
+                if (names.length > 0 && !matchesAnyPattern([securityGroup.name, securityGroup.props.name], names)) {
+                    continue;
+                }
+
+                const inlineDirections = ["ingress", "egress"].filter(direction =>
+                    (securityGroup.props[direction] || []).length > 0);
+                const conflicts = rules.filter(rule => isReferencedBy(securityGroup, rule, "securityGroupId") &&
+                    (allowSeparateDirections ? inlineDirections.includes(rule.props.type) : inlineDirections.length > 0));
+                if (conflicts.length > 0) {
+                    reportViolation(
+                        `Security group '${securityGroup.name}' has inline rules and is also managed by standalone ` +
+                        `security group rule(s) ${conflicts.map(rule => `'${rule.name}'`).join(", ")}. Use either ` +
+                        "inline rules or standalone rules, as each removes the other's rules on update.", securityGroup.urn);
+                }
+            }
+        }),
+    };
+registerPolicy("securityGroupNoRuleManagementConflicts", securityGroupNoRuleManagementConflicts);
+
+/** @internal */
+export const route53DnssecEnabled: StackValidationPolicy = {
+        name: "route53-dnssec-enabled",
+        description: "Checks whether public Route 53 hosted zones have DNSSEC signing enabled.",
+        enforcementLevel: "advisory",
+        validateStack: (args, reportViolation) => {
+            const keySigningKeys = args.resources.filter(r => r.isType(aws.route53.KeySigningKey));
+            const dnssecs = args.resources.filter(r => r.isType(aws.route53.HostedZoneDnsSec));
+
+            for (const zone of args.resources.filter(r => r.isType(aws.route53.Zone))) {
+                // Private hosted zones, which are associated with VPCs, don't support DNSSEC.
+                if ((zone.props.vpcs || []).length > 0) {
+                    continue;
+                }
+                const references = (r: PolicyResource) => isReferencedBy(zone, r, "hostedZoneId", ["id", "zoneId"]);
+                // Signing is enabled unless `signingStatus` is "NOT_SIGNING".
+                if (dnssecs.some(d => references(d) && d.props.signingStatus !== "NOT_SIGNING")) {
+                    continue;
+                }
+                const zoneName = zone.props.name || zone.name;
+                const detail = keySigningKeys.some(references)
+                    ? "It has a key-signing key, but no 'aws.route53.HostedZoneDnsSec' resource signing the zone."
+                    : "Add an 'aws.route53.KeySigningKey' and an 'aws.route53.HostedZoneDnsSec' resource for it.";
+                reportViolation(`Route 53 hosted zone '${zoneName}' should have DNSSEC signing enabled. ${detail}`, zone.urn);
+            }
+        },
+    };
+registerPolicy("route53DnssecEnabled", route53DnssecEnabled);
@@ -0,0 +1,133 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import { PolicyProviderResource } from "@pulumi/policy";
+
+import { Policy, wrapValidations } from "./dispatch";
+
+/**
+ * Where a policy applies. A policy is skipped for resources in other partitions or regions, e.g.
+ * because the service it checks isn't available there.
+ * @internal
+ */
+export interface PolicyAvailability {
+    /** The partitions the policy applies to, e.g. "aws", "aws-cn" or "aws-us-gov". Defaults to all. */
+    partitions?: string[];
+
+    /** The regions the policy applies to, e.g. "us-east-1". Defaults to all. */
+    regions?: string[];
+}
+
+// Where each policy applies, by policy name. Policies that aren't listed apply everywhere.
+const policyAvailabilities: Record<string, PolicyAvailability> = {
+    // Global Accelerator and Lightsail are only available in the commercial partition.
+    "globalaccelerator-flow-logs": { partitions: ["aws"] },
+    "lightsail-automatic-snapshots": { partitions: ["aws"] },
+    "lightsail-public-access": { partitions: ["aws"] },
+};
+
+/**
+ * Returns where the policy with the given name applies, or undefined if it applies everywhere.
+ * @internal
+ */
+export function getPolicyAvailability(policyName: string): PolicyAvailability | undefined {
+    return policyAvailabilities[policyName];
+}
+
+/**
+ * The partition and region of a resource. Either may be undefined if it can't be determined.
+ * @internal
+ */
+export interface ResourceLocation {
+    partition?: string;
+    region?: string;
+}
+
+// Region prefixes of the partitions other than "aws", longest first so that e.g. "us-isob-" isn't
+// mistaken for "us-iso-".
+const partitionRegionPrefixes: Array<[string, string]> = [
+    ["us-isob-", "aws-iso-b"],
+    ["us-iso-", "aws-iso"],
+    ["us-gov-", "aws-us-gov"],
+    ["cn-", "aws-cn"],
+];
+
+/**
+ * Returns the partition the region is in, e.g. "aws-us-gov" for "us-gov-west-1".
+ * @internal
+ */
+export function getPartitionOfRegion(region: string): string {
+    const match = partitionRegionPrefixes.find(([prefix]) => region.startsWith(prefix));
+    return match ? match[1] : "aws";
+}
+
+/**
+ * Returns the partition and region of a resource. The resource's ARN, once known, is authoritative.
+ * Otherwise, the region is that of the resource's provider or, for the default provider, the
+ * `AWS_REGION` or `AWS_DEFAULT_REGION` environment variable.
+ * @internal
+ */
+export function getResourceLocation(props: Record<string, any>, provider?: PolicyProviderResource): ResourceLocation {
+    const arn = typeof props.arn === "string" && props.arn.startsWith("arn:") ? props.arn.split(":") : undefined;
+    const region = (arn && arn[3]) || (provider && provider.props.region) ||
+        process.env.AWS_REGION || process.env.AWS_DEFAULT_REGION || undefined;
+    const partition = (arn && arn[1]) || (region ? getPartitionOfRegion(region) : undefined);
+    return { partition, region };
+}
+
+/**
+ * Returns true if a policy with the given availability applies at the location. Policies apply
+ * wherever the location can't be determined, so that they're not skipped by mistake.
+ * @internal
+ */
+export function appliesAt(availability: PolicyAvailability, location: ResourceLocation): boolean {
+    if (availability.partitions && location.partition && !availability.partitions.includes(location.partition)) {
+        return false;
+    }
+    return !(availability.regions && location.region && !availability.regions.includes(location.region));
+}
+
+/**
+ * Returns a copy of the policy that skips resources where it doesn't apply, logging the first skip
+ * in each location with `log`. Stack validations still see every resource, so that they can follow
+ * references between resources, but violations of resources where the policy doesn't apply are dropped.
+ * @internal
+ */
+export function withRegionAvailability(
+    policy: Policy, availability: PolicyAvailability, log: (message: string) => void = console.error): Policy {
+
+    const logged = new Set<string>();
+    const applies = (location: ResourceLocation) => {
+        if (appliesAt(availability, location)) {
+            return true;
+        }
+        const where = [location.region, location.partition].filter(part => part !== undefined).join(", ");
+        if (!logged.has(where)) {
+            logged.add(where);
+            log(`awsguard: skipping ${policy.name} in ${where}, where it doesn't apply`);
+        }
+        return false;
+    };
+
+    return wrapValidations(policy,
+        validation => (args, reportViolation) =>
+            applies(getResourceLocation(args.props, args.provider)) ? validation(args, reportViolation) : undefined,
+        validation => (args, reportViolation) => validation(args, (message, urn) => {
+            const resource = urn ? args.resources.find(r => r.urn === urn) : undefined;
+            if (applies(resource ? getResourceLocation(resource.props, resource.provider) : getResourceLocation({}))) {
+                reportViolation(message, urn);
+            }
+        }),
+    );
+}
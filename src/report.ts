@@ -0,0 +1,69 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import * as fs from "fs";
+
+import { EnforcementLevel } from "@pulumi/policy";
+
+import { Policy, wrapValidations } from "./dispatch";
+
+/**
+ * The environment variable used to specify a file that violations are written to, one JSON object
+ * per line. This is in addition to the violations reported to Pulumi.
+ */
+export const reportFileEnvVar = "AWSGUARD_REPORT_FILE";
+
+/**
+ * A structured record of a single policy violation, as written to the report file.
+ */
+export interface ViolationRecord {
+    /** The name of the policy that was violated. */
+    policyName: string;
+    /** The URN of the violating resource, if known. */
+    urn?: string;
+    /** The enforcement level of the policy. */
+    enforcementLevel: EnforcementLevel;
+    /** The violation message. */
+    message: string;
+}
+
+/**
+ * Appends a violation record to the report file. Each record is written with a single append of a
+ * complete line, so records from policies running across many resources never interleave.
+ * @internal
+ */
+export function writeViolationRecord(filePath: string, record: ViolationRecord): void {
+    fs.appendFileSync(filePath, JSON.stringify(record) + "\n", { encoding: "utf8", flag: "a" });
+}
+
+/**
+ * Returns a copy of the policy that writes each violation it reports to the report file.
+ * @internal
+ */
+export function withViolationRecords(policy: Policy, enforcementLevel: EnforcementLevel, filePath: string): Policy {
+    const record = (message: string, urn?: string) => {
+        writeViolationRecord(filePath, { policyName: policy.name, urn, enforcementLevel, message });
+    };
+
+    return wrapValidations(policy,
+        validation => (args, reportViolation) => validation(args, (message, urn) => {
+            record(message, urn || args.urn);
+            reportViolation(message, urn);
+        }),
+        validation => (args, reportViolation) => validation(args, (message, urn) => {
+            record(message, urn);
+            reportViolation(message, urn);
+        }),
+    );
+}
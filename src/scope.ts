@@ -0,0 +1,58 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import { Policy, wrapValidations } from "./dispatch";
+import { hasTag } from "./util";
+
+/**
+ * Selects resources by tag. Resources that don't support tags never match.
+ */
+export interface TagSelector {
+    /** The tag's key. */
+    key: string;
+
+    /** If set, the tag must also have this value. */
+    value?: string;
+}
+
+/**
+ * Returns true if a resource with the given properties is in scope: it has the `only` tag, if
+ * given, and doesn't have the `exclude` tag, if given.
+ * @internal
+ */
+export function isInTagScope(props: Record<string, any>, only?: TagSelector, exclude?: TagSelector): boolean {
+    if (only && !hasTag(props, only.key, only.value)) {
+        return false;
+    }
+    return !(exclude && hasTag(props, exclude.key, exclude.value));
+}
+
+/**
+ * Returns a copy of the policy that only checks resources in the tag scope. Resource validations
+ * are skipped for resources out of scope. Stack validations still see every resource, so that they
+ * can follow references between resources, but violations of resources out of scope are dropped.
+ * @internal
+ */
+export function withTagScope(policy: Policy, only?: TagSelector, exclude?: TagSelector): Policy {
+    return wrapValidations(policy,
+        validation => (args, reportViolation) =>
+            isInTagScope(args.props, only, exclude) ? validation(args, reportViolation) : undefined,
+        validation => (args, reportViolation) => validation(args, (message, urn) => {
+            const resource = urn ? args.resources.find(r => r.urn === urn) : undefined;
+            if (!resource || isInTagScope(resource.props, only, exclude)) {
+                reportViolation(message, urn);
+            }
+        }),
+    );
+}
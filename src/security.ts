@@ -23,10 +23,14 @@ import {
     validateResourceOfType,
     validateStackResourcesOfType,
 } from "@pulumi/policy";
+import { Resource } from "@pulumi/pulumi";
 
+import { callAwsApi, isApiUnavailableError } from "./awsApi";
 import { registerPolicy } from "./awsGuard";
+import { namePatternFormat } from "./configSchema";
 import { defaultEnforcementLevel } from "./enforcementLevel";
 import { PolicyArgs } from "./policyArgs";
+import { allowsPublicAccess, describePolicyStatement, getPolicyStatements, matchesAnyPattern } from "./util";
 
 // Retrieving the aws region
 const awsConfigRegion = aws.config.region;
@@ -34,10 +38,87 @@ const awsConfigRegion = aws.config.region;
 // Mixin additional properties onto AwsGuardArgs.
 declare module "./awsGuard" {
     interface AwsGuardArgs {
+        /**
+         * Checks whether an ACM certificate has expired. Certificates provided by ACM are automatically renewed. ACM
+         * does not automatically renew certificates that you import.
+         *
+         * Enforcement level of the `acm-certificate-expiration` policy, or its enforcement level and options:
+         * `maxDaysUntilExpiration`, `expirationThresholdDays`, `skipWhenCredentialsUnavailable`.
+         */
         acmCertificateExpiration?: EnforcementLevel | (AcmCertificateExpirationArgs & PolicyArgs);
+
+        /**
+         * Checks whether ACM certificates are requested for wildcard domains, which broaden the impact of a compromised
+         * key.
+         *
+         * Enforcement level of the `acm-certificate-no-wildcard` policy, or its enforcement level and options:
+         * `allowedWildcardDomains`.
+         */
+        acmCertificateNoWildcard?: EnforcementLevel | (AcmCertificateNoWildcardArgs & PolicyArgs);
+
+        /**
+         * Checks that key rotation is enabled for each customer master key (CMK). Checks that key rotation is enabled
+         * for specific key object. Does not apply to CMKs that have imported key material.
+         *
+         * Enforcement level of the `cmk-backing-key-rotation-enabled` policy.
+         */
         cmkBackingKeyRotationEnabled?: EnforcementLevel;
+
+        /**
+         * Checks whether an access key have been rotated within maxKeyAge days.
+         *
+         * Enforcement level of the `access-keys-rotated` policy, or its enforcement level and options: `maxKeyAge`.
+         */
         iamAccessKeysRotated?: EnforcementLevel | (IamAccessKeysRotatedArgs & PolicyArgs);
+
+        /**
+         * Checks whether multi-factor Authentication (MFA) is enabled for an IAM user that use a console password.
+         *
+         * Enforcement level of the `mfa-enabled-for-iam-console-access` policy.
+         */
         iamMfaEnabledForConsoleAccess?: EnforcementLevel;
+
+        /**
+         * Checks whether IAM users are created. Roles assumed through federation or by services avoid the long-lived
+         * credentials of IAM users.
+         *
+         * Enforcement level of the `prefer-iam-roles-over-users` policy, or its enforcement level and options:
+         * `allowedUserNames`.
+         */
+        preferIamRolesOverUsers?: EnforcementLevel | (PreferIamRolesOverUsersArgs & PolicyArgs);
+
+        /**
+         * Checks whether Secrets Manager secret versions have a secret string given literally in the program, rather
+         * than computed from another resource. Values read from config can't be told apart from literals, so this is
+         * advisory.
+         *
+         * Enforcement level of the `secretsmanager-no-plaintext-secret-string` policy.
+         */
+        secretsmanagerNoPlaintextSecretString?: EnforcementLevel;
+
+        /**
+         * Checks whether KMS key policies grant all KMS actions to any principal without a condition.
+         *
+         * Enforcement level of the `kms-key-policy-no-wildcard-admin` policy.
+         */
+        kmsKeyPolicyNoWildcardAdmin?: EnforcementLevel;
+
+        /**
+         * Checks whether resources that terminate TLS, such as CloudFront distributions and load balancer listeners,
+         * use a TLS policy that enforces the minimum TLS version.
+         *
+         * Enforcement level of the `minimum-tls-version` policy, or its enforcement level and options:
+         * `minimumVersion`, `resourceTypes`.
+         */
+        minimumTlsVersion?: EnforcementLevel | (MinimumTlsVersionArgs & PolicyArgs);
+
+        /**
+         * Checks whether stacks that create EC2 instances, ECR repositories or Lambda functions also enable Amazon
+         * Inspector scanning for them.
+         *
+         * Enforcement level of the `inspector-enabled` policy.
+         */
+        inspectorEnabled?: EnforcementLevel;
     }
 }
 
@@ -45,8 +126,17 @@ declare module "./awsGuard" {
 const msInDay = 24 * 60 * 60 * 1000;
 
 export interface AcmCertificateExpirationArgs {
-    /** Max days before certificate expires. Defaults to 14. */
+    /** Max days before certificate expires. Defaults to 14. Superseded by `expirationThresholdDays`, if set. */
     maxDaysUntilExpiration?: number;
+
+    /** Minimum number of days a certificate must remain valid for. Defaults to `maxDaysUntilExpiration`. */
+    expirationThresholdDays?: number;
+
+    /**
+     * If true, skip the policy with a warning when the AWS API is unreachable or credentials are
+     * unavailable (e.g. in offline CI), rather than failing the preview. Defaults to false.
+     */
+    skipWhenCredentialsUnavailable?: boolean;
 }
 
 /** @internal */
@@ -59,21 +149,43 @@ export const acmCertificateExpiration: StackValidationPolicy = {
                     type: "number",
                     default: 14,
                 },
+                expirationThresholdDays: {
+                    type: "number",
+                },
+                skipWhenCredentialsUnavailable: {
+                    type: "boolean",
+                    default: false,
+                },
             },
         },
         validateStack: validateStackResourcesOfType(aws.acm.Certificate, async (acmCertificates, args, reportViolation) => {
-            const { maxDaysUntilExpiration } =  args.getConfig<AcmCertificateExpirationArgs>();
+            const { maxDaysUntilExpiration, expirationThresholdDays, skipWhenCredentialsUnavailable } =
+                args.getConfig<AcmCertificateExpirationArgs>();
+            const thresholdDays = expirationThresholdDays !== undefined ? expirationThresholdDays : maxDaysUntilExpiration;
             // Need to pass in aws region for acm.
             const acm = new AWS.ACM({region: awsConfigRegion});
             // Fetch the full ACM certificate using the AWS SDK to get its expiration date.
             for (const certInStack of acmCertificates) {
-                const describeCertResp = await acm.describeCertificate({ CertificateArn: certInStack.id}).promise();
+                let describeCertResp: AWS.ACM.DescribeCertificateResponse | undefined;
+                try {
+                    describeCertResp = await callAwsApi("acm-certificate-expiration",
+                        () => acm.describeCertificate({ CertificateArn: certInStack.id}).promise());
+                } catch (err) {
+                    if (skipWhenCredentialsUnavailable && isApiUnavailableError(err)) {
+                        console.warn(`warning: skipping acm-certificate-expiration, the AWS API is unavailable: ${err.message}`);
+                        return;
+                    }
+                    throw err;
+                }
+                if (!describeCertResp) {
+                    continue;
+                }
                 const certDescription = describeCertResp.Certificate;
                 if (certDescription && certDescription.NotAfter) {
                     let daysUntilExpiry = (certDescription.NotAfter.getTime() - Date.now()) / msInDay;
                     daysUntilExpiry = Math.floor(daysUntilExpiry);
-                    if (daysUntilExpiry < maxDaysUntilExpiration!) {
-                        reportViolation(`certificate expires in ${daysUntilExpiry} (max allowed ${maxDaysUntilExpiration} days)`);
+                    if (daysUntilExpiry < thresholdDays!) {
+                        reportViolation(`certificate expires in ${daysUntilExpiry} (max allowed ${thresholdDays} days)`);
                     }
                 }
             }
@@ -81,6 +193,46 @@ export const acmCertificateExpiration: StackValidationPolicy = {
     };
 registerPolicy("acmCertificateExpiration", acmCertificateExpiration);
 
+export interface AcmCertificateNoWildcardArgs {
+    /**
+     * Domains that may use wildcard certificates. Either the wildcard domain (e.g. "*.example.com")
+     * or the domain it covers (e.g. "example.com") may be given.
+     */
+    allowedWildcardDomains?: string[];
+}
+
+/** @internal */
+export const acmCertificateNoWildcard: ResourceValidationPolicy = {
+        name: "acm-certificate-no-wildcard",
+        description: "Checks whether ACM certificates are requested for wildcard domains, which broaden the impact of a compromised key.",
+        enforcementLevel: "advisory",
+        configSchema: {
+            properties: {
+                allowedWildcardDomains: {
+                    type: "array",
+                    items: { type: "string" },
+                    default: [],
+                },
+            },
+        },
+        validateResource: validateResourceOfType(aws.acm.Certificate, (certificate, args, reportViolation) => {
+            const { allowedWildcardDomains } = args.getConfig<AcmCertificateNoWildcardArgs>();
+
+            const domains = [certificate.domainName, ...(certificate.subjectAlternativeNames || [])];
+            for (const domain of domains) {
+                if (!domain || !domain.startsWith("*.")) {
+                    continue;
+                }
+                const allowed = (allowedWildcardDomains || []).some(allowedDomain =>
+                    allowedDomain === domain || `*.${allowedDomain}` === domain);
+                if (!allowed) {
+                    reportViolation(`ACM certificate '${args.name}' must not use the wildcard domain '${domain}'.`);
+                }
+            }
+        }),
+    };
+registerPolicy("acmCertificateNoWildcard", acmCertificateNoWildcard);
+
 /** @internal */
 export const cmkBackingKeyRotationEnabled: ResourceValidationPolicy = {
         name: "cmk-backing-key-rotation-enabled",
@@ -121,10 +273,14 @@ export const iamAccessKeysRotated: StackValidationPolicy = {
                     continue;
                 }
                 // Use the AWS SDK to list the access keys for the user, which will contain the key's creation date.
-                let paginationToken = undefined;
-                let accessKeysResp: AWS.IAM.ListAccessKeysResponse;
+                let paginationToken: string | undefined = undefined;
+                let accessKeysResp: AWS.IAM.ListAccessKeysResponse | undefined;
                 do {
-                    accessKeysResp = await iam.listAccessKeys({ UserName: instance.user, Marker: paginationToken }).promise();
+                    accessKeysResp = await callAwsApi("access-keys-rotated",
+                        () => iam.listAccessKeys({ UserName: instance.user, Marker: paginationToken }).promise());
+                    if (!accessKeysResp) {
+                        break;
+                    }
                     for (const accessKey of accessKeysResp.AccessKeyMetadata) {
                         if (accessKey.AccessKeyId === instance.id && accessKey.CreateDate) {
                             let daysSinceCreated = (Date.now() - accessKey.CreateDate!.getTime()) / msInDay;
@@ -147,7 +303,11 @@ export const iamMfaEnabledForConsoleAccess: ResourceValidationPolicy = {
         description: "Checks whether multi-factor Authentication (MFA) is enabled for an IAM user that use a console password.",
         validateResource: validateResourceOfType(aws.iam.UserLoginProfile, async (instance, _, reportViolation) => {
             const iam = new AWS.IAM();
-            const mfaDevicesResp = await iam.listMFADevices({ UserName: instance.user }).promise();
+            const mfaDevicesResp = await callAwsApi("mfa-enabled-for-iam-console-access",
+                () => iam.listMFADevices({ UserName: instance.user }).promise());
+            if (!mfaDevicesResp) {
+                return;
+            }
             // We don't bother with paging through all MFA devices, since we only check that there is at least one.
             if (mfaDevicesResp.MFADevices.length === 0) {
                 reportViolation(`no MFA device enabled for IAM User '${instance.user}'`);
@@ -155,3 +315,367 @@ export const iamMfaEnabledForConsoleAccess: ResourceValidationPolicy = {
         }),
     };
 registerPolicy("iamMfaEnabledForConsoleAccess", iamMfaEnabledForConsoleAccess);
+
+export interface PreferIamRolesOverUsersArgs {
+    /** Names or name patterns of users (resource or user names) that may be created, e.g. `svc-*` service accounts. */
+    allowedUserNames?: string[];
+}
+
+/** @internal */
+export const preferIamRolesOverUsers: ResourceValidationPolicy = {
+        name: "prefer-iam-roles-over-users",
+        description: "Checks whether IAM users are created. Roles assumed through federation or by services " +
+            "avoid the long-lived credentials of IAM users.",
+        enforcementLevel: "advisory",
+        configSchema: {
+            properties: {
+                allowedUserNames: {
+                    type: "array",
+                    items: { type: "string", format: namePatternFormat },
+                    default: [],
+                },
+            },
+        },
+        validateResource: validateResourceOfType(aws.iam.User, (user, args, reportViolation) => {
+            const { allowedUserNames } = args.getConfig<PreferIamRolesOverUsersArgs>();
+
+            if (matchesAnyPattern([args.name, user.name], allowedUserNames)) {
+                return;
+            }
+            reportViolation(`IAM user '${args.name}' should be replaced by a role assumed through federation or by a service.`);
+        }),
+    };
+registerPolicy("preferIamRolesOverUsers", preferIamRolesOverUsers);
+
+/** @internal */
+export const secretsmanagerNoPlaintextSecretString: StackValidationPolicy = {
+        name: "secretsmanager-no-plaintext-secret-string",
+        description: "Checks whether Secrets Manager secret versions have a secret string given literally in the " +
+            "program, rather than computed from another resource. Values read from config can't be told apart " +
+            "from literals, so this is advisory.",
+        enforcementLevel: "advisory",
+        validateStack: (args, reportViolation) => {
+            for (const version of args.resources.filter(r => r.isType(aws.secretsmanager.SecretVersion))) {
+                // A value computed from another resource is unknown during a preview, and has a dependency.
+                const dependencies = (version.propertyDependencies || {}).secretString || [];
+                if (typeof version.props.secretString === "string" && dependencies.length === 0) {
+                    reportViolation(
+                        `Secrets Manager secret version '${version.name}' appears to have a hardcoded secret string. ` +
+                        "Generate it with a resource such as 'random.RandomPassword', or create the secret without " +
+                        "a version and set its value outside of Pulumi instead.", version.urn);
+                }
+            }
+        },
+    };
+registerPolicy("secretsmanagerNoPlaintextSecretString", secretsmanagerNoPlaintextSecretString);
+
+function checkKmsKeyPolicy(keyName: string, document: any, reportViolation: (message: string) => void) {
+    // Granting kms:* to the account root, as the default key policy does, delegates access to the
+    // account's IAM policies, so only grants to any principal are reported.
+    getPolicyStatements(document).forEach((statement, index) => {
+        if (allowsPublicAccess(statement, ["kms:*"])) {
+            reportViolation(
+                `KMS key '${keyName}' must not allow any principal to administer the key: statement ` +
+                `${describePolicyStatement(statement, index)} grants kms:* to any principal without a condition.`);
+        }
+    });
+}
+
+/** @internal */
+export const kmsKeyPolicyNoWildcardAdmin: ResourceValidationPolicy = {
+        name: "kms-key-policy-no-wildcard-admin",
+        description: "Checks whether KMS key policies grant all KMS actions to any principal without a condition.",
+        validateResource: [
+            validateResourceOfType(aws.kms.Key, (key, args, reportViolation) => {
+                checkKmsKeyPolicy(args.name, key.policy, reportViolation);
+            }),
+            validateResourceOfType(aws.kms.KeyPolicy, (keyPolicy, args, reportViolation) => {
+                const keyName = typeof keyPolicy.keyId === "string" && keyPolicy.keyId ? keyPolicy.keyId : args.name;
+                checkKmsKeyPolicy(keyName, keyPolicy.policy, reportViolation);
+            }),
+        ],
+    };
+registerPolicy("kmsKeyPolicyNoWildcardAdmin", kmsKeyPolicyNoWildcardAdmin);
+
+export interface MinimumTlsVersionArgs {
+    /** The minimum TLS version resources must require: "1.0", "1.1", "1.2" or "1.3". Defaults to "1.2". */
+    minimumVersion?: string;
+
+    /**
+     * The types of resources to check: "cloudfront" (distributions), "elb" (load balancer listeners),
+     * "apigateway" (custom domain names), "opensearch" (OpenSearch and Elasticsearch domains) or
+     * "elasticache" (Redis replication groups). Defaults to all of them.
+     */
+    resourceTypes?: string[];
+}
+
+// TLS versions, from least to most secure. "none" is a connection without encryption in transit.
+const tlsVersions = ["none", "SSLv3", "1.0", "1.1", "1.2", "1.3"];
+
+function describeTlsVersion(version: string): string {
+    switch (version) {
+        case "none":
+            return "unencrypted connections";
+        case "SSLv3":
+            return "SSLv3";
+        default:
+            return `TLS ${version}`;
+    }
+}
+
+interface TlsCheck {
+    resourceClass: { new(...rest: any[]): Resource };
+    description: string;
+    // Returns the TLS policy the resource uses, or undefined if it doesn't terminate TLS, e.g. an HTTP listener.
+    getTlsPolicy: (props: Record<string, any>) => string | undefined;
+    // The known TLS policies of the service, oldest first, and the lowest TLS version each allows.
+    tlsPolicies: Array<[string, string]>;
+    // Returns how to fix the resource, if it takes more than switching to the suggested TLS policy.
+    describeFix?: (props: Record<string, any>, suggestion: string) => string | undefined;
+}
+
+const cloudfrontTlsPolicies: Array<[string, string]> = [
+    ["SSLv3", "SSLv3"],
+    ["TLSv1", "1.0"],
+    ["TLSv1_2016", "1.0"],
+    ["TLSv1.1_2016", "1.1"],
+    ["TLSv1.2_2018", "1.2"],
+    ["TLSv1.2_2019", "1.2"],
+    ["TLSv1.2_2021", "1.2"],
+];
+
+const elbTlsPolicies: Array<[string, string]> = [
+    ["ELBSecurityPolicy-2015-05", "1.0"],
+    ["ELBSecurityPolicy-TLS-1-0-2015-04", "1.0"],
+    ["ELBSecurityPolicy-2016-08", "1.0"],
+    ["ELBSecurityPolicy-TLS-1-1-2017-01", "1.1"],
+    ["ELBSecurityPolicy-TLS-1-2-2017-01", "1.2"],
+    ["ELBSecurityPolicy-TLS-1-2-Ext-2018-06", "1.2"],
+    ["ELBSecurityPolicy-FS-2018-06", "1.0"],
+    ["ELBSecurityPolicy-FS-1-1-2019-08", "1.1"],
+    ["ELBSecurityPolicy-FS-1-2-2019-08", "1.2"],
+    ["ELBSecurityPolicy-FS-1-2-Res-2019-08", "1.2"],
+    ["ELBSecurityPolicy-FS-1-2-Res-2020-10", "1.2"],
+    ["ELBSecurityPolicy-TLS13-1-0-2021-06", "1.0"],
+    ["ELBSecurityPolicy-TLS13-1-1-2021-06", "1.1"],
+    ["ELBSecurityPolicy-TLS13-1-2-Ext2-2021-06", "1.2"],
+    ["ELBSecurityPolicy-TLS13-1-2-Ext1-2021-06", "1.2"],
+    ["ELBSecurityPolicy-TLS13-1-2-Res-2021-06", "1.2"],
+    ["ELBSecurityPolicy-TLS13-1-2-2021-06", "1.2"],
+    ["ELBSecurityPolicy-TLS13-1-3-2021-06", "1.3"],
+];
+
+const apiGatewayTlsPolicies: Array<[string, string]> = [
+    ["TLS_1_0", "1.0"],
+    ["TLS_1_2", "1.2"],
+];
+
+const openSearchTlsPolicies: Array<[string, string]> = [
+    ["Policy-Min-TLS-1-0-2019-07", "1.0"],
+    ["Policy-Min-TLS-1-2-2019-07", "1.2"],
+    ["Policy-Min-TLS-1-2-PFS-2023-10", "1.2"],
+];
+
+// ElastiCache doesn't have TLS policies, only a setting that enables TLS 1.2 or later.
+const elastiCacheTlsPolicies: Array<[string, string]> = [
+    ["transit encryption disabled", "none"],
+    ["transit encryption enabled", "1.2"],
+];
+
+function getListenerTlsPolicy(props: Record<string, any>): string | undefined {
+    // Listeners without an `sslPolicy` use ELBSecurityPolicy-2016-08.
+    if (props.protocol !== "HTTPS" && props.protocol !== "TLS") {
+        return undefined;
+    }
+    return props.sslPolicy || "ELBSecurityPolicy-2016-08";
+}
+
+function getOpenSearchTlsPolicy(props: Record<string, any>): string {
+    const options = props.domainEndpointOptions;
+    return (options && options.tlsSecurityPolicy) || "Policy-Min-TLS-1-0-2019-07";
+}
+
+// The resources checked for each of the resource types that may be configured, and how to find the
+// TLS policy each uses when it's not set explicitly.
+const tlsChecks: Record<string, TlsCheck[]> = {
+    cloudfront: [
+        {
+            resourceClass: aws.cloudfront.Distribution,
+            description: "CloudFront distribution",
+            // Distributions that use the default *.cloudfront.net certificate always allow TLSv1.
+            getTlsPolicy: props => {
+                const certificate = props.viewerCertificate || {};
+                return (!certificate.cloudfrontDefaultCertificate && certificate.minimumProtocolVersion) || "TLSv1";
+            },
+            tlsPolicies: cloudfrontTlsPolicies,
+            describeFix: (props, suggestion) => {
+                const certificate = props.viewerCertificate || {};
+                const custom = certificate.acmCertificateArn || certificate.iamCertificateId;
+                if (certificate.cloudfrontDefaultCertificate || !custom) {
+                    return "The default *.cloudfront.net certificate always allows TLSv1, so use a custom " +
+                        `certificate, e.g. from ACM, with the minimum protocol version '${suggestion}' instead.`;
+                }
+                return undefined;
+            },
+        },
+    ],
+    elb: [
+        {
+            resourceClass: aws.lb.Listener,
+            description: "Load balancer listener",
+            getTlsPolicy: getListenerTlsPolicy,
+            tlsPolicies: elbTlsPolicies,
+        },
+        {
+            resourceClass: aws.alb.Listener,
+            description: "Load balancer listener",
+            getTlsPolicy: getListenerTlsPolicy,
+            tlsPolicies: elbTlsPolicies,
+        },
+        {
+            resourceClass: aws.elasticloadbalancingv2.Listener,
+            description: "Load balancer listener",
+            getTlsPolicy: getListenerTlsPolicy,
+            tlsPolicies: elbTlsPolicies,
+        },
+    ],
+    apigateway: [
+        {
+            resourceClass: aws.apigateway.DomainName,
+            description: "API Gateway domain name",
+            getTlsPolicy: props => props.securityPolicy || "TLS_1_0",
+            tlsPolicies: apiGatewayTlsPolicies,
+        },
+        {
+            resourceClass: aws.apigatewayv2.DomainName,
+            description: "API Gateway domain name",
+            getTlsPolicy: props => props.domainNameConfiguration && props.domainNameConfiguration.securityPolicy,
+            tlsPolicies: apiGatewayTlsPolicies,
+        },
+    ],
+    opensearch: [
+        {
+            resourceClass: aws.opensearch.Domain,
+            description: "OpenSearch domain",
+            getTlsPolicy: getOpenSearchTlsPolicy,
+            tlsPolicies: openSearchTlsPolicies,
+        },
+        {
+            resourceClass: aws.elasticsearch.Domain,
+            description: "Elasticsearch domain",
+            getTlsPolicy: getOpenSearchTlsPolicy,
+            tlsPolicies: openSearchTlsPolicies,
+        },
+    ],
+    elasticache: [
+        {
+            resourceClass: aws.elasticache.ReplicationGroup,
+            description: "ElastiCache replication group",
+            getTlsPolicy: props =>
+                props.transitEncryptionEnabled ? "transit encryption enabled" : "transit encryption disabled",
+            tlsPolicies: elastiCacheTlsPolicies,
+        },
+    ],
+};
+
+const defaultMinimumTlsVersion = "1.2";
+
+// Returns the policy to suggest instead of one that allows a TLS version below `minimumVersion`:
+// the newest of the policies that allow the fewest TLS versions while enforcing the minimum.
+function suggestTlsPolicy(tlsPolicies: Array<[string, string]>, minimumVersion: string): string | undefined {
+    const minimumRank = tlsVersions.indexOf(minimumVersion);
+    let suggestion: string | undefined;
+    let suggestionRank = tlsVersions.length;
+    for (const [name, version] of tlsPolicies) {
+        const rank = tlsVersions.indexOf(version);
+        if (rank >= minimumRank && rank <= suggestionRank) {
+            suggestion = name;
+            suggestionRank = rank;
+        }
+    }
+    return suggestion;
+}
+
+/** @internal */
+export const minimumTlsVersion: ResourceValidationPolicy = {
+        name: "minimum-tls-version",
+        description: "Checks whether resources that terminate TLS, such as CloudFront distributions and load balancer " +
+            "listeners, use a TLS policy that enforces the minimum TLS version.",
+        configSchema: {
+            properties: {
+                minimumVersion: {
+                    type: "string",
+                    enum: ["1.0", "1.1", "1.2", "1.3"],
+                    default: defaultMinimumTlsVersion,
+                },
+                resourceTypes: {
+                    type: "array",
+                    items: { type: "string", enum: Object.keys(tlsChecks) },
+                    default: Object.keys(tlsChecks),
+                },
+            },
+        },
+        validateResource: (args, reportViolation) => {
+            const config = args.getConfig<MinimumTlsVersionArgs>();
+            const minimumVersion = config.minimumVersion || defaultMinimumTlsVersion;
+
+            for (const resourceType of config.resourceTypes || Object.keys(tlsChecks)) {
+                for (const check of tlsChecks[resourceType] || []) {
+                    if (!args.isType(check.resourceClass)) {
+                        continue;
+                    }
+                    const tlsPolicy = check.getTlsPolicy(args.props);
+                    const known = check.tlsPolicies.find(([name]) => name === tlsPolicy);
+                    // Policies that aren't known, e.g. ones newer than this pack, can't be compared.
+                    if (!tlsPolicy || !known || tlsVersions.indexOf(known[1]) >= tlsVersions.indexOf(minimumVersion)) {
+                        continue;
+                    }
+                    const suggestion = suggestTlsPolicy(check.tlsPolicies, minimumVersion);
+                    let fix = "No policy of the service enforces it.";
+                    if (suggestion) {
+                        fix = (check.describeFix && check.describeFix(args.props, suggestion)) ||
+                            `Use '${suggestion}' instead.`;
+                    }
+                    reportViolation(
+                        `${check.description} '${args.name}' (${args.type}) uses the TLS policy '${tlsPolicy}', ` +
+                        `which allows ${describeTlsVersion(known[1])}, but the minimum is TLS ${minimumVersion}. ` +
+                        fix);
+                }
+            }
+        },
+    };
+registerPolicy("minimumTlsVersion", minimumTlsVersion);
+
+// The Amazon Inspector scan types, and the resources that need each.
+const inspectorScanTypes: Array<[string, Array<{ new(...rest: any[]): Resource }>]> = [
+    ["EC2", [aws.ec2.Instance, aws.ec2.LaunchTemplate, aws.autoscaling.Group]],
+    ["ECR", [aws.ecr.Repository]],
+    ["LAMBDA", [aws.lambda.Function]],
+];
+
+/** @internal */
+export const inspectorEnabled: StackValidationPolicy = {
+        name: "inspector-enabled",
+        description: "Checks whether stacks that create EC2 instances, ECR repositories or Lambda functions " +
+            "also enable Amazon Inspector scanning for them.",
+        enforcementLevel: "advisory",
+        validateStack: (args, reportViolation) => {
+            const enabled = new Set<string>();
+            for (const enabler of args.resources.filter(r => r.isType(aws.inspector2.Enabler))) {
+                for (const resourceType of enabler.props.resourceTypes || []) {
+                    enabled.add(resourceType);
+                }
+            }
+
+            const uncovered = inspectorScanTypes
+                .filter(([scanType, classes]) => !enabled.has(scanType) &&
+                    args.resources.some(r => classes.some(cls => r.isType(cls))))
+                .map(([scanType]) => scanType);
+            if (uncovered.length > 0) {
+                reportViolation(`The stack creates resources that Amazon Inspector should scan, but doesn't enable ` +
+                    `it for the scan types ${uncovered.join(", ")}. Add an 'aws.inspector2.Enabler' resource with ` +
+                    "these 'resourceTypes' to find software vulnerabilities and unintended network exposure.");
+            }
+        },
+    };
+registerPolicy("inspectorEnabled", inspectorEnabled);
@@ -0,0 +1,109 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+
+import { EnforcementLevel } from "@pulumi/policy";
+
+/**
+ * How serious a policy's violations are, from "critical" (e.g. resources exposed to the internet)
+ * to "low" (e.g. cost and hygiene checks).
+ */
+export type Severity = "critical" | "high" | "medium" | "low";
+
+/**
+ * The enforcement level to run the policies of each severity with. Severities that aren't given
+ * leave their policies' enforcement levels unchanged.
+ */
+export type SeverityEnforcement = Partial<Record<Severity, EnforcementLevel>>;
+
+const severities: Severity[] = ["critical", "high", "medium", "low"];
+
+/** @internal */
+export function isSeverity(value: any): value is Severity {
+    return severities.includes(value);
+}
+
+// The severity of each policy, by policy name. Policies that aren't listed are "medium".
+const policySeverities: Record<string, Severity> = {
+    "codebuild-no-plaintext-credentials": "critical",
+    "ec2-instance-profile-least-privilege": "critical",
+    "ecr-repository-no-public-access": "critical",
+    "kms-key-policy-no-wildcard-admin": "critical",
+    "mfa-enabled-for-iam-console-access": "critical",
+    "rds-instance-public-access": "critical",
+    "redshift-cluster-public-access": "critical",
+    "s3-bucket-acl-no-public": "critical",
+    "security-group-restricted-ingress": "critical",
+    "sns-topic-access-policy": "critical",
+    "sqs-queue-access-policy": "critical",
+
+    "access-keys-rotated": "high",
+    "acm-certificate-expiration": "high",
+    "alb-http-to-https-redirection": "high",
+    "apigateway-method-cached-and-encrypted": "high",
+    "apigatewayv2-websocket-connect-auth": "high",
+    "batch-no-public-ip": "high",
+    "cmk-backing-key-rotation-enabled": "high",
+    "codepipeline-artifact-encryption": "high",
+    "codebuild-privileged-mode": "high",
+    "dax-cluster-encryption": "high",
+    "dynamodb-table-encryption-enabled": "high",
+    "ec2-approved-ami-owner": "high",
+    "ec2-imdsv2-required": "high",
+    "ec2-instance-no-public-ip": "high",
+    "efs-encrypted": "high",
+    "elasticsearch-encrypted-at-rest": "high",
+    "elasticsearch-in-vpc-only": "high",
+    "encrypted-volumes": "high",
+    "firehose-server-side-encryption": "high",
+    "fsx-encryption": "high",
+    "glue-job-security-configuration": "high",
+    "glue-security-configuration-encryption": "high",
+    "kinesis-stream-encryption": "high",
+    "memorydb-cluster-encryption": "high",
+    "minimum-tls-version": "high",
+    "msk-cluster-encryption": "high",
+    "rds-ca-certificate-current": "high",
+    "rds-performance-insights-encrypted": "high",
+    "rds-storage-encrypted": "high",
+    "redshift-cluster-configuration": "high",
+    "redshift-serverless-encryption": "high",
+    "sagemaker-endpoint-config-encryption": "high",
+    "sagemaker-notebook-no-direct-internet": "high",
+    "timestream-database-kms-key": "high",
+    "timestream-magnetic-store-rejected-data-encrypted": "high",
+    "transfer-server-security-policy": "high",
+    "workspaces-volume-encryption": "high",
+
+    "apigateway-endpoint-type": "low",
+    "apigateway-stage-cached": "low",
+    "ebs-volume-type-allowlist": "low",
+    "ec2-deprecated-instance-generation": "low",
+    "ec2-instance-detailed-monitoring-enabled": "low",
+    "ec2-required-tags-on-launch-template": "low",
+    "ec2-volume-inuse": "low",
+    "eip-attached": "low",
+    "lambda-reserved-concurrency": "low",
+    "nat-gateway-cost": "low",
+    "no-inline-cloudformation": "low",
+    "redshift-cluster-maintenance-settings": "low",
+};
+
+/**
+ * Returns the severity of the policy with the given name.
+ * @internal
+ */
+export function getSeverity(policyName: string): Severity {
+    return policySeverities[policyName] || "medium";
+}
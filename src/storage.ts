@@ -14,17 +14,119 @@
 
 import * as aws from "@pulumi/aws";
 
-import { EnforcementLevel, ResourceValidationPolicy, validateResourceOfType } from "@pulumi/policy";
+import {
+    EnforcementLevel,
+    PolicyResource,
+    ResourceValidationPolicy,
+    StackValidationPolicy,
+    validateResourceOfType,
+} from "@pulumi/policy";
 
 import { registerPolicy } from "./awsGuard";
+import { namePatternFormat } from "./configSchema";
 import { defaultEnforcementLevel } from "./enforcementLevel";
+import { groupedByResource } from "./messages";
+import { PolicyArgs } from "./policyArgs";
+import { hasTag, isReferencedBy, isReferencedByNested, matchesAnyPattern } from "./util";
 
 // Mixin additional properties onto AwsGuardArgs.
 declare module "./awsGuard" {
     interface AwsGuardArgs {
+        /**
+         * Checks whether Amazon Elastic File System (Amazon EFS) is configured to encrypt the file data using AWS Key
+         * Management Service (AWS KMS).
+         *
+         * Enforcement level of the `efs-encrypted` policy.
+         */
         efsEncrypted?: EnforcementLevel;
+
+        /**
+         * Checks whether Elastic Load Balancing has deletion protection enabled.
+         *
+         * Enforcement level of the `elb-deletion-protection-enabled` policy.
+         */
         elbDeletionProtectionEnabled?: EnforcementLevel;
+
+        /**
+         * Checks whether logging is enabled for your S3 buckets.
+         *
+         * Enforcement level of the `s3-bucket-logging-enabled` policy.
+         */
         s3BucketLoggingEnabled?: EnforcementLevel;
+
+        /**
+         * Checks whether S3 buckets have lifecycle rules to manage the expiration or transition of objects.
+         *
+         * Enforcement level of the `s3-bucket-lifecycle-configured` policy, or its enforcement level and options:
+         * `requireExpiration`.
+         */
+        s3BucketLifecycleConfigured?: EnforcementLevel | (S3BucketLifecycleConfiguredArgs & PolicyArgs);
+
+        /**
+         * Checks whether S3 buckets that require write-once-read-many (WORM) storage have object lock enabled.
+         *
+         * Enforcement level of the `s3-bucket-object-lock-enabled` policy, or its enforcement level and options:
+         * `wormTagKey`, `wormTagValue`, `wormBucketNames`.
+         */
+        s3BucketObjectLockEnabled?: EnforcementLevel | (S3BucketObjectLockEnabledArgs & PolicyArgs);
+
+        /**
+         * Checks whether versioned S3 buckets have MFA delete enabled, so that deleting object versions or suspending
+         * versioning requires multi-factor authentication.
+         *
+         * Enforcement level of the `s3-bucket-mfa-delete` policy.
+         */
+        s3BucketMfaDelete?: EnforcementLevel;
+
+        /**
+         * Checks whether AWS Transfer Family servers use a sufficiently recent security policy and don't enable
+         * plaintext FTP.
+         *
+         * Enforcement level of the `transfer-server-security-policy` policy, or its enforcement level and options:
+         * `minimumSecurityPolicyName`, `ftpAllowedServerNames`.
+         */
+        transferServerSecurityPolicy?: EnforcementLevel | (TransferServerSecurityPolicyArgs & PolicyArgs);
+
+        /**
+         * Checks whether Amazon FSx file systems are encrypted with a customer managed KMS key. Lustre scratch file
+         * systems, which can't use a customer managed key, are skipped.
+         *
+         * Enforcement level of the `fsx-encryption` policy, or its enforcement level and options:
+         * `requireCustomerManagedKey`.
+         */
+        fsxEncryption?: EnforcementLevel | (FsxEncryptionArgs & PolicyArgs);
+
+        /**
+         * Checks whether S3 buckets tagged as requiring disaster recovery replication have a replication configuration.
+         *
+         * Enforcement level of the `s3-bucket-replication-configured` policy, or its enforcement level and options:
+         * `replicationTagKey`, `replicationTagValue`.
+         */
+        s3BucketReplicationConfigured?: EnforcementLevel | (S3BucketReplicationConfiguredArgs & PolicyArgs);
+
+        /**
+         * Checks that S3 bucket ACLs do not grant access to all users or to all authenticated AWS users.
+         *
+         * Enforcement level of the `s3-bucket-acl-no-public` policy.
+         */
+        s3BucketAclNoPublic?: EnforcementLevel;
+
+        /**
+         * Checks whether the Lambda functions, SQS queues and SNS subscriptions that S3 bucket notifications deliver
+         * events to have a dead-letter queue, so that events that can't be processed aren't lost.
+         *
+         * Enforcement level of the `s3-event-notification-reliability` policy.
+         */
+        s3EventNotificationReliability?: EnforcementLevel;
+
+        /**
+         * Checks whether S3 buckets holding large or long-lived data, selected by tag, have an intelligent-tiering
+         * configuration or a lifecycle rule that transitions objects to cheaper storage classes.
+         *
+         * Enforcement level of the `s3-intelligent-tiering` policy, or its enforcement level and options:
+         * `scopeTagKey`, `scopeTagValue`.
+         */
+        s3IntelligentTiering?: EnforcementLevel | (S3IntelligentTieringArgs & PolicyArgs);
     }
 }
 
@@ -74,3 +176,469 @@ export const s3BucketLoggingEnabled: ResourceValidationPolicy = {
         }),
     };
 registerPolicy("s3BucketLoggingEnabled", s3BucketLoggingEnabled);
+
+// Properties of an S3 bucket that other resources use to refer to it.
+const bucketIdProperties = ["id", "bucket"];
+
+function isBucket(resource: PolicyResource): boolean {
+    return resource.isType(aws.s3.Bucket) || resource.isType(aws.s3.BucketV2);
+}
+
+export interface S3BucketLifecycleConfiguredArgs {
+    /** If true, at least one lifecycle rule must expire objects. Defaults to false. */
+    requireExpiration?: boolean;
+}
+
+/** @internal */
+export const s3BucketLifecycleConfigured: StackValidationPolicy = {
+        name: "s3-bucket-lifecycle-configured",
+        description: "Checks whether S3 buckets have lifecycle rules to manage the expiration or transition of objects.",
+        enforcementLevel: "advisory",
+        configSchema: {
+            properties: {
+                requireExpiration: {
+                    type: "boolean",
+                    default: false,
+                },
+            },
+        },
+        validateStack: (args, reportViolation) => {
+            const { requireExpiration } = args.getConfig<Required<S3BucketLifecycleConfiguredArgs>>();
+
+            const lifecycleConfigurations = args.resources.filter(r => r.isType(aws.s3.BucketLifecycleConfigurationV2));
+            for (const bucket of args.resources.filter(isBucket)) {
+                // Inline rules on the bucket, plus any standalone lifecycle configuration for it.
+                const rules: any[] = (bucket.props.lifecycleRules || []).filter((rule: any) => rule.enabled !== false);
+                for (const config of lifecycleConfigurations) {
+                    if (isReferencedBy(bucket, config, "bucket", bucketIdProperties)) {
+                        rules.push(...(config.props.rules || []).filter((rule: any) => rule.status !== "Disabled"));
+                    }
+                }
+
+                if (rules.length === 0) {
+                    reportViolation(`S3 bucket '${bucket.name}' must have lifecycle rules configured.`, bucket.urn);
+                } else if (requireExpiration && !rules.some(rule => rule.expiration)) {
+                    reportViolation(`S3 bucket '${bucket.name}' must have a lifecycle rule that expires objects.`, bucket.urn);
+                }
+            }
+        },
+    };
+registerPolicy("s3BucketLifecycleConfigured", s3BucketLifecycleConfigured);
+
+export interface S3BucketObjectLockEnabledArgs {
+    /** Buckets with this tag require WORM storage. Defaults to "worm". */
+    wormTagKey?: string;
+
+    /** The value the `wormTagKey` tag must have for a bucket to require WORM storage. Defaults to "true". */
+    wormTagValue?: string;
+
+    /** Names or name patterns of buckets (resource names or bucket names) that require WORM storage. */
+    wormBucketNames?: string[];
+}
+
+/** @internal */
+export const s3BucketObjectLockEnabled: StackValidationPolicy = {
+        name: "s3-bucket-object-lock-enabled",
+        description: "Checks whether S3 buckets that require write-once-read-many (WORM) storage have object lock enabled.",
+        enforcementLevel: "advisory",
+        configSchema: {
+            properties: {
+                wormTagKey: {
+                    type: "string",
+                    default: "worm",
+                },
+                wormTagValue: {
+                    type: "string",
+                    default: "true",
+                },
+                wormBucketNames: {
+                    type: "array",
+                    items: { type: "string", format: namePatternFormat },
+                    default: [],
+                },
+            },
+        },
+        validateStack: (args, reportViolation) => {
+            const { wormTagKey, wormTagValue, wormBucketNames } = args.getConfig<Required<S3BucketObjectLockEnabledArgs>>();
+
+            const lockConfigurations = args.resources.filter(r => r.isType(aws.s3.BucketObjectLockConfigurationV2));
+            for (const bucket of args.resources.filter(isBucket)) {
+                const requiresWorm = hasTag(bucket.props, wormTagKey, wormTagValue) ||
+                    matchesAnyPattern([bucket.name, bucket.props.bucket], wormBucketNames);
+                if (!requiresWorm) {
+                    continue;
+                }
+
+                const inlineConfig = bucket.props.objectLockConfiguration;
+                const lockEnabled = bucket.props.objectLockEnabled === true ||
+                    (inlineConfig !== undefined && inlineConfig.objectLockEnabled === "Enabled") ||
+                    lockConfigurations.some(config =>
+                        isReferencedBy(bucket, config, "bucket", bucketIdProperties) && config.props.objectLockEnabled === "Enabled");
+
+                if (!lockEnabled) {
+                    reportViolation(
+                        `S3 bucket '${bucket.name}' requires WORM storage and must have object lock enabled. ` +
+                        "Object lock can only be enabled when the bucket is created and cannot be added later.", bucket.urn);
+                }
+            }
+        },
+    };
+registerPolicy("s3BucketObjectLockEnabled", s3BucketObjectLockEnabled);
+
+/** @internal */
+export const s3BucketMfaDelete: StackValidationPolicy = {
+        name: "s3-bucket-mfa-delete",
+        description: "Checks whether versioned S3 buckets have MFA delete enabled, so that deleting object versions " +
+            "or suspending versioning requires multi-factor authentication.",
+        enforcementLevel: "advisory",
+        validateStack: (args, reportViolation) => {
+            const versionings = args.resources.filter(r => r.isType(aws.s3.BucketVersioningV2));
+            for (const bucket of args.resources.filter(isBucket)) {
+                // Inline versioning on the bucket, or a standalone versioning configuration for it.
+                const inlineVersioning = bucket.props.versioning;
+                const configurations = versionings
+                    .filter(versioning => isReferencedBy(bucket, versioning, "bucket", bucketIdProperties))
+                    .map(versioning => versioning.props.versioningConfiguration || {});
+
+                // MFA delete only protects object versions, so unversioned buckets don't need it.
+                const versioned = (inlineVersioning !== undefined && inlineVersioning.enabled === true) ||
+                    configurations.some(configuration => configuration.status === "Enabled");
+                if (!versioned) {
+                    continue;
+                }
+
+                const mfaDelete = (inlineVersioning !== undefined && inlineVersioning.mfaDelete === true) ||
+                    configurations.some(configuration => configuration.mfaDelete === "Enabled");
+                if (!mfaDelete) {
+                    reportViolation(
+                        `S3 bucket '${bucket.name}' is versioned and should have MFA delete enabled. Enable it ` +
+                        "with an 'aws.s3.BucketVersioningV2' for the bucket that sets 'mfaDelete' to \"Enabled\" " +
+                        "and 'mfa' to the root account's MFA device serial number and current code.", bucket.urn);
+                }
+            }
+        },
+    };
+registerPolicy("s3BucketMfaDelete", s3BucketMfaDelete);
+
+
+export interface TransferServerSecurityPolicyArgs {
+    /**
+     * The oldest security policy Transfer Family servers may use. Policies are compared by the date in
+     * their name. Defaults to "TransferSecurityPolicy-2020-06".
+     */
+    minimumSecurityPolicyName?: string;
+
+    /** Resource names or name patterns of servers that are allowed to enable the plaintext FTP protocol. */
+    ftpAllowedServerNames?: string[];
+}
+
+// Transfer Family servers use this security policy when `securityPolicyName` isn't set.
+const defaultTransferSecurityPolicyName = "TransferSecurityPolicy-2018-11";
+
+// Returns the "YYYY-MM" date that ends every Transfer Family security policy name, if present.
+function transferSecurityPolicyDate(name: string): string | undefined {
+    const match = /(\d{4}-\d{2})$/.exec(name);
+    return match ? match[1] : undefined;
+}
+
+/** @internal */
+export const transferServerSecurityPolicy: ResourceValidationPolicy = {
+        name: "transfer-server-security-policy",
+        description: "Checks whether AWS Transfer Family servers use a sufficiently recent security policy and don't enable plaintext FTP.",
+        configSchema: {
+            properties: {
+                minimumSecurityPolicyName: {
+                    type: "string",
+                    default: "TransferSecurityPolicy-2020-06",
+                },
+                ftpAllowedServerNames: {
+                    type: "array",
+                    items: { type: "string", format: namePatternFormat },
+                    default: [],
+                },
+            },
+        },
+        validateResource: validateResourceOfType(aws.transfer.Server, (server, args, reportViolation) => {
+            const { minimumSecurityPolicyName, ftpAllowedServerNames } =
+                args.getConfig<Required<TransferServerSecurityPolicyArgs>>();
+
+            const securityPolicyName = server.securityPolicyName || defaultTransferSecurityPolicyName;
+            const date = transferSecurityPolicyDate(securityPolicyName);
+            const minimumDate = transferSecurityPolicyDate(minimumSecurityPolicyName);
+            if (date === undefined || (minimumDate !== undefined && date < minimumDate)) {
+                reportViolation(
+                    `Transfer server '${args.name}' uses security policy '${securityPolicyName}', ` +
+                    `which is older than the minimum '${minimumSecurityPolicyName}'.`);
+            }
+
+            if ((server.protocols || []).includes("FTP") && !matchesAnyPattern(args.name, ftpAllowedServerNames)) {
+                reportViolation(`Transfer server '${args.name}' must not enable the plaintext FTP protocol.`);
+            }
+        }),
+    };
+registerPolicy("transferServerSecurityPolicy", transferServerSecurityPolicy);
+
+
+export interface FsxEncryptionArgs {
+    /**
+     * If true, FSx file systems must be encrypted with a customer managed KMS key rather than the
+     * AWS managed key they use by default. Defaults to true.
+     */
+    requireCustomerManagedKey?: boolean;
+}
+
+/** @internal */
+export const fsxEncryption: ResourceValidationPolicy = {
+        name: "fsx-encryption",
+        description: "Checks whether Amazon FSx file systems are encrypted with a customer managed KMS key. " +
+            "Lustre scratch file systems, which can't use a customer managed key, are skipped.",
+        configSchema: {
+            properties: {
+                requireCustomerManagedKey: {
+                    type: "boolean",
+                    default: true,
+                },
+            },
+        },
+        validateResource: [
+            validateResourceOfType(aws.fsx.LustreFileSystem, (fileSystem, args, reportViolation) => {
+                const { requireCustomerManagedKey } = args.getConfig<FsxEncryptionArgs>();
+                // Lustre file systems are scratch file systems unless a persistent deployment type is specified.
+                const deploymentType = fileSystem.deploymentType || "SCRATCH_1";
+                if (requireCustomerManagedKey !== false && !deploymentType.startsWith("SCRATCH") && !fileSystem.kmsKeyId) {
+                    reportViolation(`FSx for Lustre file system '${args.name}' must be encrypted with a customer managed KMS key.`);
+                }
+            }),
+            validateResourceOfType(aws.fsx.WindowsFileSystem, (fileSystem, args, reportViolation) => {
+                const { requireCustomerManagedKey } = args.getConfig<FsxEncryptionArgs>();
+                if (requireCustomerManagedKey !== false && !fileSystem.kmsKeyId) {
+                    reportViolation(`FSx for Windows File Server file system '${args.name}' must be encrypted with a customer managed KMS key.`);
+                }
+            }),
+            validateResourceOfType(aws.fsx.OntapFileSystem, (fileSystem, args, reportViolation) => {
+                const { requireCustomerManagedKey } = args.getConfig<FsxEncryptionArgs>();
+                if (requireCustomerManagedKey !== false && !fileSystem.kmsKeyId) {
+                    reportViolation(`FSx for NetApp ONTAP file system '${args.name}' must be encrypted with a customer managed KMS key.`);
+                }
+            }),
+        ],
+    };
+registerPolicy("fsxEncryption", fsxEncryption);
+
+export interface S3BucketReplicationConfiguredArgs {
+    /** Buckets with this tag require replication for disaster recovery. Defaults to "dr-replication". */
+    replicationTagKey?: string;
+
+    /** The value the `replicationTagKey` tag must have for a bucket to require replication. Defaults to "true". */
+    replicationTagValue?: string;
+}
+
+/** @internal */
+export const s3BucketReplicationConfigured: StackValidationPolicy = {
+        name: "s3-bucket-replication-configured",
+        description: "Checks whether S3 buckets tagged as requiring disaster recovery replication have a replication configuration.",
+        enforcementLevel: "advisory",
+        configSchema: {
+            properties: {
+                replicationTagKey: {
+                    type: "string",
+                    default: "dr-replication",
+                },
+                replicationTagValue: {
+                    type: "string",
+                    default: "true",
+                },
+            },
+        },
+        validateStack: (args, reportViolation) => {
+            const { replicationTagKey, replicationTagValue } = args.getConfig<S3BucketReplicationConfiguredArgs>();
+            if (!replicationTagKey) {
+                return;
+            }
+
+            const replicationConfigs = args.resources.filter(r => r.isType(aws.s3.BucketReplicationConfig));
+            for (const bucket of args.resources.filter(isBucket)) {
+                if (!hasTag(bucket.props, replicationTagKey, replicationTagValue)) {
+                    continue;
+                }
+
+                const inlineConfig = bucket.props.replicationConfiguration;
+                const replicated = (inlineConfig !== undefined && (inlineConfig.rules || []).length > 0) ||
+                    (bucket.props.replicationConfigurations || []).some((config: any) => (config.rules || []).length > 0) ||
+                    replicationConfigs.some(config => isReferencedBy(bucket, config, "bucket", bucketIdProperties));
+                if (!replicated) {
+                    reportViolation(
+                        `S3 bucket '${bucket.name}' requires disaster recovery replication and must have a replication configuration.`,
+                        bucket.urn);
+                }
+            }
+        },
+    };
+registerPolicy("s3BucketReplicationConfigured", s3BucketReplicationConfigured);
+
+// Canned ACLs that grant access to everyone, or to any AWS account.
+const publicCannedAcls = ["public-read", "public-read-write", "authenticated-read"];
+
+// Grantee groups that include everyone, or any AWS account.
+const publicGranteeGroups = [
+    "http://acs.amazonaws.com/groups/global/AllUsers",
+    "http://acs.amazonaws.com/groups/global/AuthenticatedUsers",
+];
+
+/** @internal */
+export const s3BucketAclNoPublic: ResourceValidationPolicy = {
+        name: "s3-bucket-acl-no-public",
+        description: "Checks that S3 bucket ACLs do not grant access to all users or to all authenticated AWS users.",
+        validateResource: [
+            validateResourceOfType(aws.s3.Bucket, (bucket, args, reportViolation) => {
+                if (bucket.acl && publicCannedAcls.includes(bucket.acl)) {
+                    reportViolation(`S3 bucket '${args.name}' must not use the public ACL '${bucket.acl}'.`);
+                }
+                for (const grant of bucket.grants || []) {
+                    if (grant.uri && publicGranteeGroups.includes(grant.uri)) {
+                        reportViolation(`S3 bucket '${args.name}' must not grant access to the group '${grant.uri}'.`);
+                    }
+                }
+            }),
+            validateResourceOfType(aws.s3.BucketAclV2, (acl, args, reportViolation) => {
+                // The bucket's name is only known here if it was given literally.
+                const bucketName = typeof acl.bucket === "string" ? acl.bucket : args.name;
+                if (acl.acl && publicCannedAcls.includes(acl.acl)) {
+                    reportViolation(`S3 bucket '${bucketName}' must not use the public ACL '${acl.acl}'.`);
+                }
+                const grants = (acl.accessControlPolicy && acl.accessControlPolicy.grants) || [];
+                for (const grant of grants) {
+                    const uri = grant.grantee && grant.grantee.uri;
+                    if (uri && publicGranteeGroups.includes(uri)) {
+                        reportViolation(`S3 bucket '${bucketName}' must not grant access to the group '${uri}'.`);
+                    }
+                }
+            }),
+        ],
+    };
+registerPolicy("s3BucketAclNoPublic", s3BucketAclNoPublic);
+
+// Returns the values of `property` of each element of a list of objects, e.g. the ARNs of a bucket
+// notification's targets.
+function pluck(elements: any, property: string): any[] {
+    return (Array.isArray(elements) ? elements : []).map(element => element && element[property]);
+}
+
+/** @internal */
+export const s3EventNotificationReliability: StackValidationPolicy = {
+        name: "s3-event-notification-reliability",
+        description: "Checks whether the Lambda functions, SQS queues and SNS subscriptions that S3 bucket notifications " +
+            "deliver events to have a dead-letter queue, so that events that can't be processed aren't lost.",
+        enforcementLevel: "advisory",
+        validateStack: groupedByResource((args, reportViolation) => {
+            const invokeConfigs = args.resources.filter(r => r.isType(aws.lambda.FunctionEventInvokeConfig));
+            const redrivePolicies = args.resources.filter(r => r.isType(aws.sqs.RedrivePolicy));
+            const subscriptions = args.resources.filter(r => r.isType(aws.sns.TopicSubscription));
+
+            const functionHasDlq = (fn: PolicyResource) =>
+                !!(fn.props.deadLetterConfig && fn.props.deadLetterConfig.targetArn) ||
+                invokeConfigs.some(config => {
+                    const onFailure = config.props.destinationConfig && config.props.destinationConfig.onFailure;
+                    return !!(onFailure && onFailure.destination) &&
+                        isReferencedBy(fn, config, "functionName", ["name", "arn", "id"]);
+                });
+            const queueHasDlq = (queue: PolicyResource) =>
+                !!queue.props.redrivePolicy ||
+                redrivePolicies.some(policy => isReferencedBy(queue, policy, "queueUrl", ["url", "id"]));
+
+            for (const notification of args.resources.filter(r => r.isType(aws.s3.BucketNotification))) {
+                const props = notification.props;
+                for (const fn of args.resources.filter(r => r.isType(aws.lambda.Function))) {
+                    const targeted = isReferencedByNested(
+                        fn, notification, "lambdaFunctions", pluck(props.lambdaFunctions, "lambdaFunctionArn"), ["arn"]);
+                    if (targeted && !functionHasDlq(fn)) {
+                        reportViolation(
+                            `Bucket notification '${notification.name}' sends events to Lambda function '${fn.name}', ` +
+                            "which has no dead-letter queue or on-failure destination.", notification.urn);
+                    }
+                }
+                for (const queue of args.resources.filter(r => r.isType(aws.sqs.Queue))) {
+                    const targeted = isReferencedByNested(queue, notification, "queues", pluck(props.queues, "queueArn"), ["arn"]);
+                    if (targeted && !queueHasDlq(queue)) {
+                        reportViolation(
+                            `Bucket notification '${notification.name}' sends events to SQS queue '${queue.name}', ` +
+                            "which has no dead-letter queue.", notification.urn);
+                    }
+                }
+                for (const topic of args.resources.filter(r => r.isType(aws.sns.Topic))) {
+                    if (!isReferencedByNested(topic, notification, "topics", pluck(props.topics, "topicArn"), ["arn", "id"])) {
+                        continue;
+                    }
+                    // SNS retries delivery to, and keeps a dead-letter queue for, each subscription.
+                    for (const subscription of subscriptions) {
+                        if (isReferencedBy(topic, subscription, "topic", ["arn", "id"]) && !subscription.props.redrivePolicy) {
+                            reportViolation(
+                                `Bucket notification '${notification.name}' sends events to SNS topic '${topic.name}', ` +
+                                `whose subscription '${subscription.name}' has no dead-letter queue.`, notification.urn);
+                        }
+                    }
+                }
+            }
+        }),
+    };
+registerPolicy("s3EventNotificationReliability", s3EventNotificationReliability);
+
+export interface S3IntelligentTieringArgs {
+    /** Buckets with this tag hold large or long-lived data, and are checked. Defaults to "long-lived". */
+    scopeTagKey?: string;
+
+    /** The value the `scopeTagKey` tag must have for a bucket to be checked. Defaults to "true". */
+    scopeTagValue?: string;
+}
+
+/** @internal */
+export const s3IntelligentTiering: StackValidationPolicy = {
+        name: "s3-intelligent-tiering",
+        description: "Checks whether S3 buckets holding large or long-lived data, selected by tag, have an " +
+            "intelligent-tiering configuration or a lifecycle rule that transitions objects to cheaper storage classes.",
+        enforcementLevel: "advisory",
+        configSchema: {
+            properties: {
+                scopeTagKey: {
+                    type: "string",
+                    default: "long-lived",
+                },
+                scopeTagValue: {
+                    type: "string",
+                    default: "true",
+                },
+            },
+        },
+        validateStack: (args, reportViolation) => {
+            const { scopeTagKey, scopeTagValue } = args.getConfig<S3IntelligentTieringArgs>();
+            const tagKey = scopeTagKey || "long-lived";
+            const tagValue = scopeTagValue !== undefined ? scopeTagValue : "true";
+
+            const tieringConfigurations = args.resources.filter(r => r.isType(aws.s3.BucketIntelligentTieringConfiguration));
+            const lifecycleConfigurations = args.resources.filter(r => r.isType(aws.s3.BucketLifecycleConfigurationV2));
+            for (const bucket of args.resources.filter(isBucket)) {
+                if (!hasTag(bucket.props, tagKey, tagValue)) {
+                    continue;
+                }
+
+                const tiered = tieringConfigurations.some(config =>
+                    isReferencedBy(bucket, config, "bucket", bucketIdProperties) && config.props.status !== "Disabled");
+                // Inline rules on the bucket, plus any standalone lifecycle configuration for it.
+                const transitions = (bucket.props.lifecycleRules || [])
+                    .filter((rule: any) => rule.enabled !== false && (rule.transitions || []).length > 0);
+                for (const config of lifecycleConfigurations) {
+                    if (isReferencedBy(bucket, config, "bucket", bucketIdProperties)) {
+                        transitions.push(...(config.props.rules || [])
+                            .filter((rule: any) => rule.status !== "Disabled" && (rule.transitions || []).length > 0));
+                    }
+                }
+
+                if (!tiered && transitions.length === 0) {
+                    reportViolation(
+                        `S3 bucket '${bucket.name}' holds long-lived data and should have an intelligent-tiering ` +
+                        "configuration or a lifecycle rule that transitions objects to cheaper storage classes.", bucket.urn);
+                }
+            }
+        },
+    };
+registerPolicy("s3IntelligentTiering", s3IntelligentTiering);
@@ -0,0 +1,95 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+
+import * as assert from "assert";
+
+import "mocha";
+
+import * as aws from "@pulumi/aws";
+import { PolicyPackConfig, ResourceValidationPolicy } from "@pulumi/policy";
+
+import { getAcknowledgement } from "../acknowledge";
+import { applyEnforcementLevelCallback } from "../awsGuard";
+import * as compute from "../compute";
+
+import { createResourceValidationArgs } from "./util";
+
+// Make mixins available.
+import "../index";
+
+describe("#getAcknowledgement", () => {
+    it("returns the reason of the policy's acknowledgement tag", () => {
+        const props = { tags: { "awsguard/acknowledge/encrypted-volumes": "Migrating in Q3" } };
+        assert.strictEqual(getAcknowledgement(props, "encrypted-volumes"), "Migrating in Q3");
+        assert.strictEqual(getAcknowledgement(props, "ec2-no-key-pair"), undefined);
+    });
+
+    it("ignores acknowledgements without a reason", () => {
+        const props = { tags: { "awsguard/acknowledge/encrypted-volumes": " " } };
+        assert.strictEqual(getAcknowledgement(props, "encrypted-volumes"), undefined);
+        assert.strictEqual(getAcknowledgement({}, "encrypted-volumes"), undefined);
+    });
+});
+
+describe("#applyEnforcementLevelCallback with acknowledgements", () => {
+    async function getViolations(policy: ResourceValidationPolicy, tags: Record<string, string>): Promise<string[]> {
+        const args = createResourceValidationArgs(aws.ec2.Instance, {
+            ami: "ami-12345678",
+            instanceType: "t2.micro",
+            tags,
+        });
+        const violations: string[] = [];
+        for (const validation of Array.isArray(policy.validateResource) ? policy.validateResource : [policy.validateResource]) {
+            await validation(args, message => violations.push(message));
+        }
+        return violations;
+    }
+
+    it("downgrades acknowledged violations to advisory and includes the reason", async () => {
+        const config: PolicyPackConfig = { all: "mandatory" };
+        const [mandatory, advisory] = <ResourceValidationPolicy[]>applyEnforcementLevelCallback(
+            compute.encryptedVolumes, { allowAcknowledgements: true }, config);
+
+        assert.strictEqual(mandatory.name, "encrypted-volumes");
+        assert.strictEqual(advisory.name, "encrypted-volumes-advisory");
+
+        const acknowledged = { "awsguard/acknowledge/encrypted-volumes": "Legacy AMI, see OPS-123" };
+        assert.deepStrictEqual(await getViolations(mandatory, acknowledged), []);
+        assert.deepStrictEqual(await getViolations(advisory, acknowledged), [
+            "The EC2 instance root block device must be encrypted. (acknowledged: Legacy AMI, see OPS-123)",
+        ]);
+
+        assert.deepStrictEqual(await getViolations(mandatory, {}), [
+            "The EC2 instance root block device must be encrypted.",
+        ]);
+        assert.deepStrictEqual(await getViolations(advisory, {}), []);
+    });
+
+    it("only downgrades violations of the acknowledged policy", async () => {
+        const [mandatory] = <ResourceValidationPolicy[]>applyEnforcementLevelCallback(
+            compute.encryptedVolumes, { allowAcknowledgements: true }, { all: "mandatory" });
+        const acknowledged = { "awsguard/acknowledge/ec2-no-key-pair": "Bastion host" };
+        assert.deepStrictEqual(await getViolations(mandatory, acknowledged), [
+            "The EC2 instance root block device must be encrypted.",
+        ]);
+    });
+
+    it("leaves the policy unchanged if acknowledgements aren't allowed or it is already advisory", () => {
+        const policy = compute.encryptedVolumes;
+        assert.deepStrictEqual(applyEnforcementLevelCallback(policy, {}, { all: "mandatory" }), [policy]);
+        assert.deepStrictEqual(
+            applyEnforcementLevelCallback(policy, { allowAcknowledgements: true }, { all: "advisory" }), [policy]);
+    });
+});
@@ -0,0 +1,219 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import "mocha";
+
+import * as aws from "@pulumi/aws";
+import { ResourceValidationArgs } from "@pulumi/policy";
+
+import * as analytics from "../analytics";
+
+import { assertHasResourceViolation, assertNoResourceViolations, createResourceValidationArgs } from "./util";
+
+describe("#glueSecurityConfigurationEncryption", () => {
+    const policy = analytics.glueSecurityConfigurationEncryption;
+
+    function getHappyPathArgs(): ResourceValidationArgs {
+        return createResourceValidationArgs(aws.glue.SecurityConfiguration, {
+            encryptionConfiguration: {
+                cloudwatchEncryption: { cloudwatchEncryptionMode: "SSE-KMS", kmsKeyArn: "test-key-arn" },
+                jobBookmarksEncryption: { jobBookmarksEncryptionMode: "CSE-KMS", kmsKeyArn: "test-key-arn" },
+                s3Encryption: { s3EncryptionMode: "SSE-KMS", kmsKeyArn: "test-key-arn" },
+            },
+        });
+    }
+
+    it("Should pass if all encryption modes are enabled", async () => {
+        const args = getHappyPathArgs();
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should fail if CloudWatch encryption is disabled", async () => {
+        const args = getHappyPathArgs();
+        args.props.encryptionConfiguration.cloudwatchEncryption.cloudwatchEncryptionMode = "DISABLED";
+
+        await assertHasResourceViolation(policy, args, { message: "must have CloudWatch encryption enabled." });
+    });
+
+    it("Should fail if job bookmark encryption is disabled", async () => {
+        const args = getHappyPathArgs();
+        args.props.encryptionConfiguration.jobBookmarksEncryption.jobBookmarksEncryptionMode = "DISABLED";
+
+        await assertHasResourceViolation(policy, args, { message: "must have job bookmark encryption enabled." });
+    });
+
+    it("Should fail if S3 encryption is disabled", async () => {
+        const args = getHappyPathArgs();
+        args.props.encryptionConfiguration.s3Encryption.s3EncryptionMode = "DISABLED";
+
+        await assertHasResourceViolation(policy, args, { message: "must have S3 encryption enabled." });
+    });
+});
+
+describe("#glueJobSecurityConfiguration", () => {
+    const policy = analytics.glueJobSecurityConfiguration;
+
+    function getHappyPathArgs(): ResourceValidationArgs {
+        return createResourceValidationArgs(aws.glue.Job, {
+            roleArn: "arn:aws:iam::123456789012:role/glue",
+            command: { scriptLocation: "s3://bucket/script.py" },
+            securityConfiguration: "test-security-configuration",
+        });
+    }
+
+    it("Should pass if the job uses a security configuration", async () => {
+        const args = getHappyPathArgs();
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should fail if the job does not use a security configuration", async () => {
+        const args = getHappyPathArgs();
+        args.props.securityConfiguration = undefined;
+
+        await assertHasResourceViolation(policy, args, { message: "should use a security configuration." });
+    });
+});
+
+describe("#mskClusterEncryption", () => {
+    const policy = analytics.mskClusterEncryption;
+
+    function getHappyPathArgs(): ResourceValidationArgs {
+        return createResourceValidationArgs(aws.msk.Cluster, {
+            clusterName: "test-cluster",
+            kafkaVersion: "2.8.1",
+            numberOfBrokerNodes: 3,
+            brokerNodeGroupInfo: {
+                clientSubnets: ["subnet-1", "subnet-2", "subnet-3"],
+                instanceType: "kafka.m5.large",
+                securityGroups: ["sg-1"],
+            },
+            encryptionInfo: {
+                encryptionAtRestKmsKeyArn: "test-key-arn",
+                encryptionInTransit: { clientBroker: "TLS" },
+            },
+        });
+    }
+
+    it("Should pass if the cluster is encrypted at rest and in transit", async () => {
+        const args = getHappyPathArgs();
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should pass if client to broker encryption uses the TLS default", async () => {
+        const args = getHappyPathArgs();
+        args.props.encryptionInfo.encryptionInTransit = undefined;
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should fail if the cluster is not encrypted at rest with a KMS key", async () => {
+        const args = getHappyPathArgs();
+        args.props.encryptionInfo.encryptionAtRestKmsKeyArn = undefined;
+
+        await assertHasResourceViolation(policy, args, { message: "must encrypt data at rest with a KMS key." });
+    });
+
+    it("Should fail if the cluster allows plaintext client connections", async () => {
+        const args = getHappyPathArgs();
+        args.props.encryptionInfo.encryptionInTransit.clientBroker = "TLS_PLAINTEXT";
+
+        await assertHasResourceViolation(policy, args, {
+            message: "must use TLS for client to broker encryption in transit. 'TLS_PLAINTEXT' is not allowed.",
+        });
+    });
+});
+
+describe("#kinesisStreamEncryption", () => {
+    const policy = analytics.kinesisStreamEncryption;
+
+    function getHappyPathArgs(): ResourceValidationArgs {
+        return createResourceValidationArgs(aws.kinesis.Stream, {
+            shardCount: 1,
+            encryptionType: "KMS",
+            kmsKeyId: "alias/aws/kinesis",
+        });
+    }
+
+    it("Should pass if the stream is encrypted with a KMS key", async () => {
+        const args = getHappyPathArgs();
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should fail if the encryption type is unspecified", async () => {
+        const args = getHappyPathArgs();
+        args.props.encryptionType = undefined;
+
+        await assertHasResourceViolation(policy, args, { message: "must be encrypted." });
+    });
+
+    it("Should fail if the encryption type is NONE", async () => {
+        const args = getHappyPathArgs();
+        args.props.encryptionType = "NONE";
+
+        await assertHasResourceViolation(policy, args, { message: "must be encrypted." });
+    });
+
+    it("Should fail if KMS encryption does not specify a key", async () => {
+        const args = getHappyPathArgs();
+        args.props.kmsKeyId = undefined;
+
+        await assertHasResourceViolation(policy, args, { message: "must specify the KMS key used for encryption." });
+    });
+});
+
+describe("#firehoseServerSideEncryption", () => {
+    const policy = analytics.firehoseServerSideEncryption;
+
+    function getHappyPathArgs(): ResourceValidationArgs {
+        return createResourceValidationArgs(aws.kinesis.FirehoseDeliveryStream, {
+            destination: "extended_s3",
+            serverSideEncryption: { enabled: true },
+        }, { suggestSourceStreamEncryption: false });
+    }
+
+    it("Should pass if server-side encryption is enabled", async () => {
+        const args = getHappyPathArgs();
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should fail if server-side encryption is unspecified", async () => {
+        const args = getHappyPathArgs();
+        args.props.serverSideEncryption = undefined;
+
+        await assertHasResourceViolation(policy, args, { message: "must have server-side encryption enabled." });
+    });
+
+    it("Should fail if server-side encryption is disabled", async () => {
+        const args = getHappyPathArgs();
+        args.props.serverSideEncryption.enabled = false;
+
+        await assertHasResourceViolation(policy, args, { message: "must have server-side encryption enabled." });
+    });
+
+    it("Should skip delivery streams whose source is a Kinesis stream", async () => {
+        const args = getHappyPathArgs();
+        args.props.serverSideEncryption = undefined;
+        args.props.kinesisSourceConfiguration = { kinesisStreamArn: "test-stream-arn", roleArn: "test-role-arn" };
+
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should suggest encrypting the source stream if configured", async () => {
+        const args = createResourceValidationArgs(aws.kinesis.FirehoseDeliveryStream, {
+            destination: "extended_s3",
+            kinesisSourceConfiguration: { kinesisStreamArn: "test-stream-arn", roleArn: "test-role-arn" },
+        }, { suggestSourceStreamEncryption: true });
+
+        await assertHasResourceViolation(policy, args, { message: "reads from a Kinesis stream, which should be encrypted" });
+    });
+});
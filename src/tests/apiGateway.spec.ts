@@ -0,0 +1,112 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+
+import "mocha";
+
+import * as aws from "@pulumi/aws";
+
+import * as apiGateway from "../apiGateway";
+
+import {
+    assertHasStackViolation,
+    assertNoStackViolations,
+    createPolicyResource,
+    createStackValidationArgsWithResources,
+} from "./util";
+
+describe("#apiGatewayWafAssociated", () => {
+    const policy = apiGateway.apiGatewayWafAssociated;
+
+    function createStage() {
+        return createPolicyResource(aws.apigateway.Stage, {
+            restApi: "abc123",
+            deployment: "def456",
+            stageName: "prod",
+        }, "test-stage");
+    }
+
+    it("Should fail if the stage has no web ACL", async () => {
+        await assertHasStackViolation(policy, createStackValidationArgsWithResources([createStage()]), {
+            message: "API Gateway Stage 'prod' must be associated with a WAF web ACL.",
+        });
+    });
+
+    it("Should pass if a WAF web ACL association refers to the stage", async () => {
+        const stage = createStage();
+        const association = createPolicyResource(aws.wafv2.WebAclAssociation, {
+            webAclArn: "arn:aws:wafv2:us-west-2:123456789012:regional/webacl/api/1234",
+        }, "test-association", { resourceArn: [stage] });
+        await assertNoStackViolations(policy, createStackValidationArgsWithResources([stage, association]));
+
+        const classicStage = createStage();
+        classicStage.props.arn = "arn:aws:apigateway:us-west-2::/restapis/abc123/stages/prod";
+        const classicAssociation = createPolicyResource(aws.wafregional.WebAclAssociation, {
+            webAclId: "1234",
+            resourceArn: classicStage.props.arn,
+        }, "test-classic-association");
+        await assertNoStackViolations(policy, createStackValidationArgsWithResources([classicStage, classicAssociation]));
+    });
+});
+
+describe("#apiGatewayV2WebsocketConnectAuth", () => {
+    const policy = apiGateway.apiGatewayV2WebsocketConnectAuth;
+
+    function createApi() {
+        return createPolicyResource(aws.apigatewayv2.Api, {
+            name: "chat",
+            protocolType: "WEBSOCKET",
+            routeSelectionExpression: "$request.body.action",
+        }, "test-api");
+    }
+
+    it("Should fail if the $connect route doesn't require authorization", async () => {
+        const api = createApi();
+        const route = createPolicyResource(aws.apigatewayv2.Route, {
+            routeKey: "$connect",
+            authorizationType: "NONE",
+        }, "connect-route", { apiId: [api] });
+        await assertHasStackViolation(policy, createStackValidationArgsWithResources([api, route]), {
+            message: "API Gateway WebSocket API 'chat' must require authorization on its '$connect' route 'connect-route'.",
+        });
+
+        // Routes are unauthorized by default.
+        const defaultRoute = createPolicyResource(aws.apigatewayv2.Route, {
+            apiId: "abc123",
+            routeKey: "$connect",
+        }, "connect-route");
+        await assertHasStackViolation(policy, createStackValidationArgsWithResources([defaultRoute]), {
+            message: "API Gateway WebSocket API 'abc123' must require authorization on its '$connect' route",
+        });
+    });
+
+    it("Should pass if the $connect route requires authorization", async () => {
+        const api = createApi();
+        const route = createPolicyResource(aws.apigatewayv2.Route, {
+            routeKey: "$connect",
+            authorizationType: "CUSTOM",
+            authorizerId: "def456",
+        }, "connect-route", { apiId: [api] });
+        await assertNoStackViolations(policy, createStackValidationArgsWithResources([api, route]));
+    });
+
+    it("Should ignore other routes", async () => {
+        const api = createApi();
+        const route = createPolicyResource(aws.apigatewayv2.Route, {
+            routeKey: "sendMessage",
+            authorizationType: "NONE",
+        }, "send-route", { apiId: [api] });
+        await assertNoStackViolations(policy, createStackValidationArgsWithResources([api, route]));
+    });
+});
@@ -0,0 +1,245 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import "mocha";
+
+import * as aws from "@pulumi/aws";
+import { ResourceValidationArgs } from "@pulumi/policy";
+
+import * as applicationIntegration from "../applicationIntegration";
+
+import {
+    assertHasResourceViolation, assertHasStackViolation,
+    assertNoResourceViolations, assertNoStackViolations,
+    createPolicyResource, createResourceValidationArgs, createStackValidationArgsWithResources,
+} from "./util";
+
+describe("#appSyncApiLogging", () => {
+    const policy = applicationIntegration.appSyncApiLogging;
+
+    function getHappyPathArgs(): ResourceValidationArgs {
+        return createResourceValidationArgs(aws.appsync.GraphQLApi, {
+            authenticationType: "API_KEY",
+            logConfig: {
+                cloudwatchLogsRoleArn: "arn:aws:iam::123456789012:role/appsync-logs",
+                fieldLogLevel: "ERROR",
+            },
+        }, { sensitiveTagKey: "sensitive", sensitiveTagValue: "true" });
+    }
+
+    it("Should pass if the API has logging configured", async () => {
+        const args = getHappyPathArgs();
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should fail if the API has no logging configured", async () => {
+        const args = getHappyPathArgs();
+        args.props.logConfig = undefined;
+
+        await assertHasResourceViolation(policy, args, { message: "must have logging configured." });
+    });
+
+    it("Should fail if a sensitive API uses API key authentication", async () => {
+        const args = getHappyPathArgs();
+        args.props.tags = { sensitive: "true" };
+
+        await assertHasResourceViolation(policy, args, { message: "is sensitive and must not use API_KEY authentication." });
+    });
+
+    it("Should pass if a sensitive API uses IAM authentication", async () => {
+        const args = getHappyPathArgs();
+        args.props.tags = { sensitive: "true" };
+        args.props.authenticationType = "AWS_IAM";
+
+        await assertNoResourceViolations(policy, args);
+    });
+});
+
+describe("#mqBrokerEncryption", () => {
+    const policy = applicationIntegration.mqBrokerEncryption;
+
+    function getHappyPathArgs(): ResourceValidationArgs {
+        return createResourceValidationArgs(aws.mq.Broker, {
+            engineType: "ActiveMQ",
+            engineVersion: "5.17.6",
+            hostInstanceType: "mq.t3.micro",
+            users: [{ username: "admin", password: "password" }],
+            encryptionOptions: { kmsKeyId: "test-key-id", useAwsOwnedKey: false },
+            publiclyAccessible: false,
+        });
+    }
+
+    it("Should pass if the broker is encrypted, private and up to date", async () => {
+        const args = getHappyPathArgs();
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should fail if the broker isn't encrypted with a customer managed key", async () => {
+        const args = getHappyPathArgs();
+        args.props.encryptionOptions = undefined;
+        await assertHasResourceViolation(policy, args, {
+            message: "MQ broker 'unknown' must be encrypted with a customer managed KMS key.",
+        });
+
+        args.props.encryptionOptions = { useAwsOwnedKey: true };
+        await assertHasResourceViolation(policy, args, { message: "must be encrypted with a customer managed KMS key." });
+    });
+
+    it("Should fail if the broker is publicly accessible", async () => {
+        const args = getHappyPathArgs();
+        args.props.publiclyAccessible = true;
+        await assertHasResourceViolation(policy, args, { message: "MQ broker 'unknown' must not be publicly accessible." });
+    });
+
+    it("Should fail if the broker uses a deprecated engine version", async () => {
+        const args = getHappyPathArgs();
+        args.props.engineVersion = "5.15.16";
+        await assertHasResourceViolation(policy, args, {
+            message: "MQ broker 'unknown' must not use the deprecated engine version '5.15.16'.",
+        });
+
+        const configuredArgs = createResourceValidationArgs(aws.mq.Broker, getHappyPathArgs().props, {
+            deprecatedEngineVersions: ["5.17.*"],
+        });
+        await assertHasResourceViolation(policy, configuredArgs, { message: "deprecated engine version '5.17.6'." });
+    });
+});
+
+describe("#snsTopicAccessPolicy", () => {
+    const policy = applicationIntegration.snsTopicAccessPolicy;
+
+    function policyDocument(...statements: any[]): string {
+        return JSON.stringify({ Version: "2012-10-17", Statement: statements });
+    }
+
+    it("Should fail if the topic allows anyone to publish", async () => {
+        const args = createResourceValidationArgs(aws.sns.Topic, {
+            policy: policyDocument({ Sid: "PublicPublish", Effect: "Allow", Principal: "*", Action: "sns:Publish", Resource: "*" }),
+        });
+        await assertHasResourceViolation(policy, args, {
+            message: "SNS topic 'unknown' must not allow anyone to subscribe or publish: statement 'PublicPublish' " +
+                "allows any principal without a condition restricting the source.",
+        });
+    });
+
+    it("Should pass if public access is restricted by a condition", async () => {
+        const args = createResourceValidationArgs(aws.sns.Topic, {
+            policy: policyDocument({
+                Effect: "Allow",
+                Principal: { Service: "s3.amazonaws.com" },
+                Action: "sns:Publish",
+                Resource: "*",
+            }, {
+                Effect: "Allow",
+                Principal: { AWS: "*" },
+                Action: ["sns:Subscribe", "sns:Publish"],
+                Resource: "*",
+                Condition: { StringEquals: { "aws:PrincipalOrgID": "o-1234567890" } },
+            }),
+        });
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should pass if the policy is missing or not valid JSON", async () => {
+        const args = createResourceValidationArgs(aws.sns.Topic, {});
+        await assertNoResourceViolations(policy, args);
+
+        args.props.policy = "{ not json";
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should fail if a topic policy allows anyone to subscribe", async () => {
+        const args = createResourceValidationArgs(aws.sns.TopicPolicy, {
+            arn: "arn:aws:sns:us-west-2:123456789012:my-topic",
+            policy: policyDocument({ Effect: "Allow", Principal: { AWS: "*" }, Action: "SNS:*", Resource: "*" }),
+        });
+        await assertHasResourceViolation(policy, args, {
+            message: "SNS topic 'my-topic' must not allow anyone to subscribe or publish: statement 1 allows any principal",
+        });
+    });
+});
+
+describe("#sqsQueueAccessPolicy", () => {
+    const policy = applicationIntegration.sqsQueueAccessPolicy;
+
+    function policyDocument(...statements: any[]): string {
+        return JSON.stringify({ Version: "2012-10-17", Statement: statements });
+    }
+
+    it("Should fail if the queue allows anyone to access it", async () => {
+        const args = createResourceValidationArgs(aws.sqs.Queue, {
+            policy: policyDocument({ Effect: "Allow", Principal: "*", Action: "sqs:*", Resource: "*" }),
+        });
+        await assertHasResourceViolation(policy, args, {
+            message: "SQS queue 'unknown' must not allow public access: statement 1 allows any principal " +
+                "without a condition restricting the source.",
+        });
+    });
+
+    it("Should pass if public access is restricted by a condition", async () => {
+        const args = createResourceValidationArgs(aws.sqs.Queue, {
+            policy: policyDocument({
+                Sid: "AllowTopic",
+                Effect: "Allow",
+                Principal: "*",
+                Action: "sqs:SendMessage",
+                Resource: "*",
+                Condition: { ArnEquals: { "aws:SourceArn": "arn:aws:sns:us-west-2:123456789012:my-topic" } },
+            }),
+        });
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should fail if a queue policy allows anyone to send messages", async () => {
+        const args = createResourceValidationArgs(aws.sqs.QueuePolicy, {
+            queueUrl: "https://sqs.us-west-2.amazonaws.com/123456789012/my-queue",
+            policy: policyDocument({ Sid: "Public", Effect: "Allow", Principal: { AWS: "*" }, Action: "sqs:SendMessage" }),
+        });
+        await assertHasResourceViolation(policy, args, {
+            message: "SQS queue 'my-queue' must not allow public access: statement 'Public' allows any principal",
+        });
+    });
+});
+
+describe("#snsSqsEncryptionConsistency", () => {
+    const policy = applicationIntegration.snsSqsEncryptionConsistency;
+
+    function createResources(topicProps: Record<string, any>, queueProps: Record<string, any>) {
+        const topic = createPolicyResource(aws.sns.Topic, topicProps, "orders");
+        const queue = createPolicyResource(aws.sqs.Queue, queueProps, "order-processing");
+        const subscription = createPolicyResource(aws.sns.TopicSubscription, { protocol: "sqs" }, "orders-to-processing",
+            { topic: [topic], endpoint: [queue] });
+        return createStackValidationArgsWithResources([topic, queue, subscription]);
+    }
+
+    it("Should fail if an encrypted topic delivers to an unencrypted queue", async () => {
+        const args = createResources({ kmsMasterKeyId: "alias/aws/sns" }, { sqsManagedSseEnabled: false });
+        await assertHasStackViolation(policy, args, {
+            message: "SNS topic 'orders' delivers messages to SQS queue 'order-processing', but queue 'order-processing' " +
+                "is not encrypted. Both should be encrypted so that messages are protected from end to end.",
+        });
+    });
+
+    it("Should fail if the topic is not encrypted", async () => {
+        const args = createResources({}, { sqsManagedSseEnabled: false });
+        await assertHasStackViolation(policy, args, {
+            message: "but topic 'orders' and queue 'order-processing' are not encrypted.",
+        });
+    });
+
+    it("Should pass if both the topic and the queue are encrypted", async () => {
+        await assertNoStackViolations(policy, createResources({ kmsMasterKeyId: "alias/aws/sns" }, { kmsMasterKeyId: "alias/aws/sqs" }));
+        await assertNoStackViolations(policy, createResources({ kmsMasterKeyId: "alias/aws/sns" }, {}));
+    });
+});
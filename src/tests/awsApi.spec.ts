@@ -0,0 +1,117 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import * as assert from "assert";
+
+import "mocha";
+
+import { ApiErrorBehavior, callAwsApi, configureAwsApi, isApiUnavailableError } from "../awsApi";
+
+// Returns a promise that never settles, simulating an unreachable endpoint.
+function hang(): Promise<string> {
+    return new Promise<string>(() => undefined);
+}
+
+describe("#callAwsApi", () => {
+    afterEach(() => {
+        configureAwsApi({});
+    });
+
+    it("returns the result of successful calls", async () => {
+        configureAwsApi({ onApiError: "skip" });
+        assert.strictEqual(await callAwsApi("test-policy", async () => "ok"), "ok");
+    });
+
+    it("raises errors when configured to fail", async () => {
+        configureAwsApi({ onApiError: "fail", timeoutSeconds: 0.05, maxRetries: 0 });
+        await assert.rejects(callAwsApi("test-policy", hang), (err: any) => {
+            return isApiUnavailableError(err) && /did not complete within 0.05 seconds/.test(err.message);
+        });
+    });
+
+    it("returns undefined for timed out calls when configured to warn or skip", async () => {
+        configureAwsApi({ onApiError: "warn", timeoutSeconds: 0.05, maxRetries: 0 });
+        assert.strictEqual(await callAwsApi("test-policy", hang), undefined);
+
+        configureAwsApi({ onApiError: "skip", timeoutSeconds: 0.05, maxRetries: 0 });
+        assert.strictEqual(await callAwsApi("test-policy", hang), undefined);
+    });
+
+    it("returns undefined for failed calls when configured to skip", async () => {
+        configureAwsApi({ onApiError: "skip" });
+        const result = await callAwsApi("test-policy", async () => {
+            const err: any = new Error("connect EHOSTDOWN 169.254.169.254:80");
+            err.code = "EHOSTDOWN";
+            throw err;
+        });
+        assert.strictEqual(result, undefined);
+    });
+
+    it("retries transient failures until the call succeeds", async () => {
+        configureAwsApi({ onApiError: "fail", maxRetries: 3, retryBaseDelayMs: 1 });
+        let attempts = 0;
+        const result = await callAwsApi("test-policy", async () => {
+            attempts++;
+            if (attempts < 3) {
+                const err: any = new Error("Rate exceeded");
+                err.code = "ThrottlingException";
+                throw err;
+            }
+            return "ok";
+        });
+        assert.strictEqual(result, "ok");
+        assert.strictEqual(attempts, 3);
+    });
+
+    it("gives up after the configured number of retries", async () => {
+        configureAwsApi({ onApiError: "fail", maxRetries: 2, retryBaseDelayMs: 1 });
+        let attempts = 0;
+        await assert.rejects(callAwsApi("test-policy", async () => {
+            attempts++;
+            const err: any = new Error("connect ETIMEDOUT 169.254.169.254:80");
+            err.code = "ETIMEDOUT";
+            throw err;
+        }), /ETIMEDOUT/);
+        assert.strictEqual(attempts, 3);
+    });
+
+    it("does not retry errors that aren't transient", async () => {
+        configureAwsApi({ onApiError: "fail", maxRetries: 2, retryBaseDelayMs: 1 });
+        let attempts = 0;
+        await assert.rejects(callAwsApi("test-policy", async () => {
+            attempts++;
+            const err: any = new Error("Access denied");
+            err.code = "AccessDeniedException";
+            throw err;
+        }), /Access denied/);
+        assert.strictEqual(attempts, 1);
+    });
+
+    it("raises errors that aren't transient even when configured to warn or skip", async () => {
+        const behaviors: ApiErrorBehavior[] = ["warn", "skip"];
+        for (const onApiError of behaviors) {
+            configureAwsApi({ onApiError, maxRetries: 0 });
+            await assert.rejects(callAwsApi("test-policy", async () => {
+                const err: any = new Error("User is not authorized to perform: iam:ListAccessKeys");
+                err.code = "AccessDenied";
+                throw err;
+            }), /not authorized/);
+        }
+    });
+
+    it("allows the configured behavior to be overridden per call", async () => {
+        configureAwsApi({ onApiError: "fail", timeoutSeconds: 0.05, maxRetries: 0 });
+        assert.strictEqual(await callAwsApi("test-policy", hang, "skip"), undefined);
+    });
+});
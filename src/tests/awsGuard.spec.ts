@@ -16,7 +16,10 @@ import * as assert from "assert";
 
 import "mocha";
 
-import { getNameAndArgs } from "../awsGuard";
+import { ResourceValidationPolicy } from "@pulumi/policy";
+
+import { getEnforcementLevel, getInitialConfig, getNameAndArgs, getVersionSummary } from "../awsGuard";
+import * as compute from "../compute";
 
 // Make mixins available.
 import "../index";
@@ -43,4 +46,126 @@ describe("#AwsGuard", () => {
             assert.deepStrictEqual(getNameAndArgs({}, { all: "disabled" }), [defaultName, {}]);
         });
     });
+
+    describe("getEnforcementLevel", () => {
+        const policy = { name: "test-policy", description: "Test policy.", validateResource: () => undefined };
+
+        it("prefers the policy's config, then 'all', then the policy's default", () => {
+            assert.strictEqual(getEnforcementLevel(policy), "advisory");
+            assert.strictEqual(getEnforcementLevel({ ...policy, enforcementLevel: "mandatory" }), "mandatory");
+            assert.strictEqual(getEnforcementLevel({ ...policy, enforcementLevel: "mandatory" }, { all: "disabled" }), "disabled");
+            assert.strictEqual(getEnforcementLevel(policy, { all: "disabled", "test-policy": "mandatory" }), "mandatory");
+            assert.strictEqual(
+                getEnforcementLevel(policy, { all: "disabled", "test-policy": { enforcementLevel: "mandatory" } }), "mandatory");
+            assert.strictEqual(getEnforcementLevel(policy, { all: "mandatory", "test-policy": { foo: "bar" } }), "mandatory");
+        });
+    });
+
+    describe("getInitialConfig", () => {
+        const validateResource = () => undefined;
+        const policyMap = {
+            ec2VolumeInUse: { name: "ec2-volume-inuse", description: "Low severity.", validateResource },
+            encryptedVolumes: { name: "encrypted-volumes", description: "High severity.", validateResource },
+            s3BucketLoggingEnabled: { name: "s3-bucket-logging-enabled", description: "Medium severity.", validateResource },
+        };
+
+        it("applies the enforcement level for each policy's severity", () => {
+            const config = getInitialConfig(policyMap, {
+                all: "disabled",
+                severityEnforcement: { high: "mandatory", low: "advisory" },
+            });
+            assert.deepStrictEqual(config, {
+                all: "disabled",
+                "ec2-volume-inuse": "advisory",
+                "encrypted-volumes": "mandatory",
+            });
+        });
+
+        it("prefers a policy's own enforcement level over its severity's", () => {
+            const config = getInitialConfig(policyMap, {
+                severityEnforcement: { high: "mandatory", low: "disabled" },
+                ec2VolumeInUse: "mandatory",
+                encryptedVolumes: { enforcementLevel: "advisory" },
+            });
+            assert.deepStrictEqual(config, {
+                "ec2-volume-inuse": "mandatory",
+                "encrypted-volumes": { enforcementLevel: "advisory" },
+            });
+        });
+
+        it("keeps a policy's settings when applying its severity's enforcement level", () => {
+            const config = getInitialConfig(policyMap, {
+                severityEnforcement: { low: "mandatory" },
+                ec2VolumeInUse: { checkDeletion: false },
+            });
+            assert.deepStrictEqual(config, {
+                "ec2-volume-inuse": { checkDeletion: false, enforcementLevel: "mandatory" },
+            });
+        });
+
+        it("promotes advisory policies to mandatory in strict mode", () => {
+            const config = getInitialConfig(policyMap, {
+                strict: true,
+                ec2VolumeInUse: { enforcementLevel: "advisory", checkDeletion: false },
+                encryptedVolumes: "disabled",
+            });
+            assert.deepStrictEqual(config, {
+                "ec2-volume-inuse": { enforcementLevel: "mandatory", checkDeletion: false },
+                "encrypted-volumes": "disabled",
+                "s3-bucket-logging-enabled": "mandatory",
+            });
+        });
+
+        it("demotes mandatory policies to advisory in relaxed mode", () => {
+            const config = getInitialConfig(policyMap, {
+                all: "mandatory",
+                relaxed: true,
+                ec2VolumeInUse: "mandatory",
+                encryptedVolumes: "disabled",
+                s3BucketLoggingEnabled: "advisory",
+            });
+            assert.deepStrictEqual(config, {
+                all: "mandatory",
+                "ec2-volume-inuse": "advisory",
+                "encrypted-volumes": "disabled",
+                "s3-bucket-logging-enabled": "advisory",
+            });
+        });
+
+        it("rejects config that doesn't match the policy's schema", () => {
+            const ec2VolumeInUse: ResourceValidationPolicy = {
+                ...policyMap.ec2VolumeInUse,
+                configSchema: { properties: { checkDeletion: { type: "boolean" } } },
+            };
+            const withSchema = { ...policyMap, ec2VolumeInUse };
+            assert.throws(() => getInitialConfig(withSchema, { ec2VolumeInUse: <any>{ checkDeletion: "false" } }),
+                /Invalid config for policy 'ec2-volume-inuse': 'checkDeletion' must be a boolean/);
+            assert.throws(() => getInitialConfig(withSchema, { ec2VolumeInUse: <any>{ checkDeletions: false } }),
+                /Invalid config for policy 'ec2-volume-inuse': 'checkDeletions' is not a known property/);
+        });
+
+        it("rejects invalid regular expressions in name patterns", () => {
+            const withNoKeyPair = { ...policyMap, ec2NoKeyPair: compute.ec2NoKeyPair };
+            const config = (allowedInstanceNames: string[]) => ({ ec2NoKeyPair: { allowedInstanceNames } });
+            assert.throws(() => getInitialConfig(withNoKeyPair, config(["bastion", "/[a-/"])),
+                /Invalid config for policy 'ec2-no-key-pair': 'allowedInstanceNames\[1\]' must be a valid pattern: '\/\[a-\/'/);
+            getInitialConfig(withNoKeyPair, config(["bastion-*", "/^build-\\d+$/"]));
+        });
+
+        it("rejects strict and relaxed mode together", () => {
+            assert.throws(() => getInitialConfig(policyMap, { strict: true, relaxed: true }),
+                /'strict' and 'relaxed' can't both be set/);
+        });
+    });
+
+    describe("getVersionSummary", () => {
+        const policies = ["a", "b", "c"].map(name => ({ name, description: "Test policy.", validateResource: () => undefined }));
+
+        it("summarizes the version and enforcement levels", () => {
+            assert.strictEqual(getVersionSummary("1.2.3", policies),
+                "awsguard v1.2.3: 0 mandatory, 3 advisory, 0 disabled");
+            assert.strictEqual(getVersionSummary("1.2.3", policies, { all: "mandatory", b: "advisory", c: "disabled" }),
+                "awsguard v1.2.3: 1 mandatory, 1 advisory, 1 disabled (all: mandatory)");
+        });
+    });
 });
@@ -15,12 +15,21 @@
 import "mocha";
 
 import * as aws from "@pulumi/aws";
-import { ResourceValidationArgs } from "@pulumi/policy";
+import { PolicyResource, ResourceValidationArgs } from "@pulumi/policy";
 
 
 import * as compute from "../compute";
 
-import { assertHasResourceViolation, assertNoResourceViolations, createResourceValidationArgs } from "./util";
+import {
+    assertHasResourceViolation, assertHasStackViolation,
+    assertNoResourceViolations, assertNoStackViolations,
+    createPolicyResource, createResourceValidationArgs, createStackValidationArgsWithResources,
+} from "./util";
+
+import * as AWS from "aws-sdk";
+import * as AWSMock from "aws-sdk-mock";
+
+import { DescribeImagesRequest } from "aws-sdk/clients/ec2";
 
 
 describe("#ec2BlockDeviceEncryption", () => {
@@ -90,3 +99,825 @@ describe("#ec2BlockDeviceEncryption", () => {
         await assertHasResourceViolation(policy, args, { message: msg });
     });
 });
+
+describe("#ec2ApprovedAmiOwner", () => {
+    const policy = compute.ec2ApprovedAmiOwner;
+
+    before(() => {
+        AWSMock.setSDKInstance(AWS);
+        AWSMock.mock("EC2", "describeImages", (params: DescribeImagesRequest, callback: Function) => {
+            if ((params.ImageIds || []).includes("ami-missing")) {
+                callback({ code: "InvalidAMIID.NotFound", message: "The image id '[ami-missing]' does not exist" });
+                return;
+            }
+            const resp: AWS.EC2.DescribeImagesResult = {
+                Images: [
+                    { ImageId: "ami-amazon", OwnerId: "137112412989", ImageOwnerAlias: "amazon", Name: "amzn2-ami-hvm-2.0" },
+                    { ImageId: "ami-community", OwnerId: "999999999999", Name: "community-image" },
+                ].filter(image => (params.ImageIds || []).includes(image.ImageId)),
+            };
+            callback(null, resp);
+        });
+    });
+
+    after(() => {
+        AWSMock.restore("EC2", "describeImages");
+    });
+
+    function getArgs(ami: string, config: compute.Ec2ApprovedAmiOwnerArgs) {
+        return createStackValidationArgsWithResources([
+            createPolicyResource(aws.ec2.Instance, { ami, instanceType: "t2.micro" }, "test-instance"),
+            createPolicyResource(aws.ec2.LaunchTemplate, { imageId: ami }, "test-launch-template"),
+        ], config);
+    }
+
+    it("Should pass if the AMI is explicitly approved", async () => {
+        await assertNoStackViolations(policy, getArgs("ami-community", { approvedAmiIds: ["ami-community"] }));
+    });
+
+    it("Should pass if the AMI's owner is approved", async () => {
+        await assertNoStackViolations(policy, getArgs("ami-amazon", { approvedOwners: ["amazon"] }));
+        await assertNoStackViolations(policy, getArgs("ami-amazon", { approvedOwners: ["137112412989"] }));
+    });
+
+    it("Should pass if the AMI's name matches an approved pattern", async () => {
+        await assertNoStackViolations(policy, getArgs("ami-amazon", { approvedNamePatterns: ["amzn2-ami-hvm-*"] }));
+    });
+
+    it("Should fail if the AMI is not from an approved source", async () => {
+        const args = getArgs("ami-community", { approvedOwners: ["amazon"], approvedNamePatterns: ["amzn2-ami-hvm-*"] });
+        await assertHasStackViolation(policy, args, {
+            message: "EC2 instance 'test-instance' references AMI 'ami-community', which is not from an approved source.",
+        });
+        await assertHasStackViolation(policy, args, {
+            message: "EC2 launch template 'test-launch-template' references AMI 'ami-community'",
+        });
+    });
+
+    it("Should fail if the AMI cannot be found", async () => {
+        const args = getArgs("ami-missing", { approvedOwners: ["amazon"] });
+        await assertHasStackViolation(policy, args, {
+            message: "references AMI 'ami-missing', which could not be found",
+        });
+    });
+
+    it("Should still check the other AMIs if one cannot be found", async () => {
+        const args = createStackValidationArgsWithResources([
+            createPolicyResource(aws.ec2.Instance, { ami: "ami-community", instanceType: "t2.micro" }, "community"),
+            createPolicyResource(aws.ec2.Instance, { ami: "ami-missing", instanceType: "t2.micro" }, "missing"),
+        ], { approvedOwners: ["amazon"] });
+        await assertHasStackViolation(policy, args, {
+            message: "EC2 instance 'missing' references AMI 'ami-missing', which could not be found",
+        });
+        await assertHasStackViolation(policy, args, {
+            message: "EC2 instance 'community' references AMI 'ami-community', which is not from an approved source.",
+        });
+    });
+
+    it("Should pass if nothing has been approved", async () => {
+        await assertNoStackViolations(policy, getArgs("ami-community", {}));
+    });
+});
+
+describe("#ec2InstanceProfileLeastPrivilege", () => {
+    const policy = compute.ec2InstanceProfileLeastPrivilege;
+    const adminArn = "arn:aws:iam::aws:policy/AdministratorAccess";
+
+    function getResources(policyArn: string) {
+        const role = createPolicyResource(aws.iam.Role, { name: "test-role" }, "test-role");
+        const profile = createPolicyResource(aws.iam.InstanceProfile, {}, "test-profile", { role: [role] });
+        const instance = createPolicyResource(aws.ec2.Instance, {
+            ami: "ami-12345678",
+            instanceType: "t2.micro",
+        }, "test-instance", { iamInstanceProfile: [profile] });
+        const attachment = createPolicyResource(aws.iam.RolePolicyAttachment, { policyArn }, "test-attachment", {
+            role: [role],
+        });
+        return [role, profile, instance, attachment];
+    }
+
+    it("Should pass if the instance's role does not have AdministratorAccess", async () => {
+        const args = createStackValidationArgsWithResources(
+            getResources("arn:aws:iam::aws:policy/AmazonS3ReadOnlyAccess"));
+        await assertNoStackViolations(policy, args);
+    });
+
+    it("Should fail if the instance's role has AdministratorAccess attached", async () => {
+        const args = createStackValidationArgsWithResources(getResources(adminArn));
+        await assertHasStackViolation(policy, args, {
+            message: "EC2 instance 'test-instance' uses an instance profile with role 'test-role', " +
+                "which has the AdministratorAccess policy attached.",
+        });
+    });
+
+    it("Should fail if the role has AdministratorAccess as a managed policy", async () => {
+        const args = createStackValidationArgsWithResources([
+            createPolicyResource(aws.iam.Role, { name: "admin", managedPolicyArns: [adminArn] }, "test-role"),
+            createPolicyResource(aws.iam.InstanceProfile, { name: "test-profile", role: "admin" }, "test-profile"),
+            createPolicyResource(aws.ec2.Instance, { iamInstanceProfile: "test-profile" }, "test-instance"),
+        ]);
+        await assertHasStackViolation(policy, args, { message: "with role 'test-role'" });
+    });
+
+    it("Should fail if a policy attachment grants the role AdministratorAccess", async () => {
+        const [role, profile, instance] = getResources("arn:aws:iam::aws:policy/AmazonS3ReadOnlyAccess");
+        const args = createStackValidationArgsWithResources([role, profile, instance,
+            createPolicyResource(aws.iam.PolicyAttachment, { policyArn: adminArn, roles: ["test-role"] }, "test-pa"),
+        ]);
+        await assertHasStackViolation(policy, args, { message: "has the AdministratorAccess policy attached." });
+    });
+});
+
+describe("#ebsVolumeTypeAllowlist", () => {
+    const policy = compute.ebsVolumeTypeAllowlist;
+    const config = { allowedTypes: ["gp3", "io2"] };
+
+    it("Should pass if the volume has an allowed type", async () => {
+        const args = createResourceValidationArgs(aws.ebs.Volume, { availabilityZone: "us-west-2a", type: "gp3" }, config);
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should fail if the volume has a legacy type", async () => {
+        const args = createResourceValidationArgs(aws.ebs.Volume, { availabilityZone: "us-west-2a", type: "standard" }, config);
+        await assertHasResourceViolation(policy, args, {
+            message: "EBS volume 'unknown' has type 'standard', which is not an allowed volume type.",
+        });
+    });
+
+    it("Should fail if the volume type is unspecified", async () => {
+        const args = createResourceValidationArgs(aws.ebs.Volume, { availabilityZone: "us-west-2a" }, config);
+        await assertHasResourceViolation(policy, args, { message: "has type 'gp2'" });
+    });
+
+    it("Should check the instance's block devices", async () => {
+        const args = createResourceValidationArgs(aws.ec2.Instance, {
+            ami: "ami-12345678",
+            instanceType: "t2.micro",
+            rootBlockDevice: { volumeType: "gp3" },
+            ebsBlockDevices: [{ deviceName: "/dev/sdf", volumeType: "io2" }],
+        }, config);
+        await assertNoResourceViolations(policy, args);
+
+        args.props.ebsBlockDevices[0].volumeType = "gp2";
+        await assertHasResourceViolation(policy, args, {
+            message: "EC2 instance 'unknown' block device '/dev/sdf' has type 'gp2', which is not an allowed volume type.",
+        });
+
+        args.props.rootBlockDevice = undefined;
+        args.props.ebsBlockDevices = [];
+        await assertNoResourceViolations(policy, args);
+    });
+});
+
+describe("#elbAccessLoggingEnabled", () => {
+    const policy = compute.elbAccessLoggingEnabled;
+
+    it("Should check the access logs of Classic Load Balancers", async () => {
+        const args = createResourceValidationArgs(aws.elasticloadbalancing.LoadBalancer, {
+            listeners: [],
+            accessLogs: { bucket: "test-bucket", enabled: true },
+        });
+        await assertNoResourceViolations(policy, args);
+
+        args.props.accessLogs = undefined;
+        await assertHasResourceViolation(policy, args, { message: "Elastic Load Balancer must have access logs enabled." });
+    });
+
+    it("Should check the access logs of Application Load Balancers", async () => {
+        const args = createResourceValidationArgs(aws.lb.LoadBalancer, {
+            accessLogs: { bucket: "test-bucket", enabled: true },
+        });
+        await assertNoResourceViolations(policy, args);
+
+        args.props.accessLogs.enabled = false;
+        await assertHasResourceViolation(policy, args, {
+            message: "Load balancer 'unknown' of type 'application' must have access logs enabled.",
+        });
+    });
+
+    it("Should check the access logs of Network Load Balancers", async () => {
+        const args = createResourceValidationArgs(aws.lb.LoadBalancer, {
+            loadBalancerType: "network",
+            accessLogs: { bucket: "test-bucket", enabled: true },
+        });
+        await assertNoResourceViolations(policy, args);
+
+        args.props.accessLogs = undefined;
+        await assertHasResourceViolation(policy, args, {
+            message: "Load balancer 'unknown' of type 'network' must have access logs enabled.",
+        });
+    });
+
+    it("Should skip Gateway Load Balancers, which don't support access logs", async () => {
+        const args = createResourceValidationArgs(aws.lb.LoadBalancer, { loadBalancerType: "gateway" });
+        await assertNoResourceViolations(policy, args);
+    });
+});
+
+describe("#workspacesVolumeEncryption", () => {
+    const policy = compute.workspacesVolumeEncryption;
+
+    function getHappyPathArgs(): ResourceValidationArgs {
+        return createResourceValidationArgs(aws.workspaces.Workspace, {
+            bundleId: "wsb-bh8rsxt14",
+            directoryId: "d-1234567890",
+            userName: "test-user",
+            rootVolumeEncryptionEnabled: true,
+            userVolumeEncryptionEnabled: true,
+            volumeEncryptionKey: "alias/aws/workspaces",
+        });
+    }
+
+    it("Should pass if both volumes are encrypted", async () => {
+        const args = getHappyPathArgs();
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should fail if the root volume is not encrypted", async () => {
+        const args = getHappyPathArgs();
+        args.props.rootVolumeEncryptionEnabled = false;
+
+        await assertHasResourceViolation(policy, args, { message: "WorkSpace 'unknown' must have its root volume encrypted." });
+    });
+
+    it("Should fail if the user volume encryption is unspecified", async () => {
+        const args = getHappyPathArgs();
+        args.props.userVolumeEncryptionEnabled = undefined;
+
+        await assertHasResourceViolation(policy, args, { message: "WorkSpace 'unknown' must have its user volume encrypted." });
+    });
+});
+
+describe("#batchNoPublicIp", () => {
+    const policy = compute.batchNoPublicIp;
+
+    function getComputeEnvironment(subnet: PolicyResource, launchTemplate?: PolicyResource) {
+        return createPolicyResource(aws.batch.ComputeEnvironment, {
+            type: "MANAGED",
+            computeResources: {
+                type: "EC2",
+                maxVcpus: 16,
+                instanceTypes: ["c4.large"],
+                instanceRole: "arn:aws:iam::123456789012:instance-profile/batch",
+                securityGroupIds: ["sg-1234"],
+                subnets: ["subnet-1234"],
+                launchTemplate: launchTemplate ? { launchTemplateName: "batch-template" } : undefined,
+            },
+        }, "test-compute-environment", { computeResources: launchTemplate ? [subnet, launchTemplate] : [subnet] });
+    }
+
+    it("Should pass if instances launch into private subnets", async () => {
+        const subnet = createPolicyResource(aws.ec2.Subnet, {
+            vpcId: "vpc-1234", cidrBlock: "10.0.1.0/24", mapPublicIpOnLaunch: false,
+        }, "test-private-subnet");
+        const args = createStackValidationArgsWithResources([subnet, getComputeEnvironment(subnet)]);
+        await assertNoStackViolations(policy, args);
+    });
+
+    it("Should fail if instances launch into a public subnet", async () => {
+        const subnet = createPolicyResource(aws.ec2.Subnet, {
+            vpcId: "vpc-1234", cidrBlock: "10.0.0.0/24", mapPublicIpOnLaunch: true,
+        }, "test-public-subnet");
+        const privateSubnet = createPolicyResource(aws.ec2.Subnet, {
+            vpcId: "vpc-1234", cidrBlock: "10.0.1.0/24",
+        }, "test-private-subnet");
+        const args = createStackValidationArgsWithResources([subnet, privateSubnet, getComputeEnvironment(subnet)]);
+        await assertHasStackViolation(policy, args, {
+            message: "Batch compute environment 'test-compute-environment' must not launch instances into public subnet " +
+                "'test-public-subnet'.",
+        });
+    });
+
+    it("Should fail if the launch template assigns public IPs", async () => {
+        const subnet = createPolicyResource(aws.ec2.Subnet, {
+            vpcId: "vpc-1234", cidrBlock: "10.0.1.0/24",
+        }, "test-private-subnet");
+        const template = createPolicyResource(aws.ec2.LaunchTemplate, {
+            name: "batch-template",
+            networkInterfaces: [{ associatePublicIpAddress: "true" }],
+        }, "test-launch-template");
+        const args = createStackValidationArgsWithResources([subnet, template, getComputeEnvironment(subnet, template)]);
+        await assertHasStackViolation(policy, args, {
+            message: "must not assign public IP addresses to instances, but launch template 'test-launch-template' does.",
+        });
+    });
+});
+
+describe("#ec2RequiredTagsOnLaunchTemplate", () => {
+    const policy = compute.ec2RequiredTagsOnLaunchTemplate;
+    const config = { requiredTags: ["team", "cost-center"], resourceTypes: ["instance", "volume"] };
+    const tags = { "team": "platform", "cost-center": "1234" };
+
+    it("Should pass if tags are propagated to instances and volumes", async () => {
+        const args = createResourceValidationArgs(aws.ec2.LaunchTemplate, {
+            tagSpecifications: [
+                { resourceType: "instance", tags },
+                { resourceType: "volume", tags },
+            ],
+        }, config);
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should fail if tags are only set on the template", async () => {
+        const args = createResourceValidationArgs(aws.ec2.LaunchTemplate, { tags }, config);
+        await assertHasResourceViolation(policy, args, {
+            message: "EC2 launch template 'unknown' must have tag specifications with the required tags " +
+                "for resource type(s): instance, volume.",
+        });
+    });
+
+    it("Should fail if a resource type lacks a required tag", async () => {
+        const args = createResourceValidationArgs(aws.ec2.LaunchTemplate, {
+            tagSpecifications: [
+                { resourceType: "instance", tags },
+                { resourceType: "volume", tags: { team: "platform" } },
+            ],
+        }, config);
+        await assertHasResourceViolation(policy, args, { message: "for resource type(s): volume." });
+    });
+});
+
+describe("#ec2InstanceDetailedMonitoringEnabled", () => {
+    const policy = compute.ec2InstanceDetailedMonitoringEnabled;
+    const msg = "EC2 instances must have detailed monitoring enabled.";
+
+    function getArgs(config?: compute.Ec2InstanceDetailedMonitoringEnabledArgs): ResourceValidationArgs {
+        return createResourceValidationArgs(aws.ec2.Instance, {
+            ami: "ami-12345678",
+            instanceType: "t2.micro",
+            monitoring: false,
+            tags: { environment: "dev" },
+        }, config);
+    }
+
+    it("Should check all instances by default", async () => {
+        const args = getArgs();
+        await assertHasResourceViolation(policy, args, { message: msg });
+
+        args.props.monitoring = true;
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should only check instances with the scope tag", async () => {
+        await assertNoResourceViolations(policy, getArgs({ scopeTagKey: "environment", scopeTagValue: "production" }));
+        await assertHasResourceViolation(policy, getArgs({ scopeTagKey: "environment", scopeTagValue: "dev" }), { message: msg });
+        await assertHasResourceViolation(policy, getArgs({ scopeTagKey: "environment" }), { message: msg });
+    });
+
+    it("Should respect the instance name allow and deny lists", async () => {
+        await assertNoResourceViolations(policy, getArgs({ includeInstanceNames: ["prod-instance"] }));
+        await assertHasResourceViolation(policy, getArgs({ includeInstanceNames: ["unknown"] }), { message: msg });
+        await assertNoResourceViolations(policy, getArgs({ excludeInstanceNames: ["unknown"] }));
+    });
+});
+
+describe("#lambdaReservedConcurrency", () => {
+    const policy = compute.lambdaReservedConcurrency;
+    const msg = "Lambda function 'unknown' should have reserved concurrency set.";
+
+    function getArgs(reservedConcurrentExecutions?: number, config?: compute.LambdaReservedConcurrencyArgs) {
+        return createResourceValidationArgs(aws.lambda.Function, {
+            role: "arn:aws:iam::123456789012:role/lambda",
+            runtime: "nodejs14.x",
+            handler: "index.handler",
+            reservedConcurrentExecutions,
+        }, config);
+    }
+
+    it("Should pass if reserved concurrency is set", async () => {
+        await assertNoResourceViolations(policy, getArgs(10));
+        await assertNoResourceViolations(policy, getArgs(0));
+    });
+
+    it("Should fail if reserved concurrency is unset", async () => {
+        await assertHasResourceViolation(policy, getArgs(undefined), { message: msg });
+        await assertHasResourceViolation(policy, getArgs(-1), { message: msg });
+    });
+
+    it("Should respect the function name allow and deny lists", async () => {
+        await assertNoResourceViolations(policy, getArgs(undefined, { includeFunctionNames: ["critical-function"] }));
+        await assertHasResourceViolation(policy, getArgs(undefined, { includeFunctionNames: ["unknown"] }), { message: msg });
+        await assertNoResourceViolations(policy, getArgs(undefined, { excludeFunctionNames: ["unknown"] }));
+    });
+});
+
+describe("#ec2SourceDestCheck", () => {
+    const policy = compute.ec2SourceDestCheck;
+
+    it("Should pass if source/destination checking is enabled", async () => {
+        const args = createResourceValidationArgs(aws.ec2.Instance, { ami: "ami-1234", instanceType: "t3.micro" });
+        await assertNoResourceViolations(policy, args);
+
+        args.props.sourceDestCheck = true;
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should fail if an instance disables source/destination checking", async () => {
+        const args = createResourceValidationArgs(aws.ec2.Instance, {
+            ami: "ami-1234",
+            instanceType: "t3.micro",
+            sourceDestCheck: false,
+        });
+        await assertHasResourceViolation(policy, args, {
+            message: "EC2 instance 'unknown' should not disable source/destination checking unless it routes traffic.",
+        });
+    });
+
+    it("Should fail if a network interface disables source/destination checking", async () => {
+        const args = createResourceValidationArgs(aws.ec2.NetworkInterface, {
+            subnetId: "subnet-1234",
+            sourceDestCheck: false,
+        });
+        await assertHasResourceViolation(policy, args, {
+            message: "Network interface 'unknown' should not disable source/destination checking",
+        });
+    });
+
+    it("Should pass if the instance is allowed to disable source/destination checking", async () => {
+        const args = createResourceValidationArgs(aws.ec2.Instance, {
+            ami: "ami-1234",
+            instanceType: "t3.micro",
+            sourceDestCheck: false,
+        }, { allowedNames: ["nat-*"] });
+        args.name = "nat-us-west-2a";
+        await assertNoResourceViolations(policy, args);
+    });
+});
+
+describe("#ec2Imdsv2Required", () => {
+    const policy = compute.ec2Imdsv2Required;
+
+    it("Should fail if an instance doesn't require IMDSv2", async () => {
+        const args = createResourceValidationArgs(aws.ec2.Instance, { ami: "ami-1234", instanceType: "t3.micro" });
+        await assertHasResourceViolation(policy, args, {
+            message: "EC2 instance 'unknown' must require IMDSv2 by setting metadataOptions.httpTokens to \"required\".",
+        });
+
+        args.props.metadataOptions = { httpTokens: "required" };
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should fail if a launch template makes IMDSv2 optional", async () => {
+        const args = createResourceValidationArgs(aws.ec2.LaunchTemplate, {
+            metadataOptions: { httpEndpoint: "enabled", httpTokens: "optional" },
+        });
+        args.name = "test-launch-template";
+        await assertHasResourceViolation(policy, args, {
+            message: "Launch template 'test-launch-template' must require IMDSv2",
+        });
+
+        args.props.metadataOptions.httpTokens = "required";
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should fail if a launch configuration doesn't require IMDSv2", async () => {
+        const args = createResourceValidationArgs(aws.ec2.LaunchConfiguration, { imageId: "ami-1234", instanceType: "t3.micro" });
+        await assertHasResourceViolation(policy, args, { message: "Launch configuration 'unknown' must require IMDSv2" });
+    });
+
+    it("Should pass if the metadata service is disabled", async () => {
+        const args = createResourceValidationArgs(aws.ec2.LaunchTemplate, { metadataOptions: { httpEndpoint: "disabled" } });
+        await assertNoResourceViolations(policy, args);
+    });
+});
+
+describe("#eksNodegroupPrivateSubnets", () => {
+    const policy = compute.eksNodegroupPrivateSubnets;
+    const nodeGroupProps = {
+        clusterName: "test-cluster",
+        nodeRoleArn: "arn:aws:iam::123456789012:role/eks-nodes",
+        scalingConfig: { desiredSize: 1, maxSize: 1, minSize: 1 },
+    };
+
+    it("Should fail if a node group runs in a public subnet", async () => {
+        const subnet = createPolicyResource(aws.ec2.Subnet, {
+            vpcId: "vpc-1234",
+            cidrBlock: "10.0.0.0/24",
+            mapPublicIpOnLaunch: true,
+        }, "public-subnet");
+        const nodeGroup = createPolicyResource(aws.eks.NodeGroup, nodeGroupProps, "test-node-group", { subnetIds: [subnet] });
+
+        await assertHasStackViolation(policy, createStackValidationArgsWithResources([subnet, nodeGroup]), {
+            message: "EKS node group 'test-node-group' should not run in public subnet 'public-subnet'.",
+        });
+    });
+
+    it("Should pass if a node group runs in private subnets", async () => {
+        const publicSubnet = createPolicyResource(aws.ec2.Subnet, {
+            vpcId: "vpc-1234",
+            cidrBlock: "10.0.0.0/24",
+            mapPublicIpOnLaunch: true,
+        }, "public-subnet");
+        const privateSubnet = createPolicyResource(aws.ec2.Subnet, { vpcId: "vpc-1234", cidrBlock: "10.0.1.0/24" }, "private-subnet");
+        const nodeGroup = createPolicyResource(aws.eks.NodeGroup, nodeGroupProps, "test-node-group", { subnetIds: [privateSubnet] });
+
+        await assertNoStackViolations(policy, createStackValidationArgsWithResources([publicSubnet, privateSubnet, nodeGroup]));
+    });
+});
+
+describe("#ec2NoKeyPair", () => {
+    const policy = compute.ec2NoKeyPair;
+
+    it("Should report instances launched with a key pair", async () => {
+        const args = createResourceValidationArgs(aws.ec2.Instance, { ami: "ami-1234", instanceType: "t3.micro" });
+        await assertNoResourceViolations(policy, args);
+
+        args.props.keyName = "deployer";
+        await assertHasResourceViolation(policy, args, {
+            message: "EC2 instance 'unknown' should use Session Manager for access rather than the key pair 'deployer'.",
+        });
+    });
+
+    it("Should pass if the instance may use a key pair", async () => {
+        const args = createResourceValidationArgs(aws.ec2.Instance, {
+            ami: "ami-1234",
+            instanceType: "t3.micro",
+            keyName: "break-glass",
+        }, { allowedInstanceNames: ["bastion"] });
+        args.name = "bastion";
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should pass if the instance matches an allowed name pattern", async () => {
+        const args = createResourceValidationArgs(aws.ec2.Instance, {
+            ami: "ami-1234",
+            instanceType: "t3.micro",
+            keyName: "break-glass",
+        }, { allowedInstanceNames: ["jump-*", "/^break-glass-\\d+$/"] });
+        args.name = "jump-eu";
+        await assertNoResourceViolations(policy, args);
+
+        args.name = "break-glass-2";
+        await assertNoResourceViolations(policy, args);
+
+        args.name = "break-glass-admin";
+        await assertHasResourceViolation(policy, args, {
+            message: "EC2 instance 'break-glass-admin' should use Session Manager for access rather than the key pair",
+        });
+    });
+});
+
+describe("#ec2PreferLaunchTemplate", () => {
+    const policy = compute.ec2PreferLaunchTemplate;
+
+    function getArgs(config?: any) {
+        return createResourceValidationArgs(aws.ec2.Instance, {
+            ami: "ami-1234",
+            instanceType: "t3.micro",
+            keyName: "deployer",
+            iamInstanceProfile: "app-profile",
+            vpcSecurityGroupIds: ["sg-1234"],
+            userData: "#!/bin/bash",
+            monitoring: true,
+            ebsOptimized: true,
+            ebsBlockDevices: [],
+        }, config);
+    }
+
+    it("Should report instances with many inline settings", async () => {
+        await assertHasResourceViolation(policy, getArgs(), {
+            message: "EC2 instance 'unknown' sets 6 launch settings inline (keyName, iamInstanceProfile, " +
+                "vpcSecurityGroupIds, userData, monitoring, ebsOptimized) and should use an " +
+                "aws.ec2.LaunchTemplate instead.",
+        });
+    });
+
+    it("Should not count the AMI and instance type, which every instance sets", async () => {
+        const args = getArgs();
+        args.props.monitoring = undefined;
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should pass if the instance uses a launch template or has few inline settings", async () => {
+        const args = getArgs();
+        args.props.launchTemplate = { id: "lt-1234" };
+        await assertNoResourceViolations(policy, args);
+
+        await assertNoResourceViolations(policy, getArgs({ maxInlineSettings: 6 }));
+    });
+
+    it("Should pass if the instance may be configured inline", async () => {
+        const args = getArgs({ allowedInstanceNames: ["bastion"] });
+        args.name = "bastion";
+        await assertNoResourceViolations(policy, args);
+    });
+});
+
+describe("#ebsGp2Deprecated", () => {
+    const policy = compute.ebsGp2Deprecated;
+    const suffix = "See https://aws.amazon.com/blogs/storage/" +
+        "migrate-your-amazon-ebs-volumes-from-gp2-to-gp3-and-save-up-to-20-on-costs/ for how to migrate.";
+
+    it("Should report gp2 volumes", async () => {
+        const args = createResourceValidationArgs(aws.ebs.Volume, { availabilityZone: "us-west-2a", type: "gp2" });
+        await assertHasResourceViolation(policy, args, {
+            message: `EBS volume 'unknown' uses gp2 and should use gp3 instead. ${suffix}`,
+        });
+
+        args.props.type = "gp3";
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should report gp2 instance block devices", async () => {
+        const args = createResourceValidationArgs(aws.ec2.Instance, {
+            ami: "ami-1234",
+            instanceType: "t3.micro",
+            rootBlockDevice: { volumeType: "gp3" },
+            ebsBlockDevices: [{ deviceName: "/dev/sdf" }],
+        });
+        await assertHasResourceViolation(policy, args, {
+            message: `EC2 instance 'unknown' block device '/dev/sdf' uses gp2 and should use gp3 instead. ${suffix}`,
+        });
+    });
+
+    it("Should use the configured message suffix", async () => {
+        const args = createResourceValidationArgs(aws.ebs.Volume, { availabilityZone: "us-west-2a", type: "gp2" }, {
+            messageSuffix: "See https://wiki.example.com/ebs-gp3.",
+        });
+        await assertHasResourceViolation(policy, args, {
+            message: "EBS volume 'unknown' uses gp2 and should use gp3 instead. See https://wiki.example.com/ebs-gp3.",
+        });
+    });
+});
+
+describe("#ecrRepositoryNoPublicAccess", () => {
+    const policy = compute.ecrRepositoryNoPublicAccess;
+
+    function policyDocument(...statements: any[]): string {
+        return JSON.stringify({ Version: "2012-10-17", Statement: statements });
+    }
+
+    it("Should fail if anyone can pull images", async () => {
+        const args = createResourceValidationArgs(aws.ecr.RepositoryPolicy, {
+            repository: "app",
+            policy: policyDocument({ Sid: "PublicPull", Effect: "Allow", Principal: "*", Action: ["ecr:BatchGetImage"] }),
+        });
+        await assertHasResourceViolation(policy, args, {
+            message: "ECR repository 'app' must not allow public access: statement 'PublicPull' allows any principal " +
+                "to pull or push images without a condition restricting the account or organization.",
+        });
+    });
+
+    it("Should fail if a condition doesn't restrict the account or organization", async () => {
+        const args = createResourceValidationArgs(aws.ecr.RepositoryPolicy, {
+            repository: "app",
+            policy: policyDocument({
+                Effect: "Allow",
+                Principal: { AWS: "*" },
+                Action: "ecr:*",
+                Condition: { IpAddress: { "aws:SourceIp": "203.0.113.0/24" } },
+            }),
+        });
+        await assertHasResourceViolation(policy, args, {
+            message: "ECR repository 'app' must not allow public access: statement 1 allows any principal",
+        });
+    });
+
+    it("Should pass if access is restricted to an organization or specific accounts", async () => {
+        const args = createResourceValidationArgs(aws.ecr.RepositoryPolicy, {
+            repository: "app",
+            policy: policyDocument(
+                {
+                    Effect: "Allow",
+                    Principal: "*",
+                    Action: ["ecr:BatchGetImage", "ecr:GetDownloadUrlForLayer"],
+                    Condition: { StringEquals: { "aws:PrincipalOrgID": "o-1234567890" } },
+                },
+                {
+                    Effect: "Allow",
+                    Principal: { AWS: "arn:aws:iam::123456789012:root" },
+                    Action: "ecr:*",
+                },
+            ),
+        });
+        await assertNoResourceViolations(policy, args);
+    });
+});
+
+describe("#ecsClusterContainerInsights", () => {
+    const policy = compute.ecsClusterContainerInsights;
+
+    it("Should report clusters without Container Insights", async () => {
+        const args = createResourceValidationArgs(aws.ecs.Cluster, {});
+        await assertHasResourceViolation(policy, args, {
+            message: "ECS cluster 'unknown' should have Container Insights enabled.",
+        });
+
+        args.props.settings = [{ name: "containerInsights", value: "disabled" }];
+        await assertHasResourceViolation(policy, args, {
+            message: "ECS cluster 'unknown' should have Container Insights enabled.",
+        });
+    });
+
+    it("Should pass if Container Insights is enabled", async () => {
+        const args = createResourceValidationArgs(aws.ecs.Cluster, { settings: [{ name: "containerInsights", value: "enabled" }] });
+        await assertNoResourceViolations(policy, args);
+
+        args.props.settings = [{ name: "containerInsights", value: "enhanced" }];
+        await assertNoResourceViolations(policy, args);
+    });
+});
+
+describe("#ec2InstanceProfileRequired", () => {
+    const policy = compute.ec2InstanceProfileRequired;
+
+    it("Should report instances without an instance profile", async () => {
+        const args = createResourceValidationArgs(aws.ec2.Instance, { ami: "ami-1234", instanceType: "t3.micro" });
+        await assertHasResourceViolation(policy, args, {
+            message: "EC2 instance 'unknown' should have an IAM instance profile, so that it uses a role " +
+                "rather than static credentials.",
+        });
+
+        args.props.iamInstanceProfile = "app-profile";
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should pass if the instance may run without an instance profile", async () => {
+        const args = createResourceValidationArgs(aws.ec2.Instance, { ami: "ami-1234", instanceType: "t3.micro" },
+            { allowedInstanceNames: ["bastion"] });
+        args.name = "bastion";
+        await assertNoResourceViolations(policy, args);
+    });
+});
+
+describe("#ec2DeprecatedInstanceGeneration", () => {
+    const policy = compute.ec2DeprecatedInstanceGeneration;
+
+    it("Should report instances using a previous-generation instance type", async () => {
+        const args = createResourceValidationArgs(aws.ec2.Instance, { ami: "ami-1234", instanceType: "m3.large" });
+        await assertHasResourceViolation(policy, args, {
+            message: "EC2 instance 'unknown' uses the previous-generation instance type 'm3.large' and should use " +
+                "a current-generation type instead, e.g. 'm6i.large'.",
+        });
+    });
+
+    it("Should pass if the instance uses a current-generation instance type", async () => {
+        const args = createResourceValidationArgs(aws.ec2.Instance, { ami: "ami-1234", instanceType: "m6i.large" });
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should use the configured list of deprecated families", async () => {
+        const config = { deprecatedFamilies: ["t2"] };
+        await assertNoResourceViolations(policy,
+            createResourceValidationArgs(aws.ec2.Instance, { ami: "ami-1234", instanceType: "m3.large" }, config));
+        await assertHasResourceViolation(policy,
+            createResourceValidationArgs(aws.ec2.Instance, { ami: "ami-1234", instanceType: "t2.micro" }, config), {
+                message: "EC2 instance 'unknown' uses the previous-generation instance type 't2.micro' and should use " +
+                    "a current-generation type instead.",
+            });
+    });
+});
+
+describe("#ec2HibernationRequiresEncryption", () => {
+    const policy = compute.ec2HibernationRequiresEncryption;
+
+    it("Should fail if a hibernating instance's root block device is not encrypted", async () => {
+        const args = createResourceValidationArgs(aws.ec2.Instance, { ami: "ami-1234", instanceType: "m5.large", hibernation: true });
+        await assertHasResourceViolation(policy, args, {
+            message: "EC2 instance 'unknown' has hibernation enabled, so its root block device must be encrypted.",
+        });
+
+        args.props.rootBlockDevice = { encrypted: false };
+        await assertHasResourceViolation(policy, args, {
+            message: "EC2 instance 'unknown' has hibernation enabled, so its root block device must be encrypted.",
+        });
+    });
+
+    it("Should pass if the root block device is encrypted or hibernation is disabled", async () => {
+        await assertNoResourceViolations(policy, createResourceValidationArgs(aws.ec2.Instance, {
+            ami: "ami-1234", instanceType: "m5.large", hibernation: true, rootBlockDevice: { encrypted: true },
+        }));
+        await assertNoResourceViolations(policy, createResourceValidationArgs(aws.ec2.Instance, {
+            ami: "ami-1234", instanceType: "m5.large",
+        }));
+    });
+});
+
+describe("#ebsAccountDefaultEncryption", () => {
+    const policy = compute.ebsAccountDefaultEncryption;
+
+    function createVolume() {
+        return createPolicyResource(aws.ebs.Volume, { availabilityZone: "us-west-2a", size: 10, encrypted: true }, "data");
+    }
+
+    it("Should warn if volumes are created without EBS encryption by default", async () => {
+        await assertHasStackViolation(policy, createStackValidationArgsWithResources([createVolume()]), {
+            message: "The stack creates 1 EBS volume(s) or EC2 instance(s), but doesn't enable EBS encryption by default.",
+        });
+
+        const disabled = createPolicyResource(aws.ebs.EncryptionByDefault, { enabled: false }, "default-encryption");
+        await assertHasStackViolation(policy, createStackValidationArgsWithResources([createVolume(), disabled]), {
+            message: "Add an 'aws.ebs.EncryptionByDefault' resource",
+        });
+    });
+
+    it("Should pass if the stack enables EBS encryption by default", async () => {
+        const enabled = createPolicyResource(aws.ebs.EncryptionByDefault, {}, "default-encryption");
+        await assertNoStackViolations(policy, createStackValidationArgsWithResources([createVolume(), enabled]));
+    });
+
+    it("Should pass if the stack creates no volumes", async () => {
+        const bucket = createPolicyResource(aws.s3.Bucket, {}, "bucket");
+        await assertNoStackViolations(policy, createStackValidationArgsWithResources([bucket]));
+    });
+});
@@ -0,0 +1,102 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import * as assert from "assert";
+import * as fs from "fs";
+import * as os from "os";
+import * as path from "path";
+
+import "mocha";
+
+import { ResourceValidationPolicy } from "@pulumi/policy";
+
+import { loadConfigFile, mergeArgs } from "../configFile";
+
+// Make mixins available.
+import "../index";
+
+describe("#loadConfigFile", () => {
+    const policy: ResourceValidationPolicy = { name: "ec2-volume-inuse", description: "Test policy.", validateResource: () => undefined };
+    const policyMap = { ec2VolumeInUse: policy };
+
+    function writeFile(name: string, contents: string): string {
+        const filePath = path.join(fs.mkdtempSync(path.join(os.tmpdir(), "awsguard-")), name);
+        fs.writeFileSync(filePath, contents);
+        return filePath;
+    }
+
+    it("loads JSON config", () => {
+        const filePath = writeFile("awsguard.json", JSON.stringify({
+            all: "mandatory",
+            ec2VolumeInUse: { enforcementLevel: "advisory", checkDeletion: false },
+        }));
+        assert.deepStrictEqual(loadConfigFile(filePath, policyMap), {
+            all: "mandatory",
+            ec2VolumeInUse: { enforcementLevel: "advisory", checkDeletion: false },
+        });
+    });
+
+    it("loads YAML config, referring to policies by name", () => {
+        const filePath = writeFile("awsguard.yaml", [
+            "all: advisory",
+            "onApiError: warn",
+            "ec2-volume-inuse:",
+            "  checkDeletion: false",
+        ].join("\n"));
+        assert.deepStrictEqual(loadConfigFile(filePath, policyMap), {
+            all: "advisory",
+            onApiError: "warn",
+            ec2VolumeInUse: { checkDeletion: false },
+        });
+    });
+
+    it("rejects unknown policies and invalid enforcement levels", () => {
+        assert.throws(() => loadConfigFile(writeFile("a.json", `{ "noSuchPolicy": "mandatory" }`), policyMap),
+            /'noSuchPolicy' is not a known policy/);
+        assert.throws(() => loadConfigFile(writeFile("b.json", `{ "ec2VolumeInUse": "required" }`), policyMap),
+            /'required' is not a valid enforcement level for 'ec2VolumeInUse'/);
+        assert.throws(() => loadConfigFile(writeFile("c.json", `{ "ec2VolumeInUse": { "enforcementLevel": "on" } }`), policyMap),
+            /'on' is not a valid enforcement level/);
+        assert.throws(() => loadConfigFile(writeFile("d.yml", "all: sometimes"), policyMap),
+            /'sometimes' is not a valid enforcement level for 'all'/);
+        assert.throws(() => loadConfigFile(writeFile("e.json", `{ "severityEnforcement": { "severe": "mandatory" } }`), policyMap),
+            /'severe' is not a valid severity/);
+        assert.throws(() => loadConfigFile(writeFile("f.json", `{ "severityEnforcement": { "low": "off" } }`), policyMap),
+            /'off' is not a valid enforcement level for severity 'low'/);
+    });
+});
+
+describe("#mergeArgs", () => {
+    it("prefers inline args over file args", () => {
+        assert.deepStrictEqual(
+            mergeArgs({ all: "mandatory", ec2InstanceNoPublicIP: "mandatory" }, { all: "advisory" }),
+            { all: "advisory", ec2InstanceNoPublicIP: "mandatory" });
+        assert.deepStrictEqual(
+            mergeArgs({ ec2InstanceNoPublicIP: "mandatory" }, { ec2InstanceNoPublicIP: "disabled" }),
+            { ec2InstanceNoPublicIP: "disabled" });
+    });
+
+    it("merges the settings of policies configured in both", () => {
+        assert.deepStrictEqual(
+            mergeArgs({ ec2VolumeInUse: { checkDeletion: false } }, { ec2VolumeInUse: "mandatory" }),
+            { ec2VolumeInUse: { checkDeletion: false, enforcementLevel: "mandatory" } });
+        assert.deepStrictEqual(
+            mergeArgs({ ec2VolumeInUse: "advisory" }, { ec2VolumeInUse: { checkDeletion: true } }),
+            { ec2VolumeInUse: { enforcementLevel: "advisory", checkDeletion: true } });
+    });
+
+    it("returns the file args if there are no inline args", () => {
+        assert.deepStrictEqual(mergeArgs({ all: "disabled" }), { all: "disabled" });
+    });
+});
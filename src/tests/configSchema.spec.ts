@@ -0,0 +1,92 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import * as assert from "assert";
+
+import "mocha";
+
+import { ResourceValidationPolicy } from "@pulumi/policy";
+
+import { namePatternFormat, validatePolicyConfig } from "../configSchema";
+
+describe("#validatePolicyConfig", () => {
+    const policy: ResourceValidationPolicy = {
+        name: "test-policy",
+        description: "Test policy.",
+        configSchema: {
+            properties: {
+                maxAge: { type: "number", minimum: 1, default: 90 },
+                allowedNames: { type: "array", items: { type: "string" }, default: [] },
+                checks: {
+                    type: "object",
+                    properties: {
+                        encryption: { type: "boolean", default: true },
+                    },
+                },
+            },
+        },
+        validateResource: () => undefined,
+    };
+
+    it("accepts valid config", () => {
+        validatePolicyConfig(policy, "mandatory");
+        validatePolicyConfig(policy, { enforcementLevel: "advisory" });
+        validatePolicyConfig(policy, { maxAge: 30, allowedNames: ["a", "b"], checks: { encryption: false } });
+        validatePolicyConfig({ ...policy, configSchema: undefined }, { anything: "goes" });
+    });
+
+    it("rejects invalid enforcement levels", () => {
+        assert.throws(() => validatePolicyConfig(policy, "required"),
+            /^Error: Invalid config for policy 'test-policy': 'required' is not a valid enforcement level\.$/);
+        assert.throws(() => validatePolicyConfig(policy, { enforcementLevel: "warn", maxAge: 30 }),
+            /^Error: Invalid config for policy 'test-policy': 'warn' is not a valid enforcement level\.$/);
+    });
+
+    it("rejects unknown properties", () => {
+        assert.throws(() => validatePolicyConfig(policy, { maxAgeDays: 30 }),
+            /^Error: Invalid config for policy 'test-policy': 'maxAgeDays' is not a known property\. Expected one of: 'maxAge', 'allowedNames', 'checks'\.$/);
+        assert.throws(() => validatePolicyConfig(policy, { checks: { encrypted: true } }),
+            /'checks\.encrypted' is not a known property\. Expected one of: 'checks\.encryption'\.$/);
+    });
+
+    it("rejects values of the wrong type", () => {
+        assert.throws(() => validatePolicyConfig(policy, { maxAge: "30" }),
+            /^Error: Invalid config for policy 'test-policy': 'maxAge' must be a number, but got the string "30"\.$/);
+        assert.throws(() => validatePolicyConfig(policy, { allowedNames: "a" }),
+            /'allowedNames' must be an array, but got the string "a"\.$/);
+        assert.throws(() => validatePolicyConfig(policy, { allowedNames: ["a", 2] }),
+            /'allowedNames\[1\]' must be a string, but got number 2\.$/);
+        assert.throws(() => validatePolicyConfig(policy, { checks: { encryption: "yes" } }),
+            /'checks\.encryption' must be a boolean, but got the string "yes"\.$/);
+    });
+
+    it("rejects invalid name patterns", () => {
+        const withPatterns: ResourceValidationPolicy = {
+            ...policy,
+            configSchema: {
+                properties: {
+                    allowedNames: { type: "array", items: { type: "string", format: namePatternFormat } },
+                },
+            },
+        };
+        validatePolicyConfig(withPatterns, { allowedNames: ["web-*", "/^db-\\d+$/", "\\/literal/"] });
+        assert.throws(() => validatePolicyConfig(withPatterns, { allowedNames: ["web-*", "/(unclosed/"] }),
+            /'allowedNames\[1\]' must be a valid pattern: '\/\(unclosed\/' is not a valid regular expression/);
+    });
+
+    it("rejects numbers out of range", () => {
+        assert.throws(() => validatePolicyConfig(policy, { maxAge: 0 }),
+            /'maxAge' must be at least 1, but got 0\.$/);
+    });
+});
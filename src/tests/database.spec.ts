@@ -135,6 +135,37 @@ describe("#redshiftClusterConfiguration", () => {
             await assertHasResourceViolation(policy, args, { message: msg });
         });
     });
+
+    describe("individual checks can be turned off", () => {
+        const policy = database.redshiftClusterConfiguration;
+
+        function getArgs(checks: database.RedshiftClusterConfigurationChecks): ResourceValidationArgs {
+            return createResourceValidationArgs(aws.redshift.Cluster, {
+                clusterIdentifier: "test",
+                nodeType: "not-allowed",
+                encrypted: false,
+            }, {
+                clusterDbEncrypted: true,
+                loggingEnabled: true,
+                nodeTypes: ["dc1.large"],
+                checks,
+            });
+        }
+
+        it("Should only report the checks that are on", async () => {
+            const args = getArgs({ encryption: true, nodeType: false, logging: false });
+            await assertHasResourceViolation(policy, args, { message: "Redshift cluster must be encrypted." });
+
+            await assertNoResourceViolations(policy, getArgs({ encryption: false, nodeType: false, logging: false }));
+        });
+
+        it("Should report all checks when unspecified", async () => {
+            const args = getArgs({});
+            await assertHasResourceViolation(policy, args, { message: "Redshift cluster must be encrypted." });
+            await assertHasResourceViolation(policy, args, { message: "Redshift cluster node type must be one of the following: dc1.large" });
+            await assertHasResourceViolation(policy, args, { message: "Redshift cluster must have logging enabled." });
+        });
+    });
 });
 
 describe("#redshiftClusterMaintenanceSettings", () => {
@@ -253,6 +284,112 @@ describe("#redshiftClusterPublicAccess", () => {
     });
 });
 
+describe("#memorydbClusterEncryption", () => {
+    const policy = database.memorydbClusterEncryption;
+
+    function createArgs() {
+        return createResourceValidationArgs(aws.memorydb.Cluster, {
+            name: "sessions",
+            aclName: "open-access",
+            nodeType: "db.t4g.small",
+            kmsKeyArn: "arn:aws:kms:us-west-2:123456789012:key/1234",
+        });
+    }
+
+    it("Should pass if the cluster uses TLS and a KMS key", async () => {
+        await assertNoResourceViolations(policy, createArgs());
+    });
+
+    it("Should fail if TLS is disabled", async () => {
+        const args = createArgs();
+        args.props.tlsEnabled = false;
+        await assertHasResourceViolation(policy, args, {
+            message: "MemoryDB cluster 'sessions' must have TLS enabled.",
+        });
+    });
+
+    it("Should fail if the cluster isn't encrypted with a KMS key", async () => {
+        const args = createArgs();
+        delete args.props.kmsKeyArn;
+        await assertHasResourceViolation(policy, args, {
+            message: "MemoryDB cluster 'sessions' must be encrypted at rest with a customer managed KMS key.",
+        });
+    });
+});
+
+describe("#redshiftServerlessEncryption", () => {
+    const policy = database.redshiftServerlessEncryption;
+
+    it("Should pass if the namespace is encrypted and exports its audit logs", async () => {
+        const args = createResourceValidationArgs(aws.redshiftserverless.Namespace, {
+            namespaceName: "analytics",
+            kmsKeyId: "arn:aws:kms:us-west-2:123456789012:key/1234",
+            logExports: ["connectionlog", "userlog", "useractivitylog"],
+        });
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should fail if the namespace isn't encrypted with a KMS key", async () => {
+        const args = createResourceValidationArgs(aws.redshiftserverless.Namespace, {
+            namespaceName: "analytics",
+            logExports: ["connectionlog", "userlog", "useractivitylog"],
+        });
+        await assertHasResourceViolation(policy, args, {
+            message: "Redshift Serverless namespace 'analytics' must be encrypted with a customer managed KMS key.",
+        });
+    });
+
+    it("Should fail if audit logs aren't exported", async () => {
+        const args = createResourceValidationArgs(aws.redshiftserverless.Namespace, {
+            namespaceName: "analytics",
+            kmsKeyId: "arn:aws:kms:us-west-2:123456789012:key/1234",
+            logExports: ["userlog"],
+        });
+        await assertHasResourceViolation(policy, args, {
+            message: "Redshift Serverless namespace 'analytics' must export its audit logs. " +
+                "Add \"connectionlog\", \"useractivitylog\" to 'logExports'.",
+        });
+
+        const configured = createResourceValidationArgs(aws.redshiftserverless.Namespace, {
+            namespaceName: "analytics",
+            kmsKeyId: "arn:aws:kms:us-west-2:123456789012:key/1234",
+            logExports: ["userlog"],
+        }, { requiredLogExports: ["userlog"] });
+        await assertNoResourceViolations(policy, configured);
+    });
+});
+
+describe("#daxClusterEncryption", () => {
+    const policy = database.daxClusterEncryption;
+
+    it("Should pass if the cluster is encrypted", async () => {
+        const args = createResourceValidationArgs(aws.dax.Cluster, {
+            iamRoleArn: "arn:aws:iam::123456789012:role/dax",
+            nodeType: "dax.r4.large",
+            replicationFactor: 1,
+            serverSideEncryption: { enabled: true },
+        });
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should fail if server-side encryption is disabled or not specified", async () => {
+        const args = createResourceValidationArgs(aws.dax.Cluster, {
+            iamRoleArn: "arn:aws:iam::123456789012:role/dax",
+            nodeType: "dax.r4.large",
+            replicationFactor: 1,
+            serverSideEncryption: { enabled: false },
+        });
+        await assertHasResourceViolation(policy, args, {
+            message: "DAX cluster 'unknown' must have server-side encryption enabled.",
+        });
+
+        delete args.props.serverSideEncryption;
+        await assertHasResourceViolation(policy, args, {
+            message: "DAX cluster 'unknown' must have server-side encryption enabled.",
+        });
+    });
+});
+
 describe("#dynamodbTableEncryptionEnabled", () => {
     const policy = database.dynamodbTableEncryptionEnabled;
 
@@ -392,6 +529,75 @@ describe("#rdsInstanceBackupEnabled", () => {
             await assertHasResourceViolation(policy, args, { message: msg });
         });
     });
+
+    describe("minimum backup retention period", () => {
+        const policy = database.rdsInstanceBackupEnabled;
+
+        function getArgs(backupRetentionPeriod: number, minBackupRetentionDays?: number): ResourceValidationArgs {
+            return createResourceValidationArgs(aws.rds.Instance, {
+                instanceClass: "db.m5.large",
+                backupRetentionPeriod,
+            }, { minBackupRetentionDays });
+        }
+
+        it("Should pass if backups are retained for at least the minimum", async () => {
+            await assertNoResourceViolations(policy, getArgs(7));
+            await assertNoResourceViolations(policy, getArgs(30, 14));
+        });
+
+        it("Should fail if backups are retained for less than the minimum", async () => {
+            await assertHasResourceViolation(policy, getArgs(3), {
+                message: "RDS Instances must retain backups for at least 7 days, but retain them for 3.",
+            });
+            await assertHasResourceViolation(policy, getArgs(7, 14), {
+                message: "RDS Instances must retain backups for at least 14 days",
+            });
+        });
+    });
+
+    describe("Aurora clusters", () => {
+        const policy = database.rdsInstanceBackupEnabled;
+
+        it("Should fail if the cluster retains backups for less than the configured minimum", async () => {
+            const args = createResourceValidationArgs(aws.rds.Cluster, { engine: "aurora-postgresql" }, {
+                minBackupRetentionDays: 7,
+            });
+            await assertHasResourceViolation(policy, args, {
+                message: "RDS Cluster 'unknown' must retain backups for at least 7 days, but retains them for 1.",
+            });
+
+            args.props.backupRetentionPeriod = 7;
+            await assertNoResourceViolations(policy, args);
+        });
+
+        it("Should not check the cluster's retention if no minimum is configured", async () => {
+            const args = createResourceValidationArgs(aws.rds.Cluster, { engine: "aurora-postgresql" });
+            await assertNoResourceViolations(policy, args);
+        });
+
+        it("Should check the configured retention period and backup window", async () => {
+            const args = createResourceValidationArgs(aws.rds.Cluster, {
+                engine: "aurora-postgresql",
+                backupRetentionPeriod: 14,
+                preferredBackupWindow: "07:00-09:00",
+            }, { backupRetentionPeriod: 14, preferredBackupWindow: "07:00-09:00" });
+            await assertNoResourceViolations(policy, args);
+
+            args.props.preferredBackupWindow = "01:00-03:00";
+            await assertHasResourceViolation(policy, args, {
+                message: "RDS Cluster 'unknown' must have a preferred backup window of: 07:00-09:00.",
+            });
+        });
+
+        it("Should not report the instances of a cluster", async () => {
+            const args = createResourceValidationArgs(aws.rds.ClusterInstance, {
+                clusterIdentifier: "test-cluster",
+                engine: "aurora-postgresql",
+                instanceClass: "db.r6g.large",
+            });
+            await assertNoResourceViolations(policy, args);
+        });
+    });
 });
 
 describe("#rdsInstanceMultiAZEnabled", () => {
@@ -452,6 +658,18 @@ describe("#rdsInstancePublicAccess", () => {
         const msg = "RDS Instance must not be publicly accessible.";
         await assertHasResourceViolation(policy, args, { message: msg });
     });
+
+    it("Should fail if an Aurora cluster instance is publicly accessible", async () => {
+        const args = createResourceValidationArgs(aws.rds.ClusterInstance, {
+            clusterIdentifier: "test-cluster",
+            instanceClass: "db.r6g.large",
+            publiclyAccessible: true,
+        });
+        await assertHasResourceViolation(policy, args, { message: "RDS Cluster Instance 'unknown' must not be publicly accessible." });
+
+        args.props.publiclyAccessible = false;
+        await assertNoResourceViolations(policy, args);
+    });
 });
 
 describe("#rdsStorageEncrypted", () => {
@@ -549,4 +767,216 @@ describe("#rdsStorageEncrypted", () => {
             await assertNoResourceViolations(policy, args);
         });
     });
+
+    describe("Aurora clusters", () => {
+        const policy = database.rdsStorageEncrypted;
+
+        it("Should fail if the cluster's storage is not encrypted", async () => {
+            const args = createResourceValidationArgs(aws.rds.Cluster, { engine: "aurora-mysql" });
+            await assertHasResourceViolation(policy, args, {
+                message: "RDS Cluster 'unknown' must have storage encryption enabled.",
+            });
+
+            args.props.storageEncrypted = true;
+            await assertNoResourceViolations(policy, args);
+        });
+
+        it("Should pass for serverless v1 clusters, which are always encrypted", async () => {
+            const args = createResourceValidationArgs(aws.rds.Cluster, { engine: "aurora-mysql", engineMode: "serverless" });
+            await assertNoResourceViolations(policy, args);
+        });
+
+        it("Should fail if the cluster isn't encrypted with the configured key", async () => {
+            const args = createResourceValidationArgs(aws.rds.Cluster, {
+                engine: "aurora-mysql",
+                storageEncrypted: true,
+                kmsKeyId: "other-key-id",
+            }, { kmsKeyId: "test-key-id" });
+            await assertHasResourceViolation(policy, args, {
+                message: "RDS Cluster 'unknown' must be encrypted with kms key id: test-key-id.",
+            });
+        });
+
+        it("Should not report the instances of a cluster", async () => {
+            const args = createResourceValidationArgs(aws.rds.ClusterInstance, {
+                clusterIdentifier: "test-cluster",
+                instanceClass: "db.r6g.large",
+            });
+            await assertNoResourceViolations(policy, args);
+        });
+    });
+});
+
+describe("#rdsCaCertificateCurrent", () => {
+    const policy = database.rdsCaCertificateCurrent;
+
+    it("Should fail if the instance uses a deprecated CA certificate", async () => {
+        const args = createResourceValidationArgs(aws.rds.Instance, { instanceClass: "db.t3.micro", caCertIdentifier: "rds-ca-2019" });
+        await assertHasResourceViolation(policy, args, {
+            message: "RDS Instance 'unknown' uses the CA certificate 'rds-ca-2019', which is not a current CA certificate.",
+        });
+    });
+
+    it("Should pass if the instance uses a current or the default CA certificate", async () => {
+        await assertNoResourceViolations(policy,
+            createResourceValidationArgs(aws.rds.Instance, { instanceClass: "db.t3.micro", caCertIdentifier: "rds-ca-rsa2048-g1" }));
+        await assertNoResourceViolations(policy, createResourceValidationArgs(aws.rds.Instance, { instanceClass: "db.t3.micro" }));
+    });
+
+    it("Should use the configured CA certificate identifiers", async () => {
+        const config = { allowedCaCertIdentifiers: ["rds-ca-ecc384-g1"] };
+        await assertHasResourceViolation(policy,
+            createResourceValidationArgs(aws.rds.Instance, { instanceClass: "db.t3.micro", caCertIdentifier: "rds-ca-rsa2048-g1" }, config),
+            { message: "uses the CA certificate 'rds-ca-rsa2048-g1'" });
+    });
+});
+
+describe("#rdsPerformanceInsightsEncrypted", () => {
+    const policy = database.rdsPerformanceInsightsEncrypted;
+
+    it("Should pass if Performance Insights is disabled", async () => {
+        const args = createResourceValidationArgs(aws.rds.Instance, {
+            instanceClass: "db.m5.large",
+        });
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should pass if Performance Insights is encrypted", async () => {
+        const args = createResourceValidationArgs(aws.rds.Instance, {
+            instanceClass: "db.m5.large",
+            performanceInsightsEnabled: true,
+            performanceInsightsKmsKeyId: "test-key-id",
+        });
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should fail if an instance's Performance Insights is not encrypted", async () => {
+        const args = createResourceValidationArgs(aws.rds.Instance, {
+            instanceClass: "db.m5.large",
+            performanceInsightsEnabled: true,
+        });
+
+        const msg = "must encrypt Performance Insights data with a KMS key.";
+        await assertHasResourceViolation(policy, args, { message: msg });
+    });
+
+    it("Should fail if a cluster's Performance Insights is not encrypted", async () => {
+        const args = createResourceValidationArgs(aws.rds.Cluster, {
+            engine: "aurora-postgresql",
+            performanceInsightsEnabled: true,
+        });
+        args.name = "test-cluster";
+
+        const msg = "RDS Cluster 'test-cluster' must encrypt Performance Insights data with a KMS key.";
+        await assertHasResourceViolation(policy, args, { message: msg });
+    });
+});
+
+describe("#timestreamDatabaseKmsKey", () => {
+    const policy = database.timestreamDatabaseKmsKey;
+
+    it("Should pass if the database is encrypted with a KMS key", async () => {
+        const args = createResourceValidationArgs(aws.timestreamwrite.Database, {
+            databaseName: "test",
+            kmsKeyId: "test-key-id",
+        });
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should fail if the database does not specify a KMS key", async () => {
+        const args = createResourceValidationArgs(aws.timestreamwrite.Database, { databaseName: "test" });
+        args.name = "test-database";
+
+        await assertHasResourceViolation(policy, args, {
+            message: "Timestream database 'test-database' must be encrypted with a customer managed KMS key.",
+        });
+    });
+});
+
+describe("#timestreamMagneticStoreRejectedDataEncrypted", () => {
+    const policy = database.timestreamMagneticStoreRejectedDataEncrypted;
+
+    function getHappyPathArgs(): ResourceValidationArgs {
+        return createResourceValidationArgs(aws.timestreamwrite.Table, {
+            databaseName: "test",
+            tableName: "test",
+            magneticStoreWriteProperties: {
+                enableMagneticStoreWrites: true,
+                magneticStoreRejectedDataLocation: {
+                    s3Configuration: {
+                        bucketName: "rejected-records",
+                        encryptionOption: "SSE_KMS",
+                        kmsKeyId: "test-key-id",
+                    },
+                },
+            },
+        });
+    }
+
+    it("Should pass if rejected records are encrypted with a KMS key", async () => {
+        const args = getHappyPathArgs();
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should pass if magnetic store writes are disabled", async () => {
+        const args = getHappyPathArgs();
+        args.props.magneticStoreWriteProperties = { enableMagneticStoreWrites: false };
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should fail if rejected records are not encrypted with a KMS key", async () => {
+        const args = getHappyPathArgs();
+        args.props.magneticStoreWriteProperties.magneticStoreRejectedDataLocation.s3Configuration.encryptionOption = "SSE_S3";
+
+        await assertHasResourceViolation(policy, args, {
+            message: "allows magnetic store writes and must store rejected records in S3 encrypted with a KMS key.",
+        });
+    });
+
+    it("Should fail if there is no rejected data location", async () => {
+        const args = getHappyPathArgs();
+        args.props.magneticStoreWriteProperties.magneticStoreRejectedDataLocation = undefined;
+
+        await assertHasResourceViolation(policy, args, { message: "must store rejected records" });
+    });
+});
+
+describe("#elasticacheBackupRetention", () => {
+    const policy = database.elasticacheBackupRetention;
+
+    it("Should fail if a replication group has automatic backups disabled", async () => {
+        const args = createResourceValidationArgs(aws.elasticache.ReplicationGroup, { description: "Test group." });
+        await assertHasResourceViolation(policy, args, {
+            message: "ElastiCache replication group 'unknown' must have automatic backups enabled.",
+        });
+
+        args.props.snapshotRetentionLimit = 0;
+        await assertHasResourceViolation(policy, args, { message: "must have automatic backups enabled." });
+
+        args.props.snapshotRetentionLimit = 1;
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should fail if a replication group retains snapshots for less than the minimum", async () => {
+        const args = createResourceValidationArgs(aws.elasticache.ReplicationGroup, {
+            description: "Test group.",
+            snapshotRetentionLimit: 3,
+        }, { minSnapshotRetentionDays: 7 });
+        await assertHasResourceViolation(policy, args, {
+            message: "ElastiCache replication group 'unknown' must retain snapshots for at least 7 days, but retains them for 3.",
+        });
+    });
+
+    it("Should check Redis clusters, but skip Memcached clusters and clusters in a replication group", async () => {
+        const args = createResourceValidationArgs(aws.elasticache.Cluster, { engine: "redis" });
+        await assertHasResourceViolation(policy, args, {
+            message: "ElastiCache cluster 'unknown' must have automatic backups enabled.",
+        });
+
+        args.props.engine = "memcached";
+        await assertNoResourceViolations(policy, args);
+
+        args.props = { replicationGroupId: "test-group" };
+        await assertNoResourceViolations(policy, args);
+    });
 });
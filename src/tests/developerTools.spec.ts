@@ -0,0 +1,215 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import "mocha";
+
+import * as aws from "@pulumi/aws";
+import { ResourceValidationArgs } from "@pulumi/policy";
+
+import * as developerTools from "../developerTools";
+
+import {
+    assertHasResourceViolation, assertHasStackViolation,
+    assertNoResourceViolations, assertNoStackViolations,
+    createPolicyResource, createResourceValidationArgs, createStackValidationArgsWithResources,
+} from "./util";
+
+describe("#codebuildNoPlaintextCredentials", () => {
+    const policy = developerTools.codebuildNoPlaintextCredentials;
+
+    function getHappyPathArgs(): ResourceValidationArgs {
+        return createResourceValidationArgs(aws.codebuild.Project, {
+            serviceRole: "arn:aws:iam::123456789012:role/codebuild",
+            artifacts: { type: "NO_ARTIFACTS" },
+            source: { type: "NO_SOURCE", buildspec: "buildspec.yml" },
+            environment: {
+                computeType: "BUILD_GENERAL1_SMALL",
+                image: "aws/codebuild/standard:5.0",
+                type: "LINUX_CONTAINER",
+                environmentVariables: [
+                    { name: "STAGE", value: "production" },
+                    { name: "DB_PASSWORD", value: "/build/db-password", type: "PARAMETER_STORE" },
+                    { name: "GITHUB_TOKEN", value: "build/github-token", type: "SECRETS_MANAGER" },
+                ],
+            },
+        });
+    }
+
+    it("Should pass if credentials are stored in Secrets Manager or Parameter Store", async () => {
+        const args = getHappyPathArgs();
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should fail if a credential is stored in a plaintext variable", async () => {
+        const args = getHappyPathArgs();
+        args.props.environment.environmentVariables.push({ name: "aws_secret_access_key", value: "hunter2" });
+
+        await assertHasResourceViolation(policy, args, {
+            message: "CodeBuild project 'unknown' must not store credentials in the plaintext environment variable " +
+                "'aws_secret_access_key'.",
+        });
+    });
+
+    it("Should fail if a credential's type is explicitly PLAINTEXT", async () => {
+        const args = getHappyPathArgs();
+        args.props.environment.environmentVariables[1].type = "PLAINTEXT";
+
+        await assertHasResourceViolation(policy, args, { message: "'DB_PASSWORD'" });
+    });
+
+    it("Should use the configured secret name pattern", async () => {
+        const args = createResourceValidationArgs(aws.codebuild.Project, {
+            serviceRole: "arn:aws:iam::123456789012:role/codebuild",
+            artifacts: { type: "NO_ARTIFACTS" },
+            source: { type: "NO_SOURCE", buildspec: "buildspec.yml" },
+            environment: {
+                computeType: "BUILD_GENERAL1_SMALL",
+                image: "aws/codebuild/standard:5.0",
+                type: "LINUX_CONTAINER",
+                environmentVariables: [
+                    { name: "API_KEY", value: "not-a-secret" },
+                    { name: "NPM_AUTH", value: "hunter2" },
+                ],
+            },
+        }, { secretNamePattern: "_AUTH$" });
+
+        await assertHasResourceViolation(policy, args, { message: "'NPM_AUTH'" });
+    });
+});
+
+describe("#codebuildPrivilegedMode", () => {
+    const policy = developerTools.codebuildPrivilegedMode;
+
+    function getHappyPathArgs(): ResourceValidationArgs {
+        return createResourceValidationArgs(aws.codebuild.Project, {
+            serviceRole: "arn:aws:iam::123456789012:role/codebuild",
+            artifacts: { type: "NO_ARTIFACTS" },
+            source: { type: "NO_SOURCE", buildspec: "buildspec.yml" },
+            environment: {
+                computeType: "BUILD_GENERAL1_SMALL",
+                image: "aws/codebuild/standard:5.0",
+                type: "LINUX_CONTAINER",
+                privilegedMode: false,
+            },
+        }, { allowedProjectNames: ["docker-build"] });
+    }
+
+    it("Should pass if the project does not run in privileged mode", async () => {
+        const args = getHappyPathArgs();
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should fail if the project runs in privileged mode", async () => {
+        const args = getHappyPathArgs();
+        args.props.environment.privilegedMode = true;
+
+        await assertHasResourceViolation(policy, args, {
+            message: "CodeBuild project 'unknown' must not run in privileged mode.",
+        });
+    });
+
+    it("Should pass if an allowed project runs in privileged mode", async () => {
+        const args = getHappyPathArgs();
+        args.props.environment.privilegedMode = true;
+        args.props.name = "docker-build";
+
+        await assertNoResourceViolations(policy, args);
+    });
+});
+
+describe("#amplifyBranchProtection", () => {
+    const policy = developerTools.amplifyBranchProtection;
+
+    it("Should fail if a non-production branch doesn't require basic auth", async () => {
+        const app = createPolicyResource(aws.amplify.App, {}, "test-app");
+        const branch = createPolicyResource(aws.amplify.Branch, { branchName: "feature" }, "test-branch", { appId: [app] });
+
+        const args = createStackValidationArgsWithResources([app, branch]);
+        await assertHasStackViolation(policy, args, {
+            message: "Amplify branch 'feature' is publicly accessible and should require basic auth.",
+        });
+    });
+
+    it("Should pass if the branch or its app requires basic auth", async () => {
+        const app = createPolicyResource(aws.amplify.App, {}, "test-app");
+        const branch = createPolicyResource(aws.amplify.Branch, {
+            branchName: "feature",
+            enableBasicAuth: true,
+        }, "test-branch", { appId: [app] });
+        await assertNoStackViolations(policy, createStackValidationArgsWithResources([app, branch]));
+
+        branch.props.enableBasicAuth = false;
+        app.props.enableBasicAuth = true;
+        await assertNoStackViolations(policy, createStackValidationArgsWithResources([app, branch]));
+    });
+
+    it("Should pass for public branches", async () => {
+        const branch = createPolicyResource(aws.amplify.Branch, { appId: "app-1234", branchName: "main" }, "test-branch");
+        await assertNoStackViolations(policy, createStackValidationArgsWithResources([branch]));
+
+        branch.props.branchName = "release";
+        const args = createStackValidationArgsWithResources([branch], { publicBranchNames: ["release"] });
+        await assertNoStackViolations(policy, args);
+    });
+
+    it("Should fail if the app automatically creates branches without basic auth", async () => {
+        const app = createPolicyResource(aws.amplify.App, {
+            enableAutoBranchCreation: true,
+            autoBranchCreationPatterns: ["feature/*"],
+        }, "test-app");
+        await assertHasStackViolation(policy, createStackValidationArgsWithResources([app]), {
+            message: "Amplify app 'test-app' automatically creates publicly accessible branches and should require basic auth for them.",
+        });
+
+        app.props.autoBranchCreationConfig = { enableBasicAuth: true, basicAuthCredentials: "dXNlcjpwYXNz" };
+        await assertNoStackViolations(policy, createStackValidationArgsWithResources([app]));
+    });
+});
+
+describe("#codepipelineArtifactEncryption", () => {
+    const policy = developerTools.codepipelineArtifactEncryption;
+
+    function getHappyPathArgs(): ResourceValidationArgs {
+        return createResourceValidationArgs(aws.codepipeline.Pipeline, {
+            roleArn: "arn:aws:iam::123456789012:role/codepipeline",
+            artifactStores: [{
+                location: "artifact-bucket",
+                type: "S3",
+                encryptionKey: { id: "arn:aws:kms:us-west-2:123456789012:alias/codepipeline", type: "KMS" },
+            }],
+            stages: [],
+        });
+    }
+
+    it("Should pass if the artifact store uses a customer managed key", async () => {
+        const args = getHappyPathArgs();
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should fail if the artifact store uses the AWS managed key", async () => {
+        const args = getHappyPathArgs();
+        args.props.artifactStores[0].encryptionKey = undefined;
+        await assertHasResourceViolation(policy, args, {
+            message: "CodePipeline pipeline 'unknown' must encrypt its artifact store 'artifact-bucket' with a customer managed KMS key.",
+        });
+    });
+
+    it("Should pass if customer managed keys are not required", async () => {
+        const args = createResourceValidationArgs(aws.codepipeline.Pipeline, getHappyPathArgs().props, {
+            requireCustomerManagedKey: false,
+        });
+        args.props.artifactStores[0].encryptionKey = undefined;
+        await assertNoResourceViolations(policy, args);
+    });
+});
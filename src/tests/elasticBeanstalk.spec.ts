@@ -0,0 +1,96 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+
+import "mocha";
+
+import * as aws from "@pulumi/aws";
+
+import * as elasticBeanstalk from "../elasticBeanstalk";
+
+import { assertHasResourceViolation, assertNoResourceViolations, createResourceValidationArgs } from "./util";
+
+const managedActions = { namespace: "aws:elasticbeanstalk:managedactions", name: "ManagedActionsEnabled", value: "true" };
+
+describe("#elasticbeanstalkManagedUpdates", () => {
+    const policy = elasticBeanstalk.elasticbeanstalkManagedUpdates;
+
+    it("Should fail if managed updates aren't enabled", async () => {
+        const args = createResourceValidationArgs(aws.elasticbeanstalk.Environment, { application: "app" });
+        await assertHasResourceViolation(policy, args, {
+            message: "Elastic Beanstalk environment 'unknown' should have managed platform updates enabled.",
+        });
+
+        args.props.settings = [{ ...managedActions, value: "false" }];
+        await assertHasResourceViolation(policy, args, {
+            message: "Elastic Beanstalk environment 'unknown' should have managed platform updates enabled.",
+        });
+    });
+
+    it("Should pass if managed updates are enabled", async () => {
+        const args = createResourceValidationArgs(aws.elasticbeanstalk.Environment, {
+            application: "app",
+            settings: [managedActions],
+        });
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should check configuration templates rather than the environments that use them", async () => {
+        const environment = createResourceValidationArgs(aws.elasticbeanstalk.Environment, {
+            application: "app",
+            templateName: "web",
+        });
+        await assertNoResourceViolations(policy, environment);
+
+        const template = createResourceValidationArgs(aws.elasticbeanstalk.ConfigurationTemplate, { application: "app" });
+        await assertHasResourceViolation(policy, template, {
+            message: "Elastic Beanstalk configuration template 'unknown' should have managed platform updates enabled.",
+        });
+    });
+});
+
+describe("#elasticbeanstalkPlatformVersion", () => {
+    const policy = elasticBeanstalk.elasticbeanstalkPlatformVersion;
+
+    it("Should fail if the environment uses a deprecated platform", async () => {
+        const args = createResourceValidationArgs(aws.elasticbeanstalk.Environment, {
+            application: "app",
+            solutionStackName: "64bit Amazon Linux 2 v5.4.0 running Node.js 14",
+        });
+        await assertHasResourceViolation(policy, args, {
+            message: "Elastic Beanstalk environment 'unknown' uses the deprecated platform " +
+                "'64bit Amazon Linux 2 v5.4.0 running Node.js 14'. Upgrade it to a supported platform version.",
+        });
+
+        args.props.solutionStackName = undefined;
+        args.props.platformArn = "arn:aws:elasticbeanstalk:us-west-2::platform/Python 3.6 running on 64bit Amazon Linux/2.9.0";
+        await assertHasResourceViolation(policy, args, { message: "uses the deprecated platform" });
+    });
+
+    it("Should pass if the environment uses a supported platform", async () => {
+        const args = createResourceValidationArgs(aws.elasticbeanstalk.Environment, {
+            application: "app",
+            solutionStackName: "64bit Amazon Linux 2023 v6.1.0 running Node.js 20",
+        });
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should use the configured deprecated platforms", async () => {
+        const args = createResourceValidationArgs(aws.elasticbeanstalk.Environment, {
+            application: "app",
+            solutionStackName: "64bit Amazon Linux 2023 v6.1.0 running Node.js 18",
+        }, { deprecatedPlatforms: ["*Node.js 18"] });
+        await assertHasResourceViolation(policy, args, { message: "uses the deprecated platform" });
+    });
+});
@@ -72,3 +72,38 @@ describe("#elasticsearchInVpcOnly", () => {
         await assertNoResourceViolations(policy, args);
     });
 });
+
+describe("#elasticsearchAuditLogsEnabled", () => {
+    const policy = elasticsearch.elasticsearchAuditLogsEnabled;
+    const domainName = "test-name";
+    const cloudwatchLogGroupArn = "arn:aws:logs:us-west-2:123456789012:log-group:audit";
+
+    it("Should fail if the domain has no audit logs", async () => {
+        const args = createResourceValidationArgs(aws.elasticsearch.Domain, {
+            domainName: domainName,
+            logPublishingOptions: [{ logType: "INDEX_SLOW_LOGS", cloudwatchLogGroupArn }],
+        });
+
+        const msg = `Elasticsearch domain ${domainName} must have audit logs enabled.`;
+        await assertHasResourceViolation(policy, args, { message: msg });
+    });
+
+    it("Should fail if the domain's audit logs are disabled", async () => {
+        const args = createResourceValidationArgs(aws.opensearch.Domain, {
+            domainName: domainName,
+            logPublishingOptions: [{ logType: "AUDIT_LOGS", cloudwatchLogGroupArn, enabled: false }],
+        });
+
+        const msg = `OpenSearch domain ${domainName} must have audit logs enabled.`;
+        await assertHasResourceViolation(policy, args, { message: msg });
+    });
+
+    it("Should pass if the domain has audit logs enabled", async () => {
+        const args = createResourceValidationArgs(aws.opensearch.Domain, {
+            domainName: domainName,
+            logPublishingOptions: [{ logType: "AUDIT_LOGS", cloudwatchLogGroupArn }],
+        });
+
+        await assertNoResourceViolations(policy, args);
+    });
+});
@@ -0,0 +1,90 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import * as assert from "assert";
+
+import "mocha";
+
+import * as aws from "@pulumi/aws";
+import { PolicyPackConfig, ResourceValidationPolicy } from "@pulumi/policy";
+
+import { applyEnforcementLevelCallback } from "../awsGuard";
+import * as compute from "../compute";
+import { EnforcementLevelCallback } from "../enforcementLevel";
+
+import { createResourceValidationArgs } from "./util";
+
+// Make mixins available.
+import "../index";
+
+describe("#applyEnforcementLevelCallback", () => {
+    const callback: EnforcementLevelCallback = resource =>
+        resource.props.tags && resource.props.tags.environment === "production" ? "mandatory" : "advisory";
+    const enforcementLevelCallbacks = { "encrypted-volumes": callback };
+
+    async function getViolations(policy: ResourceValidationPolicy, environment: string): Promise<string[]> {
+        const args = createResourceValidationArgs(aws.ec2.Instance, {
+            ami: "ami-12345678",
+            instanceType: "t2.micro",
+            tags: { environment },
+        });
+        const violations: string[] = [];
+        for (const validation of Array.isArray(policy.validateResource) ? policy.validateResource : [policy.validateResource]) {
+            await validation(args, message => violations.push(message));
+        }
+        return violations;
+    }
+
+    it("escalates violations of production-tagged resources to mandatory", async () => {
+        const config: PolicyPackConfig = { all: "advisory", "encrypted-volumes": { kmsId: "test-key-id" } };
+        const [mandatory, advisory] = <ResourceValidationPolicy[]>applyEnforcementLevelCallback(
+            compute.encryptedVolumes, { enforcementLevelCallbacks }, config);
+
+        assert.strictEqual(mandatory.name, "encrypted-volumes");
+        assert.strictEqual(advisory.name, "encrypted-volumes-advisory");
+        assert.deepStrictEqual(config, {
+            all: "advisory",
+            "encrypted-volumes": { kmsId: "test-key-id", enforcementLevel: "mandatory" },
+            "encrypted-volumes-advisory": { kmsId: "test-key-id", enforcementLevel: "advisory" },
+        });
+
+        assert.deepStrictEqual(await getViolations(mandatory, "production"), [
+            "The EC2 instance root block device must be encrypted.",
+        ]);
+        assert.deepStrictEqual(await getViolations(advisory, "production"), []);
+
+        assert.deepStrictEqual(await getViolations(mandatory, "staging"), []);
+        assert.deepStrictEqual(await getViolations(advisory, "staging"), [
+            "The EC2 instance root block device must be encrypted.",
+        ]);
+    });
+
+    it("adjusts the callback's enforcement levels in strict mode", async () => {
+        const config: PolicyPackConfig = { all: "advisory", "encrypted-volumes": { kmsId: "test-key-id" } };
+        const [mandatory, advisory] = <ResourceValidationPolicy[]>applyEnforcementLevelCallback(
+            compute.encryptedVolumes, { enforcementLevelCallbacks, strict: true }, config);
+
+        assert.deepStrictEqual(await getViolations(mandatory, "staging"), [
+            "The EC2 instance root block device must be encrypted.",
+        ]);
+        assert.deepStrictEqual(await getViolations(advisory, "staging"), []);
+    });
+
+    it("leaves the policy unchanged if it is disabled or has no callback", () => {
+        const policy = compute.encryptedVolumes;
+        assert.deepStrictEqual(
+            applyEnforcementLevelCallback(policy, { enforcementLevelCallbacks }, { all: "disabled" }), [policy]);
+        assert.deepStrictEqual(applyEnforcementLevelCallback(policy, {}, { all: "mandatory" }), [policy]);
+    });
+});
@@ -0,0 +1,110 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import * as assert from "assert";
+
+import "mocha";
+
+import * as aws from "@pulumi/aws";
+import { ResourceValidationPolicy, StackValidationPolicy } from "@pulumi/policy";
+
+import * as compute from "../compute";
+import * as database from "../database";
+import { withExplanations } from "../explain";
+import { withTagScope } from "../scope";
+
+import { createResourceValidationArgs, createStackValidationArgs } from "./util";
+
+describe("#withExplanations", () => {
+    async function explain(policy: ResourceValidationPolicy, args: any): Promise<[string[], string[]]> {
+        const logs: string[] = [];
+        const violations: string[] = [];
+        const wrapped = <ResourceValidationPolicy>withExplanations(policy, message => logs.push(message));
+        for (const validation of Array.isArray(wrapped.validateResource) ? wrapped.validateResource : [wrapped.validateResource]) {
+            await validation(args, message => violations.push(message));
+        }
+        return [logs, violations];
+    }
+
+    it("logs whether a resource passed or failed a policy that checks its type", async () => {
+        const args = createResourceValidationArgs(aws.ec2.Instance, {
+            ami: "ami-12345678",
+            instanceType: "t2.micro",
+            monitoring: true,
+        });
+
+        let [logs, violations] = await explain(compute.ec2InstanceDetailedMonitoringEnabled, args);
+        assert.deepStrictEqual(logs, ["awsguard explain: unknown: ec2-instance-detailed-monitoring-enabled passed"]);
+        assert.deepStrictEqual(violations, []);
+
+        args.props.monitoring = false;
+        [logs, violations] = await explain(compute.ec2InstanceDetailedMonitoringEnabled, args);
+        assert.deepStrictEqual(logs, ["awsguard explain: unknown: ec2-instance-detailed-monitoring-enabled failed (1 violation(s))"]);
+        assert.strictEqual(violations.length, 1);
+    });
+
+    it("logs when a policy does not check the resource's type", async () => {
+        const args = createResourceValidationArgs(aws.s3.Bucket, {});
+
+        const [logs] = await explain(compute.ec2InstanceDetailedMonitoringEnabled, args);
+        assert.deepStrictEqual(logs, [
+            "awsguard explain: unknown: ec2-instance-detailed-monitoring-enabled skipped (resource type not checked by this policy)",
+        ]);
+    });
+
+    it("doesn't log resources that are skipped before the policy runs", async () => {
+        const logs: string[] = [];
+        const explained = withExplanations(compute.ec2InstanceDetailedMonitoringEnabled, message => logs.push(message));
+        const wrapped = <ResourceValidationPolicy>withTagScope(explained, { key: "team" });
+        const args = createResourceValidationArgs(aws.ec2.Instance, {
+            ami: "ami-12345678",
+            instanceType: "t2.micro",
+        });
+
+        for (const validation of Array.isArray(wrapped.validateResource) ? wrapped.validateResource : [wrapped.validateResource]) {
+            await validation(args, () => undefined);
+        }
+        assert.deepStrictEqual(logs, []);
+
+        args.props.tags = { team: "payments" };
+        for (const validation of Array.isArray(wrapped.validateResource) ? wrapped.validateResource : [wrapped.validateResource]) {
+            await validation(args, () => undefined);
+        }
+        assert.deepStrictEqual(logs, ["awsguard explain: unknown: ec2-instance-detailed-monitoring-enabled failed (1 violation(s))"]);
+    });
+
+    it("logs one outcome for policies with several validations", async () => {
+        const args = createResourceValidationArgs(aws.rds.Instance, {
+            instanceClass: "db.m5.large",
+            backupRetentionPeriod: 7,
+        });
+
+        const [logs] = await explain(database.rdsInstanceBackupEnabled, args);
+        assert.deepStrictEqual(logs, ["awsguard explain: unknown: rds-instance-backup-enabled passed"]);
+    });
+
+    it("logs the outcome of stack policies", async () => {
+        const policy: StackValidationPolicy = {
+            name: "test-stack-policy",
+            description: "Test policy.",
+            validateStack: (_, reportViolation) => reportViolation("A violation."),
+        };
+
+        const logs: string[] = [];
+        const wrapped = <StackValidationPolicy>withExplanations(policy, message => logs.push(message));
+        await wrapped.validateStack(createStackValidationArgs(aws.s3.Bucket, {}), () => undefined);
+
+        assert.deepStrictEqual(logs, ["awsguard explain: stack: test-stack-policy failed (1 violation(s))"]);
+    });
+});
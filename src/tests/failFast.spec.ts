@@ -0,0 +1,105 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+
+import * as assert from "assert";
+
+import "mocha";
+
+import * as aws from "@pulumi/aws";
+import { EnforcementLevel, ResourceValidationPolicy, StackValidationPolicy } from "@pulumi/policy";
+
+import { Policy } from "../dispatch";
+import { withFailFast } from "../failFast";
+
+import { createPolicyResource, createResourceValidationArgs, createStackValidationArgsWithResources } from "./util";
+
+describe("#withFailFast", () => {
+    // Returns policies that each report a violation for every resource, counting their evaluations.
+    function createPolicies(count: number, evaluations: { count: number }): ResourceValidationPolicy[] {
+        const policies: ResourceValidationPolicy[] = [];
+        for (let i = 0; i < count; i++) {
+            policies.push({
+                name: `policy-${i}`,
+                description: "Reports every resource.",
+                validateResource: (_, reportViolation) => {
+                    evaluations.count++;
+                    reportViolation(`violation ${i}`);
+                },
+            });
+        }
+        return policies;
+    }
+
+    // Runs the policies against the resources in turn, as the policy engine would.
+    async function run(policies: Policy[], resources: number): Promise<string[]> {
+        const violations: string[] = [];
+        for (let r = 0; r < resources; r++) {
+            const args = createResourceValidationArgs(aws.s3.Bucket, {});
+            args.urn = `urn:pulumi:test::test::aws:s3/bucket:Bucket::bucket-${r}`;
+            for (const policy of <ResourceValidationPolicy[]>policies) {
+                const validations = Array.isArray(policy.validateResource) ? policy.validateResource : [policy.validateResource];
+                for (const validation of validations) {
+                    await validation(args, message => violations.push(message));
+                }
+            }
+        }
+        return violations;
+    }
+
+    it("evaluates fewer policies once resources have a mandatory violation", async () => {
+        const reportAll = { count: 0 };
+        await run(createPolicies(20, reportAll), 50);
+
+        const failFast = { count: 0 };
+        const failedUrns = new Set<string>();
+        const violations = await run(
+            createPolicies(20, failFast).map(p => withFailFast(p, "mandatory", failedUrns)), 50);
+
+        assert.strictEqual(reportAll.count, 1000);
+        assert.strictEqual(failFast.count, 50);
+        assert.strictEqual(violations.length, 50);
+        assert.strictEqual(failedUrns.size, 50);
+    });
+
+    it("keeps evaluating resources with only advisory violations", async () => {
+        const evaluations = { count: 0 };
+        const levels: EnforcementLevel[] = ["advisory", "mandatory", "mandatory"];
+        const failedUrns = new Set<string>();
+        const policies = createPolicies(3, evaluations).map((p, i) => withFailFast(p, levels[i], failedUrns));
+
+        assert.deepStrictEqual(await run(policies, 1), ["violation 0", "violation 1"]);
+        assert.strictEqual(evaluations.count, 2);
+    });
+
+    it("skips stack policies once any resource has a mandatory violation", async () => {
+        let evaluated = false;
+        const stackPolicy: StackValidationPolicy = {
+            name: "stack-policy",
+            description: "Reports nothing.",
+            validateStack: () => { evaluated = true; },
+        };
+        const args = createStackValidationArgsWithResources([createPolicyResource(aws.s3.Bucket, {}, "bucket")]);
+
+        const failedUrns = new Set<string>();
+        const wrapped = <StackValidationPolicy>withFailFast(stackPolicy, "mandatory", failedUrns);
+        await wrapped.validateStack(args, () => undefined);
+        assert.strictEqual(evaluated, true);
+
+        evaluated = false;
+        failedUrns.add("urn:pulumi:test::test::aws:s3/bucket:Bucket::bucket");
+        await wrapped.validateStack(args, () => undefined);
+        assert.strictEqual(evaluated, false);
+    });
+});
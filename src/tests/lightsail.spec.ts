@@ -0,0 +1,145 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+
+import "mocha";
+
+import * as aws from "@pulumi/aws";
+
+import * as lightsail from "../lightsail";
+
+import {
+    assertHasResourceViolation,
+    assertHasStackViolation,
+    assertNoResourceViolations,
+    assertNoStackViolations,
+    createPolicyResource,
+    createResourceValidationArgs,
+    createStackValidationArgsWithResources,
+} from "./util";
+
+describe("#lightsailPublicAccess", () => {
+    const policy = lightsail.lightsailPublicAccess;
+
+    function createInstance() {
+        return createPolicyResource(aws.lightsail.Instance, {
+            name: "web",
+            availabilityZone: "us-east-1a",
+            blueprintId: "amazon_linux_2",
+            bundleId: "nano_2_0",
+        }, "test-instance");
+    }
+
+    it("Should fail if an instance uses the default firewall", async () => {
+        const args = createStackValidationArgsWithResources([createInstance()]);
+        await assertHasStackViolation(policy, args, {
+            message: "Lightsail instance 'test-instance' uses the default firewall, which allows SSH from any address. " +
+                "Manage its ports with an aws.lightsail.InstancePublicPorts resource.",
+        });
+    });
+
+    it("Should fail if an instance opens other ports to any address", async () => {
+        const instance = createInstance();
+        const ports = createPolicyResource(aws.lightsail.InstancePublicPorts, {
+            instanceName: "web",
+            portInfos: [
+                { protocol: "tcp", fromPort: 443, toPort: 443 },
+                { protocol: "tcp", fromPort: 22, toPort: 22, cidrs: ["0.0.0.0/0"] },
+            ],
+        }, "test-ports");
+        await assertHasStackViolation(policy, createStackValidationArgsWithResources([instance, ports]), {
+            message: "Lightsail instance 'test-instance' should not open port 22 to any address.",
+        });
+
+        ports.props.portInfos.push({ protocol: "tcp", fromPort: 3389, toPort: 3389 });
+        await assertHasStackViolation(policy, createStackValidationArgsWithResources([instance, ports]), {
+            message: "Lightsail instance 'test-instance' should not open port 22 to any address. " +
+                "Lightsail instance 'test-instance' should not open port 3389 to any address.",
+        });
+
+        ports.props.portInfos = [{ protocol: "all", fromPort: 0, toPort: 65535, ipv6Cidrs: ["::/0"] }];
+        await assertHasStackViolation(policy, createStackValidationArgsWithResources([instance, ports]), {
+            message: "Lightsail instance 'test-instance' should not open ports 0-65535 to any address.",
+        });
+    });
+
+    it("Should pass if an instance only opens allowed ports to any address", async () => {
+        const instance = createInstance();
+        const ports = createPolicyResource(aws.lightsail.InstancePublicPorts, {
+            portInfos: [
+                { protocol: "tcp", fromPort: 80, toPort: 80 },
+                { protocol: "tcp", fromPort: 22, toPort: 22, cidrs: ["203.0.113.0/24"] },
+            ],
+        }, "test-ports", { instanceName: [instance] });
+        await assertNoStackViolations(policy, createStackValidationArgsWithResources([instance, ports]));
+
+        ports.props.portInfos = [{ protocol: "tcp", fromPort: 8080, toPort: 8080 }];
+        const args = createStackValidationArgsWithResources([instance, ports], { allowedPublicPorts: [8080] });
+        await assertNoStackViolations(policy, args);
+    });
+
+    it("Should fail if a database is publicly accessible", async () => {
+        const database = createPolicyResource(aws.lightsail.Database, {
+            relationalDatabaseName: "app",
+            publiclyAccessible: true,
+        }, "test-database");
+        await assertHasStackViolation(policy, createStackValidationArgsWithResources([database]), {
+            message: "Lightsail database 'test-database' should not be publicly accessible.",
+        });
+
+        database.props.publiclyAccessible = false;
+        await assertNoStackViolations(policy, createStackValidationArgsWithResources([database]));
+    });
+});
+
+describe("#lightsailAutomaticSnapshots", () => {
+    const policy = lightsail.lightsailAutomaticSnapshots;
+
+    function getInstanceArgs(addOn?: any) {
+        return createResourceValidationArgs(aws.lightsail.Instance, {
+            availabilityZone: "us-east-1a",
+            blueprintId: "amazon_linux_2",
+            bundleId: "nano_2_0",
+            addOn,
+        });
+    }
+
+    it("Should fail if an instance doesn't have automatic snapshots enabled", async () => {
+        const message = "Lightsail instance 'unknown' should have automatic snapshots enabled.";
+        await assertHasResourceViolation(policy, getInstanceArgs(), { message });
+        await assertHasResourceViolation(policy, getInstanceArgs({
+            type: "AutoSnapshot",
+            snapshotTime: "06:00",
+            status: "Disabled",
+        }), { message });
+    });
+
+    it("Should pass if an instance has automatic snapshots enabled", async () => {
+        await assertNoResourceViolations(policy, getInstanceArgs({
+            type: "AutoSnapshot",
+            snapshotTime: "06:00",
+            status: "Enabled",
+        }));
+    });
+
+    it("Should fail if a database doesn't retain automated backups", async () => {
+        const props = { relationalDatabaseName: "app", backupRetentionEnabled: false };
+        await assertHasResourceViolation(policy, createResourceValidationArgs(aws.lightsail.Database, props), {
+            message: "Lightsail database 'unknown' should retain automated backups.",
+        });
+
+        props.backupRetentionEnabled = true;
+        await assertNoResourceViolations(policy, createResourceValidationArgs(aws.lightsail.Database, props));
+    });
+});
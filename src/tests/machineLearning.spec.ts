@@ -0,0 +1,96 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import "mocha";
+
+import * as aws from "@pulumi/aws";
+import { ResourceValidationArgs } from "@pulumi/policy";
+
+import * as machineLearning from "../machineLearning";
+
+import { assertHasResourceViolation, assertNoResourceViolations, createResourceValidationArgs } from "./util";
+
+describe("#sagemakerNotebookNoDirectInternet", () => {
+    const policy = machineLearning.sagemakerNotebookNoDirectInternet;
+
+    function getHappyPathArgs(): ResourceValidationArgs {
+        return createResourceValidationArgs(aws.sagemaker.NotebookInstance, {
+            instanceType: "ml.t2.medium",
+            roleArn: "arn:aws:iam::123456789012:role/sagemaker",
+            directInternetAccess: "Disabled",
+            rootAccess: "Disabled",
+            kmsKeyId: "test-key-id",
+        }, { requireRootAccessDisabled: true, requireKmsKey: true });
+    }
+
+    it("Should pass if the notebook instance is configured properly", async () => {
+        const args = getHappyPathArgs();
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should fail if direct internet access is enabled", async () => {
+        const args = getHappyPathArgs();
+        args.props.directInternetAccess = "Enabled";
+
+        await assertHasResourceViolation(policy, args, { message: "must not have direct internet access." });
+    });
+
+    it("Should fail if direct internet access is unspecified", async () => {
+        const args = getHappyPathArgs();
+        args.props.directInternetAccess = undefined;
+
+        await assertHasResourceViolation(policy, args, { message: "must not have direct internet access." });
+    });
+
+    it("Should fail if root access is enabled", async () => {
+        const args = getHappyPathArgs();
+        args.props.rootAccess = "Enabled";
+
+        await assertHasResourceViolation(policy, args, { message: "must have root access disabled." });
+    });
+
+    it("Should fail if no KMS key is specified", async () => {
+        const args = getHappyPathArgs();
+        args.props.kmsKeyId = undefined;
+
+        await assertHasResourceViolation(policy, args, { message: "must be encrypted with a KMS key." });
+    });
+});
+
+describe("#sagemakerEndpointConfigEncryption", () => {
+    const policy = machineLearning.sagemakerEndpointConfigEncryption;
+
+    function getHappyPathArgs(): ResourceValidationArgs {
+        return createResourceValidationArgs(aws.sagemaker.EndpointConfiguration, {
+            productionVariants: [{
+                modelName: "test-model",
+                initialInstanceCount: 1,
+                instanceType: "ml.t2.medium",
+            }],
+            kmsKeyArn: "arn:aws:kms:us-west-2:123456789012:key/test-key-id",
+        });
+    }
+
+    it("Should pass if the endpoint configuration has a KMS key", async () => {
+        const args = getHappyPathArgs();
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should fail if the endpoint configuration has no KMS key", async () => {
+        const args = getHappyPathArgs();
+        args.props.kmsKeyArn = undefined;
+
+        await assertHasResourceViolation(policy, args, { message: "must be encrypted with a KMS key." });
+    });
+});
@@ -0,0 +1,146 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+
+import "mocha";
+
+import * as aws from "@pulumi/aws";
+
+import * as management from "../management";
+
+import {
+    assertHasResourceViolation,
+    assertHasStackViolation,
+    assertNoResourceViolations,
+    assertNoStackViolations,
+    createPolicyResource,
+    createResourceValidationArgs,
+    createStackValidationArgsWithResources,
+} from "./util";
+
+describe("#noInlineCloudformation", () => {
+    const policy = management.noInlineCloudformation;
+
+    it("Should report CloudFormation stacks", async () => {
+        const args = createResourceValidationArgs(aws.cloudformation.Stack, { templateUrl: "https://example.com/template.json" });
+        await assertHasResourceViolation(policy, args, {
+            message: "CloudFormation stack 'unknown' should be managed directly with Pulumi resources " +
+                "rather than deployed from the Pulumi program.",
+        });
+    });
+});
+
+describe("#deletionProtectionRequired", () => {
+    const policy = management.deletionProtectionRequired;
+    const config = { resourceTypes: ["rds", "dynamodb", "elb"] };
+
+    it("Should report RDS DB instances without deletion protection", async () => {
+        const args = createResourceValidationArgs(aws.rds.Instance, { instanceClass: "db.t3.micro" }, config);
+        await assertHasResourceViolation(policy, args, {
+            message: "RDS DB instance 'unknown' (aws:rds/instance:Instance) must have deletion protection enabled " +
+                "by setting 'deletionProtection' to true.",
+        });
+    });
+
+    it("Should report DynamoDB tables without deletion protection", async () => {
+        const args = createResourceValidationArgs(aws.dynamodb.Table,
+            { attributes: [], hashKey: "id", deletionProtectionEnabled: false }, config);
+        await assertHasResourceViolation(policy, args, {
+            message: "DynamoDB table 'unknown' (aws:dynamodb/table:Table) must have deletion protection enabled " +
+                "by setting 'deletionProtectionEnabled' to true.",
+        });
+    });
+
+    it("Should report load balancers without deletion protection", async () => {
+        const args = createResourceValidationArgs(aws.lb.LoadBalancer, {}, config);
+        await assertHasResourceViolation(policy, args, {
+            message: "Load balancer 'unknown' (aws:lb/loadBalancer:LoadBalancer) must have deletion protection enabled " +
+                "by setting 'enableDeletionProtection' to true.",
+        });
+
+        const elbV2Args = createResourceValidationArgs(aws.elasticloadbalancingv2.LoadBalancer, {}, config);
+        await assertHasResourceViolation(policy, elbV2Args, {
+            message: "Load balancer 'unknown' (aws:elasticloadbalancingv2/loadBalancer:LoadBalancer) must have " +
+                "deletion protection enabled by setting 'enableDeletionProtection' to true.",
+        });
+    });
+
+    it("Should not report resources with deletion protection", async () => {
+        await assertNoResourceViolations(policy,
+            createResourceValidationArgs(aws.rds.Cluster, { deletionProtection: true }, config));
+        await assertNoResourceViolations(policy,
+            createResourceValidationArgs(aws.lb.LoadBalancer, { enableDeletionProtection: true }, config));
+        await assertNoResourceViolations(policy,
+            createResourceValidationArgs(aws.elasticloadbalancingv2.LoadBalancer,
+                { enableDeletionProtection: true }, config));
+    });
+
+    it("Should only check the configured resource types", async () => {
+        const ec2Config = { resourceTypes: ["ec2"] };
+        await assertNoResourceViolations(policy,
+            createResourceValidationArgs(aws.rds.Instance, { instanceClass: "db.t3.micro" }, ec2Config));
+        await assertHasResourceViolation(policy,
+            createResourceValidationArgs(aws.ec2.Instance, { ami: "ami-123", instanceType: "t3.micro" }, ec2Config), {
+                message: "EC2 instance 'unknown' (aws:ec2/instance:Instance) must have deletion protection enabled " +
+                    "by setting 'disableApiTermination' to true.",
+            });
+    });
+
+    it("Should not check any resource types by default", async () => {
+        await assertNoResourceViolations(policy,
+            createResourceValidationArgs(aws.rds.Instance, { instanceClass: "db.t3.micro" }));
+        await assertNoResourceViolations(policy,
+            createResourceValidationArgs(aws.lb.LoadBalancer, {}));
+        await assertNoResourceViolations(policy,
+            createResourceValidationArgs(aws.ec2.Instance, { ami: "ami-123", instanceType: "t3.micro" }));
+    });
+});
+
+describe("#explicitProviderRegion", () => {
+    const policy = management.explicitProviderRegion;
+
+    function createBucket(name: string, providerName: string, region?: string) {
+        const bucket = createPolicyResource(aws.s3.Bucket, {}, name);
+        bucket.provider = {
+            type: "pulumi:providers:aws",
+            props: region ? { region } : {},
+            urn: `urn:pulumi:test::test::pulumi:providers:aws::${providerName}`,
+            name: providerName,
+        };
+        return bucket;
+    }
+
+    it("Should warn once per provider that doesn't set a region", async () => {
+        const args = createStackValidationArgsWithResources([
+            createBucket("logs", "default_5_0_0"),
+            createBucket("assets", "default_5_0_0"),
+            createBucket("replica", "eu", "eu-west-1"),
+        ]);
+        await assertHasStackViolation(policy, args, {
+            message: "2 resource(s), e.g. 'logs', use the default AWS provider, which doesn't set a region.",
+        });
+
+        await assertHasStackViolation(policy, createStackValidationArgsWithResources([createBucket("logs", "us")]), {
+            message: "1 resource(s), e.g. 'logs', use the AWS provider 'us', which doesn't set a region.",
+        });
+    });
+
+    it("Should pass if every provider sets a region", async () => {
+        const args = createStackValidationArgsWithResources([
+            createBucket("logs", "default_5_0_0", "us-west-2"),
+            createBucket("replica", "eu", "eu-west-1"),
+        ]);
+        await assertNoStackViolations(policy, args);
+    });
+});
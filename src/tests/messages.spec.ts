@@ -0,0 +1,136 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import * as assert from "assert";
+
+import "mocha";
+
+import * as aws from "@pulumi/aws";
+import { ResourceValidationPolicy, StackValidationPolicy } from "@pulumi/policy";
+
+import { getControlIds } from "../controls";
+import { formatViolationMessage, groupedByResource, withResourceUrns } from "../messages";
+
+import { createResourceValidationArgs, createStackValidationArgs } from "./util";
+
+const urn = "urn:pulumi:test::test::my:component:Component$aws:ec2/securityGroupRule:SecurityGroupRule::test-sg-rule";
+
+describe("#formatViolationMessage", () => {
+    it("appends the URN to the message", () => {
+        assert.strictEqual(
+            formatViolationMessage("Security group rule 'test-sg-rule' is too permissive.", urn),
+            `Security group rule 'test-sg-rule' is too permissive. (URN: ${urn})`);
+    });
+
+    it("leaves the message unchanged if the URN is unknown or already included", () => {
+        assert.strictEqual(formatViolationMessage("A violation.", undefined), "A violation.");
+        assert.strictEqual(formatViolationMessage(`Resource ${urn} is invalid.`, urn), `Resource ${urn} is invalid.`);
+    });
+
+    it("appends compliance control IDs before the URN", () => {
+        assert.strictEqual(
+            formatViolationMessage("A violation.", urn, ["CIS 2.3.1", "PCI 3.4"]),
+            `A violation. [Controls: CIS 2.3.1, PCI 3.4] (URN: ${urn})`);
+        assert.strictEqual(
+            formatViolationMessage("A violation.", undefined, ["PCI 4.1"]), "A violation. [Controls: PCI 4.1]");
+        assert.strictEqual(formatViolationMessage("A violation.", undefined, []), "A violation.");
+    });
+});
+
+describe("#withResourceUrns", () => {
+    it("adds the URN of the resource being validated to resource policy violations", async () => {
+        const policy: ResourceValidationPolicy = {
+            name: "test-resource-policy",
+            description: "Test policy.",
+            validateResource: (_, reportViolation) => reportViolation("A violation."),
+        };
+
+        const reported: string[] = [];
+        const wrapped = <ResourceValidationPolicy>withResourceUrns(policy);
+        const args = createResourceValidationArgs(aws.ec2.SecurityGroupRule, {
+            type: "ingress", fromPort: 22, toPort: 22, protocol: "tcp", securityGroupId: "sg-1234",
+        });
+        args.urn = urn;
+        for (const validation of Array.isArray(wrapped.validateResource) ? wrapped.validateResource : [wrapped.validateResource]) {
+            await validation(args, message => reported.push(message));
+        }
+
+        assert.deepStrictEqual(reported, [`A violation. (URN: ${urn})`]);
+    });
+
+    it("adds the reported URN to stack policy violations", async () => {
+        const policy: StackValidationPolicy = {
+            name: "test-stack-policy",
+            description: "Test policy.",
+            validateStack: (_, reportViolation) => {
+                reportViolation("A resource violation.", urn);
+                reportViolation("A stack violation.");
+            },
+        };
+
+        const reported: string[] = [];
+        const wrapped = <StackValidationPolicy>withResourceUrns(policy);
+        await wrapped.validateStack(createStackValidationArgs(aws.s3.Bucket, {}), message => reported.push(message));
+
+        assert.deepStrictEqual(reported, [`A resource violation. (URN: ${urn})`, "A stack violation."]);
+    });
+
+    it("adds the policy's compliance control IDs to violations", async () => {
+        const policy: StackValidationPolicy = {
+            name: "rds-storage-encrypted",
+            description: "Test policy.",
+            validateStack: (_, reportViolation) => {
+                reportViolation("A resource violation.", urn);
+                reportViolation("A stack violation.");
+            },
+        };
+
+        const reported: string[] = [];
+        const wrapped = <StackValidationPolicy>withResourceUrns(policy, getControlIds(policy.name));
+        await wrapped.validateStack(createStackValidationArgs(aws.s3.Bucket, {}), message => reported.push(message));
+
+        assert.deepStrictEqual(reported, [
+            `A resource violation. [Controls: CIS 2.3.1, PCI 3.4] (URN: ${urn})`,
+            "A stack violation. [Controls: CIS 2.3.1, PCI 3.4]",
+        ]);
+    });
+});
+
+describe("#getControlIds", () => {
+    it("returns the controls a policy maps to, if any", () => {
+        assert.deepStrictEqual(getControlIds("mfa-enabled-for-iam-console-access"), ["CIS 1.10", "PCI 8.3.1"]);
+        assert.deepStrictEqual(getControlIds("nat-gateway-cost"), []);
+    });
+});
+
+describe("#groupedByResource", () => {
+    it("combines the violations of each resource into one", async () => {
+        const other = "urn:pulumi:test::test::aws:s3/bucket:Bucket::test-bucket";
+        const validation = groupedByResource((_, reportViolation) => {
+            reportViolation("First problem.", urn);
+            reportViolation("A bucket problem.", other);
+            reportViolation("A stack problem.");
+            reportViolation("Second problem.", urn);
+        });
+
+        const reported: [string, string | undefined][] = [];
+        await validation(createStackValidationArgs(aws.s3.Bucket, {}), (message, u) => reported.push([message, u]));
+
+        assert.deepStrictEqual(reported, [
+            ["A stack problem.", undefined],
+            ["First problem. Second problem.", urn],
+            ["A bucket problem.", other],
+        ]);
+    });
+});
@@ -12,13 +12,19 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+import * as assert from "assert";
+
 import "mocha";
 
 import * as aws from "@pulumi/aws";
 
 import * as network from "../network";
 
-import { assertHasResourceViolation, assertNoResourceViolations, createResourceValidationArgs } from "./util";
+import {
+    assertHasResourceViolation, assertHasStackViolation,
+    assertNoResourceViolations, assertNoStackViolations,
+    createPolicyResource, createResourceValidationArgs, createStackValidationArgsWithResources,
+} from "./util";
 
 describe("#albHttpToHttpsRedirection", () => {
     const policy = network.albHttpToHttpsRedirection;
@@ -117,3 +123,292 @@ describe("#albHttpToHttpsRedirection", () => {
         }
     });
 });
+
+describe("#securityGroupRestrictedIngress", () => {
+    const policy = network.securityGroupRestrictedIngress;
+    const config = {
+        restrictedPorts: [22, 3389],
+        allowedCidrs: ["10.0.0.0/8", "203.0.113.0/24"],
+    };
+
+    function getSecurityGroupArgs(cidrBlocks: string[], fromPort: number = 22, toPort: number = 22) {
+        return createResourceValidationArgs(aws.ec2.SecurityGroup, {
+            ingress: [{ protocol: "tcp", fromPort, toPort, cidrBlocks }],
+        }, config);
+    }
+
+    it("Reports no violations for allowed CIDR blocks", async () => {
+        await assertNoResourceViolations(policy, getSecurityGroupArgs(["10.0.0.0/8"]));
+        await assertNoResourceViolations(policy, getSecurityGroupArgs(["203.0.113.10/32"]));
+    });
+
+    it("Reports no violations for CIDR blocks contained in an allowed block", async () => {
+        await assertNoResourceViolations(policy, getSecurityGroupArgs(["10.1.2.0/24"]));
+    });
+
+    it("Reports no violations for unrestricted ports", async () => {
+        await assertNoResourceViolations(policy, getSecurityGroupArgs(["0.0.0.0/0"], 443, 443));
+    });
+
+    it("Reports a violation for restricted ports open to disallowed CIDR blocks", async () => {
+        await assertHasResourceViolation(policy, getSecurityGroupArgs(["0.0.0.0/0"]), {
+            message: "must not allow ingress on port(s) 22 from '0.0.0.0/0', which is not an allowed CIDR block.",
+        });
+        // The block is wider than the allowed block, so it's not contained within it.
+        await assertHasResourceViolation(policy, getSecurityGroupArgs(["10.0.0.0/7"]), { message: "from '10.0.0.0/7'" });
+    });
+
+    it("Reports a violation for port ranges and protocols that include restricted ports", async () => {
+        await assertHasResourceViolation(policy, getSecurityGroupArgs(["0.0.0.0/0"], 0, 65535), {
+            message: "on port(s) 22, 3389 from '0.0.0.0/0'",
+        });
+
+        const args = createResourceValidationArgs(aws.ec2.SecurityGroup, {
+            ingress: [{ protocol: "-1", fromPort: 0, toPort: 0, ipv6CidrBlocks: ["::/0"] }],
+        }, config);
+        await assertHasResourceViolation(policy, args, { message: "from '::/0'" });
+    });
+
+    it("Checks standalone ingress rules", async () => {
+        const ingressArgs = createResourceValidationArgs(aws.ec2.SecurityGroupRule, {
+            type: "ingress",
+            securityGroupId: "sg-12345678",
+            protocol: "tcp",
+            fromPort: 3389,
+            toPort: 3389,
+            cidrBlocks: ["198.51.100.0/24"],
+        }, config);
+        await assertHasResourceViolation(policy, ingressArgs, { message: "on port(s) 3389 from '198.51.100.0/24'" });
+
+        const egressArgs = createResourceValidationArgs(aws.ec2.SecurityGroupRule, {
+            type: "egress",
+            securityGroupId: "sg-12345678",
+            protocol: "tcp",
+            fromPort: 22,
+            toPort: 22,
+            cidrBlocks: ["0.0.0.0/0"],
+        }, config);
+        await assertNoResourceViolations(policy, egressArgs);
+    });
+});
+
+describe("#natGatewayCost", () => {
+    const policy = network.natGatewayCost;
+    const config = { maxNatGateways: 2, requireMultipleAzs: true };
+
+    function getResources(...zones: string[]) {
+        const resources = [];
+        for (let i = 0; i < zones.length; i++) {
+            const subnet = createPolicyResource(aws.ec2.Subnet, {
+                vpcId: "vpc-1234", cidrBlock: `10.0.${i}.0/24`, availabilityZone: zones[i],
+            }, `test-subnet-${i}`);
+            const natGateway = createPolicyResource(aws.ec2.NatGateway, {}, `test-nat-${i}`, { subnetId: [subnet] });
+            resources.push(subnet, natGateway);
+        }
+        return resources;
+    }
+
+    it("Should pass if NAT gateways are within the threshold and spread across AZs", async () => {
+        const args = createStackValidationArgsWithResources(getResources("us-west-2a", "us-west-2b"), config);
+        await assertNoStackViolations(policy, args);
+    });
+
+    it("Should fail if there are more NAT gateways than the threshold", async () => {
+        const args = createStackValidationArgsWithResources(
+            getResources("us-west-2a", "us-west-2b", "us-west-2c"), config);
+        await assertHasStackViolation(policy, args, {
+            message: "Stack has 3 NAT gateways, which exceeds the threshold of 2.",
+        });
+    });
+
+    it("Should fail if all NAT gateways are in one AZ", async () => {
+        const args = createStackValidationArgsWithResources(getResources("us-west-2a", "us-west-2a"), config);
+        await assertHasStackViolation(policy, args, {
+            message: "Stack has 2 NAT gateway(s), all in Availability Zone 'us-west-2a'.",
+        });
+    });
+
+    it("Should not check AZs if they can't be determined, or the check is disabled", async () => {
+        await assertNoStackViolations(policy, createStackValidationArgsWithResources([
+            createPolicyResource(aws.ec2.NatGateway, { subnetId: "subnet-1234" }, "test-nat"),
+        ], config));
+        await assertNoStackViolations(policy, createStackValidationArgsWithResources(
+            getResources("us-west-2a"), { maxNatGateways: 2, requireMultipleAzs: false }));
+    });
+
+    it("Should not check AZs by default", async () => {
+        await assertNoStackViolations(policy, createStackValidationArgsWithResources(getResources("us-west-2a")));
+    });
+});
+
+describe("#eipAttached", () => {
+    const policy = network.eipAttached;
+
+    it("Should pass if the EIP is associated with an instance or network interface", async () => {
+        const args = createStackValidationArgsWithResources([
+            createPolicyResource(aws.ec2.Eip, { instance: "i-1234" }, "test-instance-eip"),
+            createPolicyResource(aws.ec2.Eip, { networkInterface: "eni-1234" }, "test-eni-eip"),
+        ]);
+        await assertNoStackViolations(policy, args);
+    });
+
+    it("Should pass if an association or NAT gateway refers to the EIP", async () => {
+        const eip = createPolicyResource(aws.ec2.Eip, { vpc: true }, "test-eip");
+        const natEip = createPolicyResource(aws.ec2.Eip, { vpc: true }, "test-nat-eip");
+        const args = createStackValidationArgsWithResources([
+            eip,
+            natEip,
+            createPolicyResource(aws.ec2.EipAssociation, { instanceId: "i-1234" }, "test-association", { allocationId: [eip] }),
+            createPolicyResource(aws.ec2.NatGateway, { subnetId: "subnet-1234" }, "test-nat", { allocationId: [natEip] }),
+        ]);
+        await assertNoStackViolations(policy, args);
+    });
+
+    it("Should fail if the EIP is not associated", async () => {
+        const args = createStackValidationArgsWithResources([
+            createPolicyResource(aws.ec2.Eip, { vpc: true }, "test-eip"),
+        ]);
+        await assertHasStackViolation(policy, args, {
+            message: "Elastic IP 'test-eip' is not associated with an instance, network interface, or NAT gateway in the stack.",
+        });
+    });
+});
+
+describe("#globalacceleratorFlowLogs", () => {
+    const policy = network.globalacceleratorFlowLogs;
+
+    it("Should fail if flow logs are disabled", async () => {
+        const args = createResourceValidationArgs(aws.globalaccelerator.Accelerator, { ipAddressType: "IPV4" });
+        await assertHasResourceViolation(policy, args, {
+            message: "Global Accelerator 'unknown' should have flow logs enabled.",
+        });
+
+        args.props.attributes = { flowLogsEnabled: false };
+        await assertHasResourceViolation(policy, args, {
+            message: "Global Accelerator 'unknown' should have flow logs enabled.",
+        });
+    });
+
+    it("Should fail if flow logs have no S3 bucket", async () => {
+        const args = createResourceValidationArgs(aws.globalaccelerator.Accelerator, {
+            attributes: { flowLogsEnabled: true },
+        });
+        await assertHasResourceViolation(policy, args, {
+            message: "Global Accelerator 'unknown' has flow logs enabled but no S3 bucket to deliver them to.",
+        });
+    });
+
+    it("Should pass if flow logs are enabled", async () => {
+        const args = createResourceValidationArgs(aws.globalaccelerator.Accelerator, {
+            attributes: { flowLogsEnabled: true, flowLogsS3Bucket: "flow-logs", flowLogsS3Prefix: "accelerator/" },
+        });
+        await assertNoResourceViolations(policy, args);
+    });
+});
+
+describe("#securityGroupNoRuleManagementConflicts", () => {
+    const policy = network.securityGroupNoRuleManagementConflicts;
+    const inlineRule = { protocol: "tcp", fromPort: 443, toPort: 443, cidrBlocks: ["10.0.0.0/8"] };
+
+    function createResources(inline: Record<string, any>, ruleType: string, groupName = "test-sg") {
+        const securityGroup = createPolicyResource(aws.ec2.SecurityGroup, inline, groupName);
+        const rule = createPolicyResource(aws.ec2.SecurityGroupRule, {
+            type: ruleType, protocol: "tcp", fromPort: 22, toPort: 22, cidrBlocks: ["10.0.0.0/8"],
+        }, "test-rule", { securityGroupId: [securityGroup] });
+        return [securityGroup, rule];
+    }
+
+    it("Should fail if a security group has inline rules and standalone rules", async () => {
+        const args = createStackValidationArgsWithResources(createResources({ ingress: [inlineRule] }, "egress"));
+        await assertHasStackViolation(policy, args, {
+            message: "Security group 'test-sg' has inline rules and is also managed by standalone security group " +
+                "rule(s) 'test-rule'. Use either inline rules or standalone rules, as each removes the other's rules on update.",
+        });
+    });
+
+    it("Should report each security group once", async () => {
+        const [securityGroup, rule] = createResources({ ingress: [inlineRule] }, "ingress");
+        const otherRule = createPolicyResource(aws.ec2.SecurityGroupRule, {
+            type: "egress", protocol: "-1", fromPort: 0, toPort: 0, cidrBlocks: ["0.0.0.0/0"],
+        }, "other-rule", { securityGroupId: [securityGroup] });
+
+        const violations: string[] = [];
+        await policy.validateStack(createStackValidationArgsWithResources([securityGroup, rule, otherRule]),
+            message => violations.push(message));
+        assert.deepStrictEqual(violations, [
+            "Security group 'test-sg' has inline rules and is also managed by standalone security group rule(s) " +
+            "'test-rule', 'other-rule'. Use either inline rules or standalone rules, as each removes the other's " +
+            "rules on update.",
+        ]);
+    });
+
+    it("Should pass if a security group only has standalone rules", async () => {
+        const args = createStackValidationArgsWithResources(createResources({}, "ingress"));
+        await assertNoStackViolations(policy, args);
+    });
+
+    it("Should allow inline and standalone rules in separate directions, if configured", async () => {
+        const config = { allowSeparateDirections: true };
+        await assertNoStackViolations(policy,
+            createStackValidationArgsWithResources(createResources({ ingress: [inlineRule] }, "egress"), config));
+        await assertHasStackViolation(policy,
+            createStackValidationArgsWithResources(createResources({ ingress: [inlineRule] }, "ingress"), config), {
+                message: "Security group 'test-sg' has inline rules and is also managed by standalone security group rule(s) 'test-rule'.",
+            });
+    });
+
+    it("Should only check the configured security groups", async () => {
+        const config = { securityGroupNames: ["web-sg"] };
+        await assertNoStackViolations(policy,
+            createStackValidationArgsWithResources(createResources({ ingress: [inlineRule] }, "ingress"), config));
+        await assertHasStackViolation(policy,
+            createStackValidationArgsWithResources(createResources({ ingress: [inlineRule] }, "ingress", "web-sg"), config), {
+                message: "Security group 'web-sg' has inline rules",
+            });
+    });
+});
+
+describe("#route53DnssecEnabled", () => {
+    const policy = network.route53DnssecEnabled;
+
+    function createZone() {
+        return createPolicyResource(aws.route53.Zone, { name: "example.com" }, "zone");
+    }
+
+    it("Should warn if a public zone isn't signed", async () => {
+        await assertHasStackViolation(policy, createStackValidationArgsWithResources([createZone()]), {
+            message: "Route 53 hosted zone 'example.com' should have DNSSEC signing enabled. " +
+                "Add an 'aws.route53.KeySigningKey' and an 'aws.route53.HostedZoneDnsSec' resource for it.",
+        });
+
+        const zone = createZone();
+        const ksk = createPolicyResource(aws.route53.KeySigningKey, {
+            keyManagementServiceArn: "arn:aws:kms:us-east-1:123456789012:key/1234",
+        }, "ksk", { hostedZoneId: [zone] });
+        await assertHasStackViolation(policy, createStackValidationArgsWithResources([zone, ksk]), {
+            message: "It has a key-signing key, but no 'aws.route53.HostedZoneDnsSec' resource signing the zone.",
+        });
+
+        const notSigning = createPolicyResource(aws.route53.HostedZoneDnsSec, {
+            signingStatus: "NOT_SIGNING",
+        }, "dnssec", { hostedZoneId: [zone] });
+        await assertHasStackViolation(policy, createStackValidationArgsWithResources([zone, ksk, notSigning]), {
+            message: "Route 53 hosted zone 'example.com' should have DNSSEC signing enabled.",
+        });
+    });
+
+    it("Should pass if the zone is signed", async () => {
+        const zone = createZone();
+        zone.props.zoneId = "Z1234567890";
+        const dnssec = createPolicyResource(aws.route53.HostedZoneDnsSec, { hostedZoneId: "Z1234567890" }, "dnssec");
+        await assertNoStackViolations(policy, createStackValidationArgsWithResources([zone, dnssec]));
+    });
+
+    it("Should ignore private zones", async () => {
+        const zone = createPolicyResource(aws.route53.Zone, {
+            name: "internal.example.com",
+            vpcs: [{ vpcId: "vpc-1234" }],
+        }, "private-zone");
+        await assertNoStackViolations(policy, createStackValidationArgsWithResources([zone]));
+    });
+});
@@ -0,0 +1,134 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import * as assert from "assert";
+
+import "mocha";
+
+import * as aws from "@pulumi/aws";
+import { ResourceValidationPolicy, StackValidationPolicy } from "@pulumi/policy";
+
+import { appliesAt, getPartitionOfRegion, getResourceLocation, withRegionAvailability } from "../region";
+
+import { createPolicyResource, createResourceValidationArgs, createStackValidationArgsWithResources } from "./util";
+
+const govCloudArn = "arn:aws-us-gov:lightsail:us-gov-west-1:123456789012:Instance/abc";
+const commercialArn = "arn:aws:lightsail:us-east-1:123456789012:Instance/abc";
+
+describe("#getPartitionOfRegion", () => {
+    it("returns the partition of each region", () => {
+        assert.strictEqual(getPartitionOfRegion("us-east-1"), "aws");
+        assert.strictEqual(getPartitionOfRegion("us-gov-west-1"), "aws-us-gov");
+        assert.strictEqual(getPartitionOfRegion("cn-north-1"), "aws-cn");
+        assert.strictEqual(getPartitionOfRegion("us-iso-east-1"), "aws-iso");
+        assert.strictEqual(getPartitionOfRegion("us-isob-east-1"), "aws-iso-b");
+    });
+});
+
+describe("#getResourceLocation", () => {
+    const envVars = ["AWS_REGION", "AWS_DEFAULT_REGION"];
+    const saved: Record<string, string | undefined> = {};
+    beforeEach(() => {
+        for (const envVar of envVars) {
+            saved[envVar] = process.env[envVar];
+            delete process.env[envVar];
+        }
+    });
+    afterEach(() => {
+        for (const envVar of envVars) {
+            if (saved[envVar] === undefined) {
+                delete process.env[envVar];
+            } else {
+                process.env[envVar] = saved[envVar];
+            }
+        }
+    });
+
+    it("prefers the resource's ARN", () => {
+        process.env.AWS_REGION = "us-east-1";
+        assert.deepStrictEqual(getResourceLocation({ arn: govCloudArn }),
+            { partition: "aws-us-gov", region: "us-gov-west-1" });
+    });
+
+    it("falls back to the provider's region, then the environment", () => {
+        const provider = { type: "pulumi:providers:aws", props: { region: "cn-north-1" }, urn: "provider", name: "china" };
+        process.env.AWS_DEFAULT_REGION = "us-gov-east-1";
+        assert.deepStrictEqual(getResourceLocation({}, provider), { partition: "aws-cn", region: "cn-north-1" });
+        assert.deepStrictEqual(getResourceLocation({}), { partition: "aws-us-gov", region: "us-gov-east-1" });
+    });
+
+    it("returns an unknown location if there is no region", () => {
+        assert.deepStrictEqual(getResourceLocation({}), { partition: undefined, region: undefined });
+    });
+});
+
+describe("#appliesAt", () => {
+    it("applies only in the given partitions and regions", () => {
+        assert.strictEqual(appliesAt({ partitions: ["aws"] }, { partition: "aws", region: "us-east-1" }), true);
+        assert.strictEqual(appliesAt({ partitions: ["aws"] }, { partition: "aws-us-gov", region: "us-gov-west-1" }), false);
+        assert.strictEqual(appliesAt({ regions: ["us-east-1"] }, { partition: "aws", region: "eu-west-1" }), false);
+    });
+
+    it("applies where the location is unknown", () => {
+        assert.strictEqual(appliesAt({ partitions: ["aws"], regions: ["us-east-1"] }, {}), true);
+    });
+});
+
+describe("#withRegionAvailability", () => {
+    const availability = { partitions: ["aws"] };
+
+    it("skips resource validations for resources in other partitions, logging the skip", async () => {
+        const logged: string[] = [];
+        const policy: ResourceValidationPolicy = {
+            name: "test-resource-policy",
+            description: "Test policy.",
+            validateResource: (_, reportViolation) => reportViolation("A violation."),
+        };
+        const wrapped = <ResourceValidationPolicy>withRegionAvailability(policy, availability, message => logged.push(message));
+        const validate = async (arn: string) => {
+            const reported: string[] = [];
+            const args = createResourceValidationArgs(aws.lightsail.Instance, <any>{ arn });
+            for (const validation of Array.isArray(wrapped.validateResource) ? wrapped.validateResource : [wrapped.validateResource]) {
+                await validation(args, message => reported.push(message));
+            }
+            return reported;
+        };
+
+        assert.deepStrictEqual(await validate(commercialArn), ["A violation."]);
+        assert.deepStrictEqual(await validate(govCloudArn), []);
+        assert.deepStrictEqual(await validate(govCloudArn), []);
+        assert.deepStrictEqual(logged,
+            ["awsguard: skipping test-resource-policy in us-gov-west-1, aws-us-gov, where it doesn't apply"]);
+    });
+
+    it("drops stack violations of resources in other partitions", async () => {
+        const commercial = createPolicyResource(aws.lightsail.Instance, { arn: commercialArn }, "commercial");
+        const govCloud = createPolicyResource(aws.lightsail.Instance, { arn: govCloudArn }, "gov-cloud");
+        const policy: StackValidationPolicy = {
+            name: "test-stack-policy",
+            description: "Test policy.",
+            validateStack: (args, reportViolation) => {
+                for (const resource of args.resources) {
+                    reportViolation(`'${resource.name}' is invalid.`, resource.urn);
+                }
+            },
+        };
+
+        const reported: string[] = [];
+        const wrapped = <StackValidationPolicy>withRegionAvailability(policy, availability, () => undefined);
+        await wrapped.validateStack(createStackValidationArgsWithResources([commercial, govCloud]), message => reported.push(message));
+
+        assert.deepStrictEqual(reported, ["'commercial' is invalid."]);
+    });
+});
@@ -0,0 +1,93 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import * as assert from "assert";
+import * as fs from "fs";
+import * as os from "os";
+import * as path from "path";
+
+import "mocha";
+
+import * as aws from "@pulumi/aws";
+import { ResourceValidationPolicy, StackValidationPolicy } from "@pulumi/policy";
+
+import { withViolationRecords } from "../report";
+
+import { createResourceValidationArgs, createStackValidationArgs } from "./util";
+
+describe("#withViolationRecords", () => {
+    let reportFile: string;
+
+    beforeEach(() => {
+        reportFile = path.join(fs.mkdtempSync(path.join(os.tmpdir(), "awsguard-")), "report.jsonl");
+    });
+
+    function readRecords(): any[] {
+        return fs.readFileSync(reportFile, "utf8").split("\n").filter(line => line).map(line => JSON.parse(line));
+    }
+
+    it("records each violation of a resource policy, and still reports it", async () => {
+        const policy: ResourceValidationPolicy = {
+            name: "test-resource-policy",
+            description: "Test policy.",
+            validateResource: (_, reportViolation) => {
+                reportViolation("first violation");
+                reportViolation("second violation");
+            },
+        };
+
+        const reported: string[] = [];
+        const wrapped = <ResourceValidationPolicy>withViolationRecords(policy, "mandatory", reportFile);
+        const args = createResourceValidationArgs(aws.s3.Bucket, {});
+        for (const validation of Array.isArray(wrapped.validateResource) ? wrapped.validateResource : [wrapped.validateResource]) {
+            await validation(args, message => reported.push(message));
+        }
+
+        assert.deepStrictEqual(reported, ["first violation", "second violation"]);
+        assert.deepStrictEqual(readRecords(), [
+            {
+                policyName: "test-resource-policy",
+                urn: "unknown",
+                enforcementLevel: "mandatory",
+                message: "first violation",
+            },
+            {
+                policyName: "test-resource-policy",
+                urn: "unknown",
+                enforcementLevel: "mandatory",
+                message: "second violation",
+            },
+        ]);
+    });
+
+    it("records violations of a stack policy with the reported URN", async () => {
+        const policy: StackValidationPolicy = {
+            name: "test-stack-policy",
+            description: "Test policy.",
+            validateStack: (_, reportViolation) => {
+                reportViolation("stack violation", "urn:pulumi:test::test::aws:s3/bucket:Bucket::b");
+            },
+        };
+
+        const wrapped = <StackValidationPolicy>withViolationRecords(policy, "advisory", reportFile);
+        await wrapped.validateStack(createStackValidationArgs(aws.s3.Bucket, {}), () => undefined);
+
+        assert.deepStrictEqual(readRecords(), [{
+            policyName: "test-stack-policy",
+            urn: "urn:pulumi:test::test::aws:s3/bucket:Bucket::b",
+            enforcementLevel: "advisory",
+            message: "stack violation",
+        }]);
+    });
+});
@@ -0,0 +1,89 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import * as assert from "assert";
+
+import "mocha";
+
+import * as aws from "@pulumi/aws";
+import { ResourceValidationPolicy, StackValidationPolicy } from "@pulumi/policy";
+
+import { isInTagScope, withTagScope } from "../scope";
+
+import { createPolicyResource, createResourceValidationArgs, createStackValidationArgsWithResources } from "./util";
+
+describe("#isInTagScope", () => {
+    const production = { key: "Environment", value: "production" };
+
+    it("only includes resources with the 'only' tag", () => {
+        assert.strictEqual(isInTagScope({ tags: { Environment: "production" } }, production), true);
+        assert.strictEqual(isInTagScope({ tags: { Environment: "dev" } }, production), false);
+        assert.strictEqual(isInTagScope({ tags: { Environment: "dev" } }, { key: "Environment" }), true);
+    });
+
+    it("excludes resources with the 'exclude' tag", () => {
+        assert.strictEqual(isInTagScope({ tags: { Environment: "production" } }, undefined, production), false);
+        assert.strictEqual(isInTagScope({ tags: { Environment: "dev" } }, undefined, production), true);
+    });
+
+    it("treats resources without tags as never matching", () => {
+        assert.strictEqual(isInTagScope({}, production), false);
+        assert.strictEqual(isInTagScope({}, undefined, production), true);
+    });
+});
+
+describe("#withTagScope", () => {
+    const only = { key: "Environment", value: "production" };
+
+    it("skips resource validations for resources out of scope", async () => {
+        const policy: ResourceValidationPolicy = {
+            name: "test-resource-policy",
+            description: "Test policy.",
+            validateResource: (_, reportViolation) => reportViolation("A violation."),
+        };
+        const wrapped = <ResourceValidationPolicy>withTagScope(policy, only);
+        const validate = async (tags: Record<string, string>) => {
+            const reported: string[] = [];
+            const args = createResourceValidationArgs(aws.s3.Bucket, { tags });
+            for (const validation of Array.isArray(wrapped.validateResource) ? wrapped.validateResource : [wrapped.validateResource]) {
+                await validation(args, message => reported.push(message));
+            }
+            return reported;
+        };
+
+        assert.deepStrictEqual(await validate({ Environment: "production" }), ["A violation."]);
+        assert.deepStrictEqual(await validate({ Environment: "dev" }), []);
+    });
+
+    it("drops stack violations of resources out of scope", async () => {
+        const prod = createPolicyResource(aws.s3.Bucket, { tags: { Environment: "production" } }, "prod-bucket");
+        const dev = createPolicyResource(aws.s3.Bucket, { tags: { Environment: "dev" } }, "dev-bucket");
+        const policy: StackValidationPolicy = {
+            name: "test-stack-policy",
+            description: "Test policy.",
+            validateStack: (args, reportViolation) => {
+                for (const resource of args.resources) {
+                    reportViolation(`'${resource.name}' is invalid.`, resource.urn);
+                }
+                reportViolation("The stack is invalid.");
+            },
+        };
+
+        const reported: string[] = [];
+        const wrapped = <StackValidationPolicy>withTagScope(policy, only);
+        await wrapped.validateStack(createStackValidationArgsWithResources([prod, dev]), message => reported.push(message));
+
+        assert.deepStrictEqual(reported, ["'prod-bucket' is invalid.", "The stack is invalid."]);
+    });
+});
@@ -21,8 +21,8 @@ import * as security from "../security";
 import {
     assertHasResourceViolation, assertHasStackViolation,
     assertNoResourceViolations, assertNoStackViolations,
-    createResourceValidationArgs, createStackValidationArgs,
-    daysFromNow, PolicyViolation,
+    createPolicyResource, createResourceValidationArgs, createStackValidationArgs,
+    createStackValidationArgsWithResources, daysFromNow, PolicyViolation,
 } from "./util";
 
 import { fail } from "assert";
@@ -30,6 +30,7 @@ import { fail } from "assert";
 import * as AWS from "aws-sdk";
 import * as AWSMock from "aws-sdk-mock";
 
+import { DescribeCertificateRequest } from "aws-sdk/clients/acm";
 import { ListAccessKeysRequest, ListMFADevicesRequest } from "aws-sdk/clients/iam";
 
 describe("#iamAccessKeysRotated", () => {
@@ -202,3 +203,346 @@ describe("#cmkBackingKeyRotationEnabled", () => {
         await assertNoResourceViolations(policy, args);
     });
 });
+
+describe("#acmCertificateExpiration", () => {
+    const policy = security.acmCertificateExpiration;
+    const certificateArn = "arn:aws:acm:us-west-2:123456789012:certificate/test";
+
+    afterEach(() => {
+        AWSMock.restore("ACM", "describeCertificate");
+    });
+
+    function mockDescribeCertificate(err: any, notAfter?: Date) {
+        AWSMock.setSDKInstance(AWS);
+        AWSMock.mock("ACM", "describeCertificate", (params: DescribeCertificateRequest, callback: Function) => {
+            if (err) {
+                callback(err);
+                return;
+            }
+            const resp: AWS.ACM.DescribeCertificateResponse = {
+                Certificate: { CertificateArn: params.CertificateArn, NotAfter: notAfter },
+            };
+            callback(null, resp);
+        });
+    }
+
+    it("Reports a violation for certificates expiring within the threshold", async () => {
+        mockDescribeCertificate(undefined, daysFromNow(10));
+
+        const args = createStackValidationArgs(aws.acm.Certificate, { id: certificateArn }, {
+            maxDaysUntilExpiration: 14,
+            expirationThresholdDays: 30,
+        });
+        await assertHasStackViolation(policy, args, { message: "(max allowed 30 days)" });
+    });
+
+    it("Reports no violations for certificates valid beyond the threshold", async () => {
+        mockDescribeCertificate(undefined, daysFromNow(60));
+
+        const args = createStackValidationArgs(aws.acm.Certificate, { id: certificateArn }, {
+            maxDaysUntilExpiration: 14,
+            expirationThresholdDays: 30,
+        });
+        await assertNoStackViolations(policy, args);
+    });
+
+    it("Skips the policy if configured to when the AWS API is unreachable", async () => {
+        const err: any = new Error("connect EHOSTDOWN 169.254.169.254:80 - Local (0.0.0.0:0)");
+        err.code = "EHOSTDOWN";
+        mockDescribeCertificate(err);
+
+        const args = createStackValidationArgs(aws.acm.Certificate, { id: certificateArn }, {
+            maxDaysUntilExpiration: 14,
+            skipWhenCredentialsUnavailable: true,
+        });
+        await assertNoStackViolations(policy, args);
+    });
+
+    it("Fails if the AWS API is unreachable and not configured to skip", async () => {
+        const err: any = new Error("connect EHOSTDOWN 169.254.169.254:80 - Local (0.0.0.0:0)");
+        err.code = "EHOSTDOWN";
+        mockDescribeCertificate(err);
+
+        const args = createStackValidationArgs(aws.acm.Certificate, { id: certificateArn }, {
+            maxDaysUntilExpiration: 14,
+            skipWhenCredentialsUnavailable: false,
+        });
+        try {
+            await assertNoStackViolations(policy, args);
+        } catch (e) {
+            return;
+        }
+        fail("expected the policy to fail");
+    });
+});
+
+describe("#acmCertificateNoWildcard", () => {
+    const policy = security.acmCertificateNoWildcard;
+
+    it("Should pass if the certificate has no wildcard domains", async () => {
+        const args = createResourceValidationArgs(aws.acm.Certificate, {
+            domainName: "www.example.com",
+            subjectAlternativeNames: ["api.example.com"],
+        });
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should fail if the certificate uses a wildcard domain", async () => {
+        const args = createResourceValidationArgs(aws.acm.Certificate, {
+            domainName: "www.example.com",
+            subjectAlternativeNames: ["*.example.com"],
+        });
+        await assertHasResourceViolation(policy, args, {
+            message: "ACM certificate 'unknown' must not use the wildcard domain '*.example.com'.",
+        });
+    });
+
+    it("Should pass if the wildcard domain is allowed", async () => {
+        const args = createResourceValidationArgs(aws.acm.Certificate, {
+            domainName: "*.example.com",
+            subjectAlternativeNames: ["*.internal.example.com"],
+        }, { allowedWildcardDomains: ["example.com", "*.internal.example.com"] });
+        await assertNoResourceViolations(policy, args);
+    });
+});
+
+describe("#preferIamRolesOverUsers", () => {
+    const policy = security.preferIamRolesOverUsers;
+
+    it("Should report IAM users", async () => {
+        const args = createResourceValidationArgs(aws.iam.User, { name: "alice" });
+        await assertHasResourceViolation(policy, args, {
+            message: "IAM user 'unknown' should be replaced by a role assumed through federation or by a service.",
+        });
+    });
+
+    it("Should pass if the user is allowed", async () => {
+        const args = createResourceValidationArgs(aws.iam.User, { name: "ci-deployer" }, { allowedUserNames: ["ci-deployer"] });
+        await assertNoResourceViolations(policy, args);
+    });
+});
+
+describe("#secretsmanagerNoPlaintextSecretString", () => {
+    const policy = security.secretsmanagerNoPlaintextSecretString;
+
+    it("Should report secret versions with a literal secret string", async () => {
+        const args = createStackValidationArgsWithResources([
+            createPolicyResource(aws.secretsmanager.SecretVersion, {
+                secretId: "test-secret",
+                secretString: "hunter2",
+            }, "test-secret-version"),
+        ]);
+        await assertHasStackViolation(policy, args, {
+            message: "Secrets Manager secret version 'test-secret-version' appears to have a hardcoded secret string. " +
+                "Generate it with a resource such as 'random.RandomPassword'",
+        });
+    });
+
+    it("Should pass if the secret string is computed from another resource", async () => {
+        const cluster = createPolicyResource(aws.rds.Cluster, { engine: "aurora-postgresql" }, "test-cluster");
+        const args = createStackValidationArgsWithResources([
+            cluster,
+            createPolicyResource(aws.secretsmanager.SecretVersion, {
+                secretId: "test-secret",
+                secretString: "{\"host\":\"test-cluster.example.com\"}",
+            }, "test-secret-version", { secretString: [cluster] }),
+        ]);
+        await assertNoStackViolations(policy, args);
+    });
+
+    it("Should pass if the secret string is unknown", async () => {
+        const args = createStackValidationArgs(aws.secretsmanager.SecretVersion, { secretId: "test-secret" });
+        await assertNoStackViolations(policy, args);
+    });
+});
+
+describe("#kmsKeyPolicyNoWildcardAdmin", () => {
+    const policy = security.kmsKeyPolicyNoWildcardAdmin;
+
+    function keyPolicy(...statements: any[]): string {
+        return JSON.stringify({ Version: "2012-10-17", Statement: statements });
+    }
+
+    const rootAdmin = {
+        Sid: "Enable IAM User Permissions",
+        Effect: "Allow",
+        Principal: { AWS: "arn:aws:iam::123456789012:root" },
+        Action: "kms:*",
+        Resource: "*",
+    };
+
+    it("Should pass for the default key policy, which grants the account root kms:*", async () => {
+        const args = createResourceValidationArgs(aws.kms.Key, { policy: keyPolicy(rootAdmin) });
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should fail if the key policy grants kms:* to any principal", async () => {
+        const args = createResourceValidationArgs(aws.kms.Key, {
+            policy: keyPolicy(rootAdmin, { Effect: "Allow", Principal: "*", Action: "kms:*", Resource: "*" }),
+        });
+        await assertHasResourceViolation(policy, args, {
+            message: "KMS key 'unknown' must not allow any principal to administer the key: statement 2 " +
+                "grants kms:* to any principal without a condition.",
+        });
+    });
+
+    it("Should pass if access for any principal is restricted by a condition", async () => {
+        const args = createResourceValidationArgs(aws.kms.Key, {
+            policy: keyPolicy({
+                Effect: "Allow",
+                Principal: { AWS: "*" },
+                Action: "kms:*",
+                Resource: "*",
+                Condition: { StringEquals: { "kms:CallerAccount": "123456789012" } },
+            }),
+        });
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should fail if a key policy resource grants kms:* to any principal", async () => {
+        const args = createResourceValidationArgs(aws.kms.KeyPolicy, {
+            keyId: "test-key-id",
+            policy: keyPolicy({ Sid: "Everyone", Effect: "Allow", Principal: { AWS: "*" }, Action: "kms:*", Resource: "*" }),
+        });
+        await assertHasResourceViolation(policy, args, {
+            message: "KMS key 'test-key-id' must not allow any principal to administer the key: statement 'Everyone'",
+        });
+    });
+});
+
+describe("#minimumTlsVersion", () => {
+    const policy = security.minimumTlsVersion;
+
+    function createListenerArgs(sslPolicy?: string, config?: any) {
+        return createResourceValidationArgs(aws.lb.Listener, {
+            loadBalancerArn: "arn:aws:elasticloadbalancing:us-west-2:123456789012:loadbalancer/app/alb/1234",
+            protocol: "HTTPS",
+            port: 443,
+            certificateArn: "arn:aws:acm:us-west-2:123456789012:certificate/1234",
+            sslPolicy,
+            defaultActions: [],
+        }, config);
+    }
+
+    it("Should fail if a listener's TLS policy allows an older TLS version", async () => {
+        await assertHasResourceViolation(policy, createListenerArgs("ELBSecurityPolicy-TLS-1-1-2017-01"), {
+            message: "Load balancer listener 'unknown' (aws:lb/listener:Listener) uses the TLS policy " +
+                "'ELBSecurityPolicy-TLS-1-1-2017-01', which allows TLS 1.1, but the minimum is TLS 1.2. " +
+                "Use 'ELBSecurityPolicy-TLS13-1-2-2021-06' instead.",
+        });
+
+        // Listeners without a TLS policy use ELBSecurityPolicy-2016-08, which allows TLS 1.0.
+        await assertHasResourceViolation(policy, createListenerArgs(), {
+            message: "uses the TLS policy 'ELBSecurityPolicy-2016-08', which allows TLS 1.0",
+        });
+    });
+
+    it("Should pass if the TLS policy enforces the minimum", async () => {
+        await assertNoResourceViolations(policy, createListenerArgs("ELBSecurityPolicy-TLS13-1-2-2021-06"));
+        await assertNoResourceViolations(policy, createListenerArgs("ELBSecurityPolicy-TLS-1-1-2017-01", { minimumVersion: "1.1" }));
+
+        // Policies the pack doesn't know can't be compared, so they're not reported.
+        await assertNoResourceViolations(policy, createListenerArgs("ELBSecurityPolicy-TLS13-1-2-2099-01"));
+
+        const httpArgs = createListenerArgs();
+        httpArgs.props.protocol = "HTTP";
+        await assertNoResourceViolations(policy, httpArgs);
+    });
+
+    it("Should check CloudFront distributions", async () => {
+        const args = createResourceValidationArgs(aws.cloudfront.Distribution, {
+            enabled: true,
+            origins: [],
+            defaultCacheBehavior: {
+                allowedMethods: ["GET", "HEAD"],
+                cachedMethods: ["GET", "HEAD"],
+                targetOriginId: "origin",
+                viewerProtocolPolicy: "redirect-to-https",
+            },
+            restrictions: { geoRestriction: { restrictionType: "none" } },
+            viewerCertificate: {
+                acmCertificateArn: "arn:aws:acm:us-east-1:123456789012:certificate/1234",
+                minimumProtocolVersion: "TLSv1_2016",
+            },
+        });
+        await assertHasResourceViolation(policy, args, {
+            message: "CloudFront distribution 'unknown' (aws:cloudfront/distribution:Distribution) uses the TLS policy " +
+                "'TLSv1_2016', which allows TLS 1.0, but the minimum is TLS 1.2. Use 'TLSv1.2_2021' instead.",
+        });
+
+        args.props.viewerCertificate.minimumProtocolVersion = "TLSv1.2_2021";
+        await assertNoResourceViolations(policy, args);
+
+        // The default CloudFront certificate always allows TLSv1, so it takes a custom certificate to fix.
+        args.props.viewerCertificate = { cloudfrontDefaultCertificate: true, minimumProtocolVersion: "TLSv1.2_2021" };
+        await assertHasResourceViolation(policy, args, {
+            message: "uses the TLS policy 'TLSv1', which allows TLS 1.0, but the minimum is TLS 1.2. The default " +
+                "*.cloudfront.net certificate always allows TLSv1, so use a custom certificate, e.g. from ACM, with " +
+                "the minimum protocol version 'TLSv1.2_2021' instead.",
+        });
+    });
+
+    it("Should check API Gateway, OpenSearch and ElastiCache resources", async () => {
+        await assertHasResourceViolation(policy, createResourceValidationArgs(aws.apigateway.DomainName, {
+            domainName: "api.example.com",
+        }), {
+            message: "uses the TLS policy 'TLS_1_0', which allows TLS 1.0, but the minimum is TLS 1.2. Use 'TLS_1_2' instead.",
+        });
+        await assertHasResourceViolation(policy, createResourceValidationArgs(aws.opensearch.Domain, {
+            domainEndpointOptions: { enforceHttps: true },
+        }), {
+            message: "uses the TLS policy 'Policy-Min-TLS-1-0-2019-07', which allows TLS 1.0",
+        });
+        await assertHasResourceViolation(policy, createResourceValidationArgs(aws.elasticache.ReplicationGroup, {
+            description: "cache",
+            transitEncryptionEnabled: false,
+        }), {
+            message: "uses the TLS policy 'transit encryption disabled', which allows unencrypted connections",
+        });
+        await assertHasResourceViolation(policy, createResourceValidationArgs(aws.apigateway.DomainName, {
+            domainName: "api.example.com",
+            securityPolicy: "TLS_1_2",
+        }, { minimumVersion: "1.3" }), {
+            message: "which allows TLS 1.2, but the minimum is TLS 1.3. No policy of the service enforces it.",
+        });
+    });
+
+    it("Should only check the configured resource types", async () => {
+        await assertNoResourceViolations(policy, createListenerArgs(undefined, { resourceTypes: ["cloudfront"] }));
+    });
+});
+
+describe("#inspectorEnabled", () => {
+    const policy = security.inspectorEnabled;
+
+    const instance = createPolicyResource(aws.ec2.Instance, { ami: "ami-1234", instanceType: "t3.micro" }, "web");
+    const repository = createPolicyResource(aws.ecr.Repository, {}, "images");
+
+    it("Should warn about scan types that aren't enabled", async () => {
+        await assertHasStackViolation(policy, createStackValidationArgsWithResources([instance, repository]), {
+            message: "The stack creates resources that Amazon Inspector should scan, but doesn't enable it for the " +
+                "scan types EC2, ECR.",
+        });
+
+        const enabler = createPolicyResource(aws.inspector2.Enabler, {
+            accountIds: ["123456789012"],
+            resourceTypes: ["EC2"],
+        }, "inspector");
+        await assertHasStackViolation(policy, createStackValidationArgsWithResources([instance, repository, enabler]), {
+            message: "but doesn't enable it for the scan types ECR.",
+        });
+    });
+
+    it("Should pass if every scan type the stack needs is enabled", async () => {
+        const enabler = createPolicyResource(aws.inspector2.Enabler, {
+            accountIds: ["123456789012"],
+            resourceTypes: ["EC2", "ECR"],
+        }, "inspector");
+        await assertNoStackViolations(policy, createStackValidationArgsWithResources([instance, repository, enabler]));
+    });
+
+    it("Should pass if the stack has nothing to scan", async () => {
+        const bucket = createPolicyResource(aws.s3.Bucket, {}, "bucket");
+        await assertNoStackViolations(policy, createStackValidationArgsWithResources([bucket]));
+    });
+});
@@ -0,0 +1,486 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import "mocha";
+
+import * as aws from "@pulumi/aws";
+import { ResourceValidationArgs } from "@pulumi/policy";
+
+import * as storage from "../storage";
+
+import {
+    assertHasResourceViolation, assertHasStackViolation,
+    assertNoResourceViolations, assertNoStackViolations,
+    createPolicyResource, createResourceValidationArgs, createStackValidationArgs, createStackValidationArgsWithResources,
+} from "./util";
+
+describe("#s3BucketLifecycleConfigured", () => {
+    const policy = storage.s3BucketLifecycleConfigured;
+
+    it("Should fail if the bucket has no lifecycle rules", async () => {
+        const args = createStackValidationArgsWithResources([
+            createPolicyResource(aws.s3.Bucket, {}, "test-bucket"),
+        ]);
+
+        const msg = "S3 bucket 'test-bucket' must have lifecycle rules configured.";
+        await assertHasStackViolation(policy, args, { message: msg });
+    });
+
+    it("Should fail if the bucket's only lifecycle rule is disabled", async () => {
+        const args = createStackValidationArgs(aws.s3.Bucket, {
+            lifecycleRules: [{ enabled: false, expiration: { days: 30 } }],
+        });
+
+        await assertHasStackViolation(policy, args, { message: "must have lifecycle rules configured." });
+    });
+
+    it("Should pass if the bucket has inline lifecycle rules", async () => {
+        const args = createStackValidationArgs(aws.s3.Bucket, {
+            lifecycleRules: [{ enabled: true, transitions: [{ days: 30, storageClass: "GLACIER" }] }],
+        });
+
+        await assertNoStackViolations(policy, args);
+    });
+
+    it("Should pass if a lifecycle configuration refers to the bucket", async () => {
+        const bucket = createPolicyResource(aws.s3.BucketV2, {}, "test-bucket");
+        const config = createPolicyResource(aws.s3.BucketLifecycleConfigurationV2, {
+            rules: [{ id: "expire", status: "Enabled", expiration: { days: 30 } }],
+        }, "test-lifecycle", { bucket: [bucket] });
+
+        const args = createStackValidationArgsWithResources([bucket, config], { requireExpiration: true });
+        await assertNoStackViolations(policy, args);
+    });
+
+    it("Should fail if a lifecycle configuration refers to another bucket", async () => {
+        const bucket = createPolicyResource(aws.s3.BucketV2, { bucket: "my-bucket" }, "test-bucket");
+        const config = createPolicyResource(aws.s3.BucketLifecycleConfigurationV2, {
+            bucket: "some-other-bucket",
+            rules: [{ id: "expire", status: "Enabled", expiration: { days: 30 } }],
+        }, "test-lifecycle");
+
+        const args = createStackValidationArgsWithResources([bucket, config]);
+        await assertHasStackViolation(policy, args, {
+            message: "S3 bucket 'test-bucket' must have lifecycle rules configured.",
+        });
+    });
+
+    it("Should fail if expiration is required but no rule expires objects", async () => {
+        const args = createStackValidationArgs(aws.s3.Bucket, {
+            lifecycleRules: [{ enabled: true, transitions: [{ days: 30, storageClass: "GLACIER" }] }],
+        }, { requireExpiration: true });
+
+        await assertHasStackViolation(policy, args, { message: "must have a lifecycle rule that expires objects." });
+    });
+});
+
+describe("#s3BucketObjectLockEnabled", () => {
+    const policy = storage.s3BucketObjectLockEnabled;
+    const config = { wormTagKey: "worm", wormTagValue: "true", wormBucketNames: ["audit-bucket"] };
+
+    it("Should ignore buckets that don't require WORM storage", async () => {
+        const args = createStackValidationArgs(aws.s3.Bucket, { tags: { worm: "false" } }, config);
+        await assertNoStackViolations(policy, args);
+    });
+
+    it("Should fail if a WORM-tagged bucket does not have object lock enabled", async () => {
+        const args = createStackValidationArgsWithResources([
+            createPolicyResource(aws.s3.Bucket, { tags: { worm: "true" } }, "test-bucket"),
+        ], config);
+
+        await assertHasStackViolation(policy, args, {
+            message: "S3 bucket 'test-bucket' requires WORM storage and must have object lock enabled. " +
+                "Object lock can only be enabled when the bucket is created and cannot be added later.",
+        });
+    });
+
+    it("Should fail if a bucket designated by name does not have object lock enabled", async () => {
+        const args = createStackValidationArgsWithResources([
+            createPolicyResource(aws.s3.BucketV2, { objectLockEnabled: false }, "audit-bucket"),
+        ], config);
+
+        await assertHasStackViolation(policy, args, { message: "cannot be added later." });
+    });
+
+    it("Should pass if the bucket has object lock enabled", async () => {
+        const args = createStackValidationArgs(aws.s3.Bucket, {
+            tags: { worm: "true" },
+            objectLockConfiguration: { objectLockEnabled: "Enabled" },
+        }, config);
+        await assertNoStackViolations(policy, args);
+    });
+
+    it("Should pass if an object lock configuration refers to the bucket", async () => {
+        const bucket = createPolicyResource(aws.s3.BucketV2, { tags: { worm: "true" } }, "test-bucket");
+        const lockConfig = createPolicyResource(aws.s3.BucketObjectLockConfigurationV2, {
+            objectLockEnabled: "Enabled",
+        }, "test-lock", { bucket: [bucket] });
+
+        const args = createStackValidationArgsWithResources([bucket, lockConfig], config);
+        await assertNoStackViolations(policy, args);
+    });
+});
+
+describe("#s3BucketMfaDelete", () => {
+    const policy = storage.s3BucketMfaDelete;
+
+    it("Should fail if a versioned bucket does not have MFA delete enabled", async () => {
+        const args = createStackValidationArgsWithResources([
+            createPolicyResource(aws.s3.Bucket, { versioning: { enabled: true } }, "test-bucket"),
+        ]);
+
+        await assertHasStackViolation(policy, args, {
+            message: "S3 bucket 'test-bucket' is versioned and should have MFA delete enabled. Enable it with an " +
+                "'aws.s3.BucketVersioningV2' for the bucket that sets 'mfaDelete' to \"Enabled\" and 'mfa' to the " +
+                "root account's MFA device serial number and current code.",
+        });
+    });
+
+    it("Should pass if the bucket's inline versioning has MFA delete enabled", async () => {
+        const args = createStackValidationArgs(aws.s3.Bucket, { versioning: { enabled: true, mfaDelete: true } });
+        await assertNoStackViolations(policy, args);
+    });
+
+    it("Should skip buckets that are not versioned", async () => {
+        await assertNoStackViolations(policy, createStackValidationArgs(aws.s3.Bucket, {}));
+        await assertNoStackViolations(policy,
+            createStackValidationArgs(aws.s3.Bucket, { versioning: { enabled: false } }));
+
+        const bucket = createPolicyResource(aws.s3.BucketV2, {}, "test-bucket");
+        const versioning = createPolicyResource(aws.s3.BucketVersioningV2, {
+            versioningConfiguration: { status: "Suspended" },
+        }, "test-versioning", { bucket: [bucket] });
+        await assertNoStackViolations(policy, createStackValidationArgsWithResources([bucket, versioning]));
+    });
+
+    it("Should check versioning configurations that refer to the bucket", async () => {
+        const bucket = createPolicyResource(aws.s3.BucketV2, {}, "test-bucket");
+        const versioning = createPolicyResource(aws.s3.BucketVersioningV2, {
+            versioningConfiguration: { status: "Enabled", mfaDelete: "Disabled" },
+        }, "test-versioning", { bucket: [bucket] });
+        await assertHasStackViolation(policy, createStackValidationArgsWithResources([bucket, versioning]), {
+            message: "S3 bucket 'test-bucket' is versioned and should have MFA delete enabled.",
+        });
+
+        versioning.props.versioningConfiguration.mfaDelete = "Enabled";
+        await assertNoStackViolations(policy, createStackValidationArgsWithResources([bucket, versioning]));
+    });
+});
+
+describe("#transferServerSecurityPolicy", () => {
+    const policy = storage.transferServerSecurityPolicy;
+
+    function getHappyPathArgs(): ResourceValidationArgs {
+        return createResourceValidationArgs(aws.transfer.Server, {
+            securityPolicyName: "TransferSecurityPolicy-2020-06",
+            protocols: ["SFTP"],
+        }, { minimumSecurityPolicyName: "TransferSecurityPolicy-2020-06", ftpAllowedServerNames: ["legacy-ftp"] });
+    }
+
+    it("Should pass if the server uses a recent security policy and SFTP", async () => {
+        const args = getHappyPathArgs();
+        await assertNoResourceViolations(policy, args);
+
+        args.props.securityPolicyName = "TransferSecurityPolicy-FIPS-2023-05";
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should fail if the server uses an older security policy", async () => {
+        const args = getHappyPathArgs();
+        args.props.securityPolicyName = "TransferSecurityPolicy-2018-11";
+
+        await assertHasResourceViolation(policy, args, {
+            message: "Transfer server 'unknown' uses security policy 'TransferSecurityPolicy-2018-11', " +
+                "which is older than the minimum 'TransferSecurityPolicy-2020-06'.",
+        });
+    });
+
+    it("Should fail if the security policy is unspecified", async () => {
+        const args = getHappyPathArgs();
+        args.props.securityPolicyName = undefined;
+
+        await assertHasResourceViolation(policy, args, { message: "uses security policy 'TransferSecurityPolicy-2018-11'" });
+    });
+
+    it("Should fail if the server enables FTP", async () => {
+        const args = getHappyPathArgs();
+        args.props.protocols = ["SFTP", "FTP"];
+
+        await assertHasResourceViolation(policy, args, { message: "must not enable the plaintext FTP protocol." });
+    });
+
+    it("Should pass if a server allowed to use FTP enables it", async () => {
+        const args = getHappyPathArgs();
+        args.props.protocols = ["FTP"];
+        args.name = "legacy-ftp";
+
+        await assertNoResourceViolations(policy, args);
+    });
+});
+
+describe("#fsxEncryption", () => {
+    const policy = storage.fsxEncryption;
+
+    it("Should fail if a persistent Lustre file system does not use a customer managed key", async () => {
+        const args = createResourceValidationArgs(aws.fsx.LustreFileSystem, {
+            subnetIds: ["subnet-1234"],
+            deploymentType: "PERSISTENT_1",
+        });
+        await assertHasResourceViolation(policy, args, {
+            message: "FSx for Lustre file system 'unknown' must be encrypted with a customer managed KMS key.",
+        });
+
+        args.props.kmsKeyId = "test-key-id";
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should skip Lustre scratch file systems", async () => {
+        const args = createResourceValidationArgs(aws.fsx.LustreFileSystem, { subnetIds: ["subnet-1234"] });
+        await assertNoResourceViolations(policy, args);
+
+        args.props.deploymentType = "SCRATCH_2";
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should fail if a Windows file system does not use a customer managed key", async () => {
+        const args = createResourceValidationArgs(aws.fsx.WindowsFileSystem, {
+            subnetIds: ["subnet-1234"],
+            throughputCapacity: 8,
+        });
+        await assertHasResourceViolation(policy, args, {
+            message: "FSx for Windows File Server file system 'unknown' must be encrypted",
+        });
+    });
+
+    it("Should fail if an ONTAP file system does not use a customer managed key", async () => {
+        const args = createResourceValidationArgs(aws.fsx.OntapFileSystem, {
+            deploymentType: "MULTI_AZ_1",
+            subnetIds: ["subnet-1234", "subnet-5678"],
+            preferredSubnetId: "subnet-1234",
+            throughputCapacity: 512,
+        });
+        await assertHasResourceViolation(policy, args, { message: "FSx for NetApp ONTAP file system 'unknown' must be encrypted" });
+    });
+
+    it("Should pass if customer managed keys are not required", async () => {
+        const args = createResourceValidationArgs(aws.fsx.WindowsFileSystem, {
+            subnetIds: ["subnet-1234"],
+            throughputCapacity: 8,
+        }, { requireCustomerManagedKey: false });
+        await assertNoResourceViolations(policy, args);
+    });
+});
+
+describe("#s3BucketReplicationConfigured", () => {
+    const policy = storage.s3BucketReplicationConfigured;
+    const config = { replicationTagKey: "dr-replication", replicationTagValue: "true" };
+
+    it("Should ignore buckets that don't require replication", async () => {
+        const args = createStackValidationArgs(aws.s3.Bucket, {}, config);
+        await assertNoStackViolations(policy, args);
+    });
+
+    it("Should fail if a tagged bucket has no replication configuration", async () => {
+        const args = createStackValidationArgsWithResources([
+            createPolicyResource(aws.s3.BucketV2, { tags: { "dr-replication": "true" } }, "test-bucket"),
+        ], config);
+        await assertHasStackViolation(policy, args, {
+            message: "S3 bucket 'test-bucket' requires disaster recovery replication and must have a replication configuration.",
+        });
+    });
+
+    it("Should pass if the bucket has an inline replication configuration", async () => {
+        const args = createStackValidationArgs(aws.s3.Bucket, {
+            tags: { "dr-replication": "true" },
+            replicationConfiguration: {
+                role: "arn:aws:iam::123456789012:role/replication",
+                rules: [{ status: "Enabled", destination: { bucket: "arn:aws:s3:::replica" } }],
+            },
+        }, config);
+        await assertNoStackViolations(policy, args);
+    });
+
+    it("Should pass if a replication configuration refers to the bucket", async () => {
+        const bucket = createPolicyResource(aws.s3.BucketV2, { tags: { "dr-replication": "true" } }, "test-bucket");
+        const replication = createPolicyResource(aws.s3.BucketReplicationConfig, {
+            role: "arn:aws:iam::123456789012:role/replication",
+            rules: [{ status: "Enabled", destination: { bucket: "arn:aws:s3:::replica" } }],
+        }, "test-replication", { bucket: [bucket] });
+
+        const args = createStackValidationArgsWithResources([bucket, replication], config);
+        await assertNoStackViolations(policy, args);
+    });
+});
+
+describe("#s3BucketAclNoPublic", () => {
+    const policy = storage.s3BucketAclNoPublic;
+
+    it("Should fail if the bucket uses a public canned ACL", async () => {
+        const args = createResourceValidationArgs(aws.s3.Bucket, { acl: "public-read" });
+        await assertHasResourceViolation(policy, args, {
+            message: "S3 bucket 'unknown' must not use the public ACL 'public-read'.",
+        });
+
+        args.props.acl = "authenticated-read";
+        await assertHasResourceViolation(policy, args, { message: "must not use the public ACL 'authenticated-read'." });
+
+        args.props.acl = "private";
+        await assertNoResourceViolations(policy, args);
+    });
+
+    it("Should fail if the bucket grants access to all users", async () => {
+        const args = createResourceValidationArgs(aws.s3.Bucket, {
+            grants: [{ type: "Group", uri: "http://acs.amazonaws.com/groups/global/AllUsers", permissions: ["READ"] }],
+        });
+        await assertHasResourceViolation(policy, args, {
+            message: "S3 bucket 'unknown' must not grant access to the group 'http://acs.amazonaws.com/groups/global/AllUsers'.",
+        });
+    });
+
+    it("Should fail if a bucket ACL grants access to all authenticated users", async () => {
+        const args = createResourceValidationArgs(aws.s3.BucketAclV2, {
+            bucket: "my-bucket",
+            accessControlPolicy: {
+                owner: { id: "owner-id" },
+                grants: [{
+                    grantee: { type: "Group", uri: "http://acs.amazonaws.com/groups/global/AuthenticatedUsers" },
+                    permission: "READ",
+                }],
+            },
+        });
+        await assertHasResourceViolation(policy, args, {
+            message: "S3 bucket 'my-bucket' must not grant access to the group 'http://acs.amazonaws.com/groups/global/AuthenticatedUsers'.",
+        });
+    });
+
+    it("Should pass if a bucket ACL is private", async () => {
+        const args = createResourceValidationArgs(aws.s3.BucketAclV2, { bucket: "my-bucket", acl: "private" });
+        await assertNoResourceViolations(policy, args);
+
+        args.props.acl = "public-read-write";
+        await assertHasResourceViolation(policy, args, { message: "S3 bucket 'my-bucket' must not use the public ACL 'public-read-write'." });
+    });
+});
+
+describe("#s3EventNotificationReliability", () => {
+    const policy = storage.s3EventNotificationReliability;
+
+    it("Should fail if a Lambda function target has no dead-letter queue", async () => {
+        const fn = createPolicyResource(aws.lambda.Function, { role: "arn:aws:iam::123456789012:role/lambda" }, "test-function");
+        const notification = createPolicyResource(aws.s3.BucketNotification, {
+            bucket: "test-bucket",
+            lambdaFunctions: [{ events: ["s3:ObjectCreated:*"] }],
+        }, "test-notification", { lambdaFunctions: [fn] });
+
+        const args = createStackValidationArgsWithResources([fn, notification]);
+        await assertHasStackViolation(policy, args, {
+            message: "Bucket notification 'test-notification' sends events to Lambda function 'test-function', " +
+                "which has no dead-letter queue or on-failure destination.",
+        });
+    });
+
+    it("Should pass if a Lambda function target has a dead-letter queue or on-failure destination", async () => {
+        const fn = createPolicyResource(aws.lambda.Function, {
+            role: "arn:aws:iam::123456789012:role/lambda",
+            deadLetterConfig: { targetArn: "arn:aws:sqs:us-west-2:123456789012:dlq" },
+        }, "test-function");
+        const notification = createPolicyResource(aws.s3.BucketNotification, {
+            bucket: "test-bucket",
+            lambdaFunctions: [{ events: ["s3:ObjectCreated:*"] }],
+        }, "test-notification", { lambdaFunctions: [fn] });
+        await assertNoStackViolations(policy, createStackValidationArgsWithResources([fn, notification]));
+
+        fn.props.deadLetterConfig = undefined;
+        const invokeConfig = createPolicyResource(aws.lambda.FunctionEventInvokeConfig, {
+            destinationConfig: { onFailure: { destination: "arn:aws:sqs:us-west-2:123456789012:dlq" } },
+        }, "test-invoke-config", { functionName: [fn] });
+        await assertNoStackViolations(policy, createStackValidationArgsWithResources([fn, invokeConfig, notification]));
+    });
+
+    it("Should fail if an SQS queue target has no dead-letter queue", async () => {
+        const queue = createPolicyResource(aws.sqs.Queue, { arn: "arn:aws:sqs:us-west-2:123456789012:events" }, "test-queue");
+        const notification = createPolicyResource(aws.s3.BucketNotification, {
+            bucket: "test-bucket",
+            queues: [{ queueArn: "arn:aws:sqs:us-west-2:123456789012:events", events: ["s3:ObjectCreated:*"] }],
+        }, "test-notification");
+        await assertHasStackViolation(policy, createStackValidationArgsWithResources([queue, notification]), {
+            message: "Bucket notification 'test-notification' sends events to SQS queue 'test-queue', which has no dead-letter queue.",
+        });
+
+        queue.props.redrivePolicy = JSON.stringify({ deadLetterTargetArn: "arn:aws:sqs:us-west-2:123456789012:dlq", maxReceiveCount: 3 });
+        await assertNoStackViolations(policy, createStackValidationArgsWithResources([queue, notification]));
+    });
+
+    it("Should fail if a subscription to an SNS topic target has no dead-letter queue", async () => {
+        const topic = createPolicyResource(aws.sns.Topic, {}, "test-topic");
+        const subscription = createPolicyResource(aws.sns.TopicSubscription, {
+            protocol: "sqs",
+            endpoint: "arn:aws:sqs:us-west-2:123456789012:events",
+        }, "test-subscription", { topic: [topic] });
+        const notification = createPolicyResource(aws.s3.BucketNotification, {
+            bucket: "test-bucket",
+            topics: [{ events: ["s3:ObjectCreated:*"] }],
+        }, "test-notification", { topics: [topic] });
+
+        await assertHasStackViolation(policy, createStackValidationArgsWithResources([topic, subscription, notification]), {
+            message: "Bucket notification 'test-notification' sends events to SNS topic 'test-topic', " +
+                "whose subscription 'test-subscription' has no dead-letter queue.",
+        });
+    });
+});
+
+describe("#s3IntelligentTiering", () => {
+    const policy = storage.s3IntelligentTiering;
+
+    it("Should ignore buckets without the scope tag", async () => {
+        const args = createStackValidationArgs(aws.s3.Bucket, { tags: { "long-lived": "false" } });
+        await assertNoStackViolations(policy, args);
+    });
+
+    it("Should fail if a long-lived bucket has no intelligent tiering or transitions", async () => {
+        const bucket = createPolicyResource(aws.s3.BucketV2, { tags: { "long-lived": "true" } }, "test-bucket");
+        const expiration = createPolicyResource(aws.s3.BucketLifecycleConfigurationV2, {
+            rules: [{ id: "expire", status: "Enabled", expiration: { days: 365 } }],
+        }, "test-lifecycle", { bucket: [bucket] });
+
+        await assertHasStackViolation(policy, createStackValidationArgsWithResources([bucket, expiration]), {
+            message: "S3 bucket 'test-bucket' holds long-lived data and should have an intelligent-tiering " +
+                "configuration or a lifecycle rule that transitions objects to cheaper storage classes.",
+        });
+    });
+
+    it("Should use the configured scope tag", async () => {
+        const args = createStackValidationArgsWithResources([
+            createPolicyResource(aws.s3.Bucket, { tags: { size: "large" } }, "test-bucket"),
+        ], { scopeTagKey: "size", scopeTagValue: "large" });
+        await assertHasStackViolation(policy, args, { message: "S3 bucket 'test-bucket' holds long-lived data" });
+    });
+
+    it("Should pass if the bucket has an intelligent-tiering configuration", async () => {
+        const bucket = createPolicyResource(aws.s3.BucketV2, { tags: { "long-lived": "true" } }, "test-bucket");
+        const tiering = createPolicyResource(aws.s3.BucketIntelligentTieringConfiguration, {
+            name: "archive",
+            tierings: [{ accessTier: "ARCHIVE_ACCESS", days: 90 }],
+        }, "test-tiering", { bucket: [bucket] });
+        await assertNoStackViolations(policy, createStackValidationArgsWithResources([bucket, tiering]));
+    });
+
+    it("Should pass if the bucket transitions objects to cheaper storage classes", async () => {
+        const args = createStackValidationArgs(aws.s3.Bucket, {
+            tags: { "long-lived": "true" },
+            lifecycleRules: [{ enabled: true, transitions: [{ days: 30, storageClass: "STANDARD_IA" }] }],
+        });
+        await assertNoStackViolations(policy, args);
+    });
+});
@@ -0,0 +1,125 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import * as assert from "assert";
+
+import "mocha";
+
+import * as aws from "@pulumi/aws";
+import { ResourceValidationPolicy, StackValidationPolicy } from "@pulumi/policy";
+
+import { withTimings } from "../awsGuard";
+import { createTimingRecorder, formatTimingSummary, logTimingSummary, PolicyTiming, withTiming } from "../timing";
+
+import { createResourceValidationArgs, createStackValidationArgsWithResources } from "./util";
+
+describe("#withTiming", () => {
+    const resourcePolicy: ResourceValidationPolicy = {
+        name: "test-resource-policy",
+        description: "Test policy.",
+        validateResource: () => undefined,
+    };
+    const stackPolicy: StackValidationPolicy = {
+        name: "test-stack-policy",
+        description: "Test policy.",
+        validateStack: () => undefined,
+    };
+
+    it("records timings and logs a summary once every stack validation has finished", async () => {
+        const logged: string[] = [];
+        const recorder = createTimingRecorder(1, message => logged.push(message));
+        const timedResourcePolicy = <ResourceValidationPolicy>withTiming(resourcePolicy, recorder);
+        const timedStackPolicy = <StackValidationPolicy>withTiming(stackPolicy, recorder);
+
+        const validateResource = Array.isArray(timedResourcePolicy.validateResource)
+            ? timedResourcePolicy.validateResource[0] : timedResourcePolicy.validateResource;
+        await validateResource(createResourceValidationArgs(aws.s3.Bucket, {}), () => undefined);
+        await validateResource(createResourceValidationArgs(aws.s3.Bucket, {}), () => undefined);
+        const timing = <PolicyTiming>recorder.timings.get("test-resource-policy");
+        assert.strictEqual(timing.resources, 2);
+        assert.strictEqual(logged.length, 0);
+
+        await timedStackPolicy.validateStack(createStackValidationArgsWithResources([]), () => undefined);
+        assert.strictEqual(logged.length, 1);
+        assert.ok(logged[0].startsWith("awsguard timing: 2 policies took "));
+        assert.ok(logged[0].includes("test-resource-policy: "));
+        assert.ok(logged[0].includes("(2 resource(s))"));
+        assert.ok(logged[0].includes("test-stack-policy: "));
+
+        // The next analysis starts afresh.
+        assert.strictEqual(recorder.timings.size, 0);
+        assert.strictEqual(recorder.remainingStackValidations, 1);
+    });
+
+    it("records timings of validations that throw", async () => {
+        const recorder = createTimingRecorder(1, () => undefined);
+        const policy = <ResourceValidationPolicy>withTiming({
+            ...resourcePolicy,
+            validateResource: () => { throw new Error("Failed."); },
+        }, recorder);
+
+        const validateResource = Array.isArray(policy.validateResource) ? policy.validateResource[0] : policy.validateResource;
+        await assert.rejects(async () => validateResource(createResourceValidationArgs(aws.s3.Bucket, {}), () => undefined));
+        assert.strictEqual((<PolicyTiming>recorder.timings.get("test-resource-policy")).resources, 1);
+    });
+});
+
+describe("#logTimingSummary", () => {
+    it("logs the timings recorded so far, if any", () => {
+        const logged: string[] = [];
+        const recorder = createTimingRecorder(0, message => logged.push(message));
+        logTimingSummary(recorder);
+        assert.strictEqual(logged.length, 0);
+
+        recorder.timings.set("test-resource-policy", { totalMs: 5, resources: 1, stacks: 0 });
+        logTimingSummary(recorder);
+        assert.deepStrictEqual(logged, [
+            "awsguard timing: 1 policies took 5ms in total\n  test-resource-policy: 5ms (1 resource(s))",
+        ]);
+        assert.strictEqual(recorder.timings.size, 0);
+    });
+});
+
+describe("#withTimings", () => {
+    it("logs the summary on exit if no stack policies run", () => {
+        const resourcePolicy: ResourceValidationPolicy = {
+            name: "test-resource-policy",
+            description: "Test policy.",
+            validateResource: () => undefined,
+        };
+        const listeners = process.listeners("beforeExit");
+        try {
+            withTimings([resourcePolicy]);
+            assert.strictEqual(process.listeners("beforeExit").length, listeners.length + 1);
+        } finally {
+            process.listeners("beforeExit")
+                .filter(listener => !listeners.includes(listener))
+                .forEach(listener => process.removeListener("beforeExit", listener));
+        }
+    });
+});
+
+describe("#formatTimingSummary", () => {
+    it("lists the slowest policies first", () => {
+        const timings = new Map<string, PolicyTiming>([
+            ["encrypted-volumes", { totalMs: 15.2, resources: 12, stacks: 0 }],
+            ["access-keys-rotated", { totalMs: 100, resources: 0, stacks: 1 }],
+        ]);
+        assert.strictEqual(formatTimingSummary(timings), [
+            "awsguard timing: 2 policies took 115ms in total",
+            "  access-keys-rotated: 100ms (1 stack(s))",
+            "  encrypted-volumes: 15ms (12 resource(s))",
+        ].join("\n"));
+    });
+});
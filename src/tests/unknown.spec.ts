@@ -0,0 +1,96 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+
+import * as assert from "assert";
+
+import "mocha";
+
+import * as aws from "@pulumi/aws";
+import { ResourceValidationArgs, ResourceValidationPolicy } from "@pulumi/policy";
+
+import * as storage from "../storage";
+import { isUnknownValueError, UnknownValueBehavior, withUnknownValueHandling } from "../unknown";
+
+import { createResourceValidationArgs } from "./util";
+
+// Mirrors the error @pulumi/policy raises when a policy reads a value that isn't known during a preview.
+class UnknownValueError extends Error {
+    constructor(message: string) {
+        super(message);
+        Object.setPrototypeOf(this, UnknownValueError.prototype);
+    }
+}
+
+// Returns args for an EFS file system whose KMS key is computed from a key created in the same update.
+function getComputedKeyArgs(): ResourceValidationArgs {
+    const args = createResourceValidationArgs(aws.efs.FileSystem, {});
+    Object.defineProperty(args.props, "kmsKeyId", {
+        get: () => { throw new UnknownValueError("string value at .kmsKeyId can't be known during preview"); },
+    });
+    return args;
+}
+
+describe("#withUnknownValueHandling", () => {
+    async function validate(behavior: UnknownValueBehavior, args: ResourceValidationArgs): Promise<[string[], string[]]> {
+        const warnings: string[] = [];
+        const violations: string[] = [];
+        const wrapped = <ResourceValidationPolicy>withUnknownValueHandling(
+            storage.efsEncrypted, behavior, message => warnings.push(message));
+        for (const validation of Array.isArray(wrapped.validateResource) ? wrapped.validateResource : [wrapped.validateResource]) {
+            await validation(args, message => violations.push(message));
+        }
+        return [warnings, violations];
+    }
+
+    it("skips resources with unknown values", async () => {
+        assert.deepStrictEqual(await validate("skip", getComputedKeyArgs()), [[], []]);
+    });
+
+    it("warns about resources with unknown values", async () => {
+        const [warnings, violations] = await validate("warn", getComputedKeyArgs());
+        assert.deepStrictEqual(warnings, [
+            "warning: skipping efs-encrypted for unknown: string value at .kmsKeyId can't be known during preview",
+        ]);
+        assert.deepStrictEqual(violations, []);
+    });
+
+    it("reports a violation for resources with unknown values", async () => {
+        const [warnings, violations] = await validate("fail", getComputedKeyArgs());
+        assert.deepStrictEqual(warnings, []);
+        assert.deepStrictEqual(violations, [
+            "efs-encrypted can't be checked during the preview: string value at .kmsKeyId can't be known during preview",
+        ]);
+    });
+
+    it("checks resources with known values as usual", async () => {
+        const args = createResourceValidationArgs(aws.efs.FileSystem, {});
+        const [, violations] = await validate("skip", args);
+        assert.deepStrictEqual(violations, ["Amazon Elastic File System must have a KMS Key defined."]);
+    });
+
+    it("raises other errors", async () => {
+        const args = createResourceValidationArgs(aws.efs.FileSystem, {});
+        Object.defineProperty(args.props, "kmsKeyId", { get: () => { throw new Error("boom"); } });
+        await assert.rejects(validate("skip", args), /boom/);
+    });
+});
+
+describe("#isUnknownValueError", () => {
+    it("recognizes unknown value errors", () => {
+        assert.strictEqual(isUnknownValueError(new UnknownValueError("value at .ami can't be known during preview")), true);
+        assert.strictEqual(isUnknownValueError(new Error("boom")), false);
+        assert.strictEqual(isUnknownValueError(undefined), false);
+    });
+});
@@ -0,0 +1,151 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import * as assert from "assert";
+
+import "mocha";
+
+import {
+    allowsPublicAccess,
+    cidrContains,
+    describePolicyStatement,
+    getPolicyStatements,
+    matchesAnyPattern,
+    matchesPattern,
+} from "../util";
+
+describe("#cidrContains", () => {
+    it("checks IPv4 containment", () => {
+        assert.strictEqual(cidrContains("10.0.0.0/8", "10.1.2.0/24"), true);
+        assert.strictEqual(cidrContains("10.0.0.0/8", "10.0.0.0/8"), true);
+        assert.strictEqual(cidrContains("10.0.0.0/8", "10.255.255.255"), true);
+        assert.strictEqual(cidrContains("0.0.0.0/0", "192.168.1.1/32"), true);
+        assert.strictEqual(cidrContains("10.0.0.0/8", "11.0.0.0/24"), false);
+        assert.strictEqual(cidrContains("10.1.2.0/24", "10.0.0.0/8"), false);
+        assert.strictEqual(cidrContains("172.16.0.0/12", "172.32.0.0/16"), false);
+    });
+
+    it("checks IPv6 containment", () => {
+        assert.strictEqual(cidrContains("::/0", "2001:db8::/32"), true);
+        assert.strictEqual(cidrContains("2001:db8::/32", "2001:db8:1234::/48"), true);
+        assert.strictEqual(cidrContains("2001:db8::/32", "2001:db9::/48"), false);
+        assert.strictEqual(cidrContains("2001:db8::/48", "::/0"), false);
+    });
+
+    it("never matches across address families or invalid blocks", () => {
+        assert.strictEqual(cidrContains("0.0.0.0/0", "::/0"), false);
+        assert.strictEqual(cidrContains("::/0", "0.0.0.0/0"), false);
+        assert.strictEqual(cidrContains("10.0.0.0/8", "not-a-cidr"), false);
+        assert.strictEqual(cidrContains("10.0.0.0/33", "10.0.0.0/8"), false);
+        assert.strictEqual(cidrContains("256.0.0.0/8", "10.0.0.0/8"), false);
+    });
+});
+
+describe("#getPolicyStatements", () => {
+    it("parses statements from JSON strings and objects", () => {
+        const statement = { Effect: "Allow", Principal: "*", Action: "sns:Publish" };
+        assert.deepStrictEqual(getPolicyStatements(JSON.stringify({ Statement: [statement] })), [statement]);
+        assert.deepStrictEqual(getPolicyStatements({ Statement: statement }), [statement]);
+    });
+
+    it("returns no statements for missing or invalid documents", () => {
+        assert.deepStrictEqual(getPolicyStatements(undefined), []);
+        assert.deepStrictEqual(getPolicyStatements("{ not json"), []);
+        assert.deepStrictEqual(getPolicyStatements(JSON.stringify({ Version: "2012-10-17" })), []);
+    });
+});
+
+describe("#allowsPublicAccess", () => {
+    it("matches statements that allow any principal to perform the actions", () => {
+        assert.strictEqual(allowsPublicAccess({ Effect: "Allow", Principal: "*", Action: "sns:Publish" }, ["sns:Publish"]), true);
+        assert.strictEqual(allowsPublicAccess({ Effect: "Allow", Principal: { AWS: ["*"] }, Action: "SNS:*" }, ["sns:Subscribe"]), true);
+        assert.strictEqual(allowsPublicAccess({ Effect: "Allow", Principal: "*", NotAction: "sns:Publish" }, ["sns:Subscribe"]), true);
+    });
+
+    it("does not match restricted statements", () => {
+        assert.strictEqual(allowsPublicAccess({ Effect: "Deny", Principal: "*", Action: "sns:Publish" }, ["sns:Publish"]), false);
+        assert.strictEqual(allowsPublicAccess({
+            Effect: "Allow", Principal: "*", Action: "sns:Publish",
+            Condition: { StringEquals: { "aws:SourceAccount": "123456789012" } },
+        }, ["sns:Publish"]), false);
+        assert.strictEqual(allowsPublicAccess({
+            Effect: "Allow", Principal: { AWS: "arn:aws:iam::123456789012:root" }, Action: "sns:Publish",
+        }, ["sns:Publish"]), false);
+        assert.strictEqual(allowsPublicAccess({ Effect: "Allow", Principal: "*", Action: "sns:GetTopicAttributes" }, ["sns:Publish"]), false);
+        assert.strictEqual(allowsPublicAccess({ Effect: "Allow", Principal: "*", NotAction: "sns:*" }, ["sns:Publish"]), false);
+    });
+});
+
+describe("#describePolicyStatement", () => {
+    it("refers to statements by Sid, or else by position", () => {
+        assert.strictEqual(describePolicyStatement({ Sid: "AllowPublish" }, 0), "'AllowPublish'");
+        assert.strictEqual(describePolicyStatement({}, 2), "3");
+    });
+});
+
+describe("#matchesPattern", () => {
+    it("matches exact values", () => {
+        assert.strictEqual(matchesPattern("bastion", "bastion"), true);
+        assert.strictEqual(matchesPattern("bastion-2", "bastion"), false);
+        assert.strictEqual(matchesPattern("Bastion", "bastion"), false);
+    });
+
+    it("matches globs", () => {
+        assert.strictEqual(matchesPattern("jump-1", "jump-*"), true);
+        assert.strictEqual(matchesPattern("jump-", "jump-*"), true);
+        assert.strictEqual(matchesPattern("prod-jump-1", "*-jump-*"), true);
+        assert.strictEqual(matchesPattern("prod-jump-1", "jump-*"), false);
+        // Characters other than `*` are literal, not regular expression syntax.
+        assert.strictEqual(matchesPattern("logs.example.com", "*.example.com"), true);
+        assert.strictEqual(matchesPattern("logs-example-com", "*.example.com"), false);
+        assert.strictEqual(matchesPattern("app(1)", "app(*)"), true);
+    });
+
+    it("matches regular expressions between slashes", () => {
+        assert.strictEqual(matchesPattern("break-glass-12", "/^break-glass-\\d+$/"), true);
+        assert.strictEqual(matchesPattern("break-glass-x", "/^break-glass-\\d+$/"), false);
+        // Unanchored expressions match anywhere in the value.
+        assert.strictEqual(matchesPattern("prod-nat-1", "/nat/"), true);
+        assert.strictEqual(matchesPattern("ubuntu/images/hvm-ssd/ubuntu-jammy-22.04-amd64", "/^ubuntu\\/images\\//"), true);
+    });
+
+    it("treats escaped characters literally", () => {
+        assert.strictEqual(matchesPattern("a*b", "a\\*b"), true);
+        assert.strictEqual(matchesPattern("axb", "a\\*b"), false);
+        assert.strictEqual(matchesPattern("/nat/", "\\/nat/"), true);
+        assert.strictEqual(matchesPattern("prod-nat-1", "\\/nat/"), false);
+        assert.strictEqual(matchesPattern("a\\b", "a\\\\b"), true);
+        // A lone slash, or one at just one end, isn't a regular expression.
+        assert.strictEqual(matchesPattern("/", "/"), true);
+        assert.strictEqual(matchesPattern("/tmp", "/tmp"), true);
+    });
+
+    it("throws on invalid regular expressions", () => {
+        assert.throws(() => matchesPattern("nat", "/[/"), /'\/\[\/' is not a valid regular expression/);
+    });
+});
+
+describe("#matchesAnyPattern", () => {
+    it("matches if any value matches any pattern", () => {
+        assert.strictEqual(matchesAnyPattern("bastion", ["jump-*", "bastion"]), true);
+        assert.strictEqual(matchesAnyPattern(["my-bucket", "my-bucket-1a2b3c"], ["/-[0-9a-f]{6}$/"]), true);
+        assert.strictEqual(matchesAnyPattern(["my-bucket", "my-bucket-1a2b3c"], ["other-*"]), false);
+    });
+
+    it("never matches undefined values or patterns", () => {
+        assert.strictEqual(matchesAnyPattern([undefined], ["*"]), false);
+        assert.strictEqual(matchesAnyPattern("bastion", undefined), false);
+        assert.strictEqual(matchesAnyPattern("bastion", []), false);
+    });
+});
@@ -51,7 +51,7 @@ export function createResourceValidationArgs<TResource extends Resource, TArgs>(
         urn: "unknown",
         name: "unknown",
         opts: empytOptions,
-        isType: (cls) => isTypeOf(type, resourceClass),
+        isType: (cls) => isTypeOf(type, cls),
         asType: (cls) => isTypeOf(type, cls) ? <any>args : undefined,
         getConfig: <T>() => <T>(config || {}),
     };
@@ -62,32 +62,51 @@ export interface PolicyViolation {
     urn?: string;
 }
 
-// createStackValidationArgs will create a StackValidationArgs, simulating a stack that has a
-// single resource with the provided type and properties.
-export function createStackValidationArgs<TResource extends Resource, TArgs>(
+// createPolicyResource will create a PolicyResource using the `type` from the specified
+// `resourceClass`, for use in simulating stacks with multiple resources.
+export function createPolicyResource<TResource extends Resource, TArgs>(
     resourceClass: { new(name: string, args: TArgs, ...rest: any[]): TResource },
     props: any,
-    config?: Record<string, any>,
-): policy.StackValidationArgs {
+    name?: string,
+    propertyDependencies?: Record<string, policy.PolicyResource[]>,
+): policy.PolicyResource {
     const type = (<any>resourceClass).__pulumiType;
     if (typeof type !== "string") {
         assert.fail("Could not determine Pulumi type from resourceClass.");
     }
 
-    const testResource: policy.PolicyResource = {
+    const resourceName = name || "unknown";
+    return {
         type: type as string,
         props: props,
-        urn: "unknown",
-        name: "unknown",
+        urn: name ? `urn:pulumi:test::test::${type}::${resourceName}` : "unknown",
+        name: resourceName,
         opts: empytOptions,
         dependencies: [],
-        propertyDependencies: {},
+        propertyDependencies: propertyDependencies || {},
         isType: (cls) => isTypeOf(type, cls),
         asType: (cls) => isTypeOf(type, cls) ? props : undefined,
     };
+}
 
+// createStackValidationArgs will create a StackValidationArgs, simulating a stack that has a
+// single resource with the provided type and properties.
+export function createStackValidationArgs<TResource extends Resource, TArgs>(
+    resourceClass: { new(name: string, args: TArgs, ...rest: any[]): TResource },
+    props: any,
+    config?: Record<string, any>,
+): policy.StackValidationArgs {
+    return createStackValidationArgsWithResources([createPolicyResource(resourceClass, props)], config);
+}
+
+// createStackValidationArgsWithResources will create a StackValidationArgs, simulating a stack
+// that contains the provided resources.
+export function createStackValidationArgsWithResources(
+    resources: policy.PolicyResource[],
+    config?: Record<string, any>,
+): policy.StackValidationArgs {
     return {
-        resources: [testResource],
+        resources: resources,
         getConfig: <T>() => <T>(config || {}),
     } as policy.StackValidationArgs;
 }
@@ -0,0 +1,131 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import { Policy, wrapValidations } from "./dispatch";
+
+/**
+ * The environment variable used to enable timing. When set, the pack records how long each policy
+ * takes and how many resources it checks, and logs a summary once the stack has been analyzed.
+ */
+export const timingEnvVar = "AWSGUARD_TIMING";
+
+/** @internal */
+export interface PolicyTiming {
+    /** The total time spent in the policy's validations, in milliseconds. */
+    totalMs: number;
+    /** The number of resources the policy's resource validations were called for. */
+    resources: number;
+    /** The number of times the policy's stack validation was called. */
+    stacks: number;
+}
+
+/**
+ * Collects the timings of the policies it's given to. Stack validations run once all resources have
+ * been analyzed, so the summary is logged when the last of them finishes.
+ * @internal
+ */
+export interface TimingRecorder {
+    timings: Map<string, PolicyTiming>;
+    stackValidations: number;
+    remainingStackValidations: number;
+    log: (message: string) => void;
+}
+
+/**
+ * Returns a recorder that logs its summary with `log` once `stackValidations` stack validations have finished.
+ * @internal
+ */
+export function createTimingRecorder(stackValidations: number, log: (message: string) => void = console.error): TimingRecorder {
+    return { timings: new Map(), stackValidations, remainingStackValidations: stackValidations, log };
+}
+
+function elapsedMs(start: [number, number]): number {
+    const [seconds, nanoseconds] = process.hrtime(start);
+    return seconds * 1000 + nanoseconds / 1e6;
+}
+
+function record(recorder: TimingRecorder, policyName: string, ms: number, resources: number, stacks: number) {
+    const timing = recorder.timings.get(policyName) || { totalMs: 0, resources: 0, stacks: 0 };
+    timing.totalMs += ms;
+    timing.resources += resources;
+    timing.stacks += stacks;
+    recorder.timings.set(policyName, timing);
+}
+
+/**
+ * Logs the summary of the timings recorded so far, if any, and starts afresh.
+ * @internal
+ */
+export function logTimingSummary(recorder: TimingRecorder): void {
+    if (recorder.timings.size > 0) {
+        recorder.log(formatTimingSummary(recorder.timings));
+    }
+    recorder.timings.clear();
+    recorder.remainingStackValidations = recorder.stackValidations;
+}
+
+/**
+ * Returns a copy of the policy that records how long its validations take with `recorder`. Only
+ * policies that are given to this are measured, so there's no overhead when timing is disabled.
+ * @internal
+ */
+export function withTiming(policy: Policy, recorder: TimingRecorder): Policy {
+    return wrapValidations(policy,
+        validation => async (args, reportViolation) => {
+            const start = process.hrtime();
+            try {
+                await validation(args, reportViolation);
+            } finally {
+                record(recorder, policy.name, elapsedMs(start), 1, 0);
+            }
+        },
+        validation => async (args, reportViolation) => {
+            const start = process.hrtime();
+            try {
+                await validation(args, reportViolation);
+            } finally {
+                record(recorder, policy.name, elapsedMs(start), 0, 1);
+                if (--recorder.remainingStackValidations === 0) {
+                    logTimingSummary(recorder);
+                }
+            }
+        },
+    );
+}
+
+/**
+ * Returns a summary of the timings, slowest policy first, e.g.:
+ *
+ *     awsguard timing: 2 policies took 115ms in total
+ *       access-keys-rotated: 100ms (1 stack(s))
+ *       encrypted-volumes: 15ms (12 resource(s))
+ *
+ * @internal
+ */
+export function formatTimingSummary(timings: Map<string, PolicyTiming>): string {
+    const entries: Array<[string, PolicyTiming]> = [];
+    timings.forEach((timing, name) => entries.push([name, timing]));
+    entries.sort((a, b) => b[1].totalMs - a[1].totalMs);
+
+    const totalMs = entries.reduce((total, [, timing]) => total + timing.totalMs, 0);
+    const lines = [`awsguard timing: ${entries.length} policies took ${Math.round(totalMs)}ms in total`];
+    for (const [name, timing] of entries) {
+        const counts = [
+            timing.resources > 0 ? `${timing.resources} resource(s)` : undefined,
+            timing.stacks > 0 ? `${timing.stacks} stack(s)` : undefined,
+        ].filter(count => count !== undefined).join(", ");
+        lines.push(`  ${name}: ${Math.round(timing.totalMs)}ms (${counts})`);
+    }
+    return lines.join("\n");
+}
@@ -0,0 +1,81 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+
+import { Policy, wrapValidations } from "./dispatch";
+
+/**
+ * How policies behave when a value they check isn't known yet during a preview, e.g. a KMS key ID
+ * computed from a key created in the same update:
+ * - "skip": The policy silently skips the resource.
+ * - "warn": A warning is logged and the policy skips the resource.
+ * - "fail": A violation is reported, since the policy can't confirm the resource is compliant.
+ *
+ * If unset, Pulumi reports an advisory note that the policy can't be run during the preview.
+ */
+export type UnknownValueBehavior = "skip" | "warn" | "fail";
+
+/**
+ * Returns true if the error was raised by @pulumi/policy because a policy read a value that isn't
+ * known during a preview.
+ * @internal
+ */
+export function isUnknownValueError(err: any): boolean {
+    if (!err) {
+        return false;
+    }
+    return (err.constructor && err.constructor.name === "UnknownValueError") ||
+        (typeof err.message === "string" && err.message.includes("can't be known during preview"));
+}
+
+/**
+ * Returns a copy of the policy that handles unknown values according to `behavior`. Other errors
+ * are raised as usual.
+ * @internal
+ */
+export function withUnknownValueHandling(
+    policy: Policy, behavior: UnknownValueBehavior, warn: (message: string) => void = console.warn): Policy {
+
+    const handle = (err: any, reportViolation: (message: string) => void, subject: string) => {
+        if (!isUnknownValueError(err)) {
+            throw err;
+        }
+        switch (behavior) {
+            case "skip":
+                return;
+            case "warn":
+                warn(`warning: skipping ${policy.name} for ${subject}: ${err.message}`);
+                return;
+            default:
+                reportViolation(`${policy.name} can't be checked during the preview: ${err.message}`);
+        }
+    };
+
+    return wrapValidations(policy,
+        validation => async (args, reportViolation) => {
+            try {
+                await validation(args, reportViolation);
+            } catch (err) {
+                handle(err, message => reportViolation(message), args.urn);
+            }
+        },
+        validation => async (args, reportViolation) => {
+            try {
+                await validation(args, reportViolation);
+            } catch (err) {
+                handle(err, message => reportViolation(message), "the stack");
+            }
+        },
+    );
+}
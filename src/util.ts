@@ -0,0 +1,294 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import { PolicyResource } from "@pulumi/policy";
+
+/**
+ * Returns true if `source` refers to `target` via the input property `property`. A reference is
+ * either a dependency the engine recorded for that property (which is all we have during a preview,
+ * when IDs are unknown), or a literal value matching one of `target`'s identifying properties.
+ * @internal
+ */
+export function isReferencedBy(
+    target: PolicyResource, source: PolicyResource, property: string, targetIdProperties: string[] = ["id"]): boolean {
+
+    const dependencies = (source.propertyDependencies || {})[property] || [];
+    if (dependencies.some(dep => dep.urn === target.urn)) {
+        return true;
+    }
+
+    const value = source.props[property];
+    if (value === undefined || value === null) {
+        return false;
+    }
+    return targetIdProperties.some(idProperty => {
+        const id = target.props[idProperty];
+        return id !== undefined && id !== null && id === value;
+    });
+}
+
+/**
+ * Returns true if `source` refers to `target` via a nested property of `property`, e.g. an ARN in a
+ * list of objects. The engine only records dependencies for top-level properties, so any dependency
+ * of `property` counts, as does a literal `value` (or any element of it, if it's an array) matching
+ * one of `target`'s identifying properties.
+ * @internal
+ */
+export function isReferencedByNested(
+    target: PolicyResource, source: PolicyResource, property: string, value: any, targetIdProperties: string[]): boolean {
+
+    const dependencies = (source.propertyDependencies || {})[property] || [];
+    if (dependencies.some(dep => dep.urn === target.urn)) {
+        return true;
+    }
+    const values = Array.isArray(value) ? value : [value];
+    return targetIdProperties.some(idProperty => {
+        const id = target.props[idProperty];
+        return id !== undefined && id !== null && values.includes(id);
+    });
+}
+
+/**
+ * Returns true if the resource's `tags` include `key`. If `value` is provided, the tag's value must
+ * also match it. Resources that don't support tags never match.
+ * @internal
+ */
+export function hasTag(props: Record<string, any>, key: string, value?: string): boolean {
+    const tags = props.tags;
+    if (!tags || typeof tags !== "object" || !(key in tags)) {
+        return false;
+    }
+    return value === undefined || tags[key] === value;
+}
+
+/**
+ * Returns true if `value` matches `pattern`, where `*` in the pattern matches any sequence of characters.
+ * @internal
+ */
+export function matchesGlob(value: string, pattern: string): boolean {
+    const escaped = pattern.split("*").map(part => part.replace(/[.+?^${}()|[\]\\]/g, "\\$&"));
+    return new RegExp(`^${escaped.join(".*")}$`).test(value);
+}
+
+const compiledPatterns = new Map<string, RegExp>();
+
+function escapeRegExp(text: string): string {
+    return text.replace(/[.*+?^${}()|[\]\\/]/g, "\\$&");
+}
+
+function compilePattern(pattern: string): RegExp {
+    let compiled = compiledPatterns.get(pattern);
+    if (compiled) {
+        return compiled;
+    }
+    if (pattern.length > 2 && pattern.startsWith("/") && pattern.endsWith("/")) {
+        try {
+            compiled = new RegExp(pattern.slice(1, -1));
+        } catch (err) {
+            throw new Error(`'${pattern}' is not a valid regular expression: ${err.message}`);
+        }
+    } else {
+        let source = "";
+        for (let i = 0; i < pattern.length; i++) {
+            if (pattern[i] === "\\" && i + 1 < pattern.length) {
+                source += escapeRegExp(pattern[++i]);
+            } else {
+                source += pattern[i] === "*" ? ".*" : escapeRegExp(pattern[i]);
+            }
+        }
+        compiled = new RegExp(`^${source}$`);
+    }
+    compiledPatterns.set(pattern, compiled);
+    return compiled;
+}
+
+/**
+ * Returns true if `value` matches `pattern`, which is one of:
+ *
+ * - an exact value, e.g. `build-server`;
+ * - a glob, where `*` matches any sequence of characters, e.g. `build-*`;
+ * - a regular expression between slashes, e.g. `/^build-\d+$/`. Like any regular expression, it
+ *   matches anywhere in the value unless it's anchored with `^` and `$`.
+ *
+ * A backslash makes the character after it literal, e.g. `\*` matches a `*` and `\/build/` matches
+ * the value `/build/` rather than being treated as a regular expression. This is the syntax of all
+ * of the pack's name-based allow-lists and exemptions.
+ * @internal
+ */
+export function matchesPattern(value: string, pattern: string): boolean {
+    if (!pattern.includes("*") && !pattern.includes("\\") && !pattern.startsWith("/")) {
+        return value === pattern;
+    }
+    return compilePattern(pattern).test(value);
+}
+
+/**
+ * Returns why `pattern` isn't a valid `matchesPattern` pattern, or undefined if it is. Only regular
+ * expressions can be invalid.
+ * @internal
+ */
+export function describeInvalidPattern(pattern: string): string | undefined {
+    try {
+        compilePattern(pattern);
+        return undefined;
+    } catch (err) {
+        return err.message;
+    }
+}
+
+/**
+ * Returns true if any of `values` matches any of `patterns` with `matchesPattern`. Values that are
+ * undefined, e.g. physical names that aren't known yet, never match.
+ * @internal
+ */
+export function matchesAnyPattern(values: string | Array<string | undefined>, patterns: string[] | undefined): boolean {
+    const candidates = (Array.isArray(values) ? values : [values]).filter((v): v is string => typeof v === "string");
+    return (patterns || []).some(pattern => candidates.some(value => matchesPattern(value, pattern)));
+}
+
+interface Cidr {
+    bytes: number[];
+    prefixLength: number;
+}
+
+function parseIpv4(address: string): number[] | undefined {
+    const parts = address.split(".");
+    if (parts.length !== 4 || !parts.every(part => /^\d{1,3}$/.test(part) && parseInt(part, 10) <= 255)) {
+        return undefined;
+    }
+    return parts.map(part => parseInt(part, 10));
+}
+
+function parseIpv6(address: string): number[] | undefined {
+    const halves = address.split("::");
+    if (halves.length > 2) {
+        return undefined;
+    }
+    const toGroups = (s: string) => s === "" ? [] : s.split(":");
+    const head = toGroups(halves[0]);
+    const tail = halves.length === 2 ? toGroups(halves[1]) : [];
+    const missing = 8 - head.length - tail.length;
+    if ((halves.length === 1 && missing !== 0) || missing < 0) {
+        return undefined;
+    }
+    const groups = [...head, ...Array<string>(halves.length === 2 ? missing : 0).fill("0"), ...tail];
+    if (!groups.every(group => /^[0-9a-fA-F]{1,4}$/.test(group))) {
+        return undefined;
+    }
+    const bytes: number[] = [];
+    for (const group of groups) {
+        const value = parseInt(group, 16);
+        bytes.push(value >> 8, value & 0xff);
+    }
+    return bytes;
+}
+
+function parseCidr(cidr: string): Cidr | undefined {
+    const [address, prefix] = cidr.trim().split("/");
+    const bytes = address.includes(":") ? parseIpv6(address) : parseIpv4(address);
+    if (!bytes) {
+        return undefined;
+    }
+    const maxPrefixLength = bytes.length * 8;
+    const prefixLength = prefix === undefined ? maxPrefixLength : parseInt(prefix, 10);
+    if (isNaN(prefixLength) || prefixLength < 0 || prefixLength > maxPrefixLength) {
+        return undefined;
+    }
+    return { bytes, prefixLength };
+}
+
+/**
+ * Returns true if every address in the CIDR block `inner` is also in the CIDR block `outer`, e.g.
+ * "10.0.0.0/8" contains "10.1.2.0/24". IPv4 and IPv6 blocks never contain each other. Invalid
+ * CIDR blocks never contain, nor are contained by, anything.
+ * @internal
+ */
+export function cidrContains(outer: string, inner: string): boolean {
+    const o = parseCidr(outer);
+    const i = parseCidr(inner);
+    if (!o || !i || o.bytes.length !== i.bytes.length || o.prefixLength > i.prefixLength) {
+        return false;
+    }
+    for (let bit = 0; bit < o.prefixLength; bit++) {
+        const mask = 0x80 >> (bit % 8);
+        const byte = Math.floor(bit / 8);
+        if ((o.bytes[byte] & mask) !== (i.bytes[byte] & mask)) {
+            return false;
+        }
+    }
+    return true;
+}
+
+function toArray(value: any): any[] {
+    if (value === undefined || value === null) {
+        return [];
+    }
+    return Array.isArray(value) ? value : [value];
+}
+
+/**
+ * Returns the statements of an IAM policy document, given either as a JSON string or an object.
+ * Returns an empty array if the document is missing or isn't valid JSON, e.g. because it isn't
+ * known yet during a preview.
+ * @internal
+ */
+export function getPolicyStatements(document: any): any[] {
+    let parsed = document;
+    if (typeof document === "string") {
+        try {
+            parsed = JSON.parse(document);
+        } catch (err) {
+            return [];
+        }
+    }
+    if (!parsed || typeof parsed !== "object") {
+        return [];
+    }
+    return toArray(parsed.Statement).filter(statement => statement && typeof statement === "object");
+}
+
+/**
+ * Returns how to refer to the policy statement at `index` in violation messages: by its Sid, if it
+ * has one, or otherwise by its 1-based position in the document.
+ * @internal
+ */
+export function describePolicyStatement(statement: any, index: number): string {
+    return typeof statement.Sid === "string" && statement.Sid ? `'${statement.Sid}'` : `${index + 1}`;
+}
+
+/**
+ * Returns true if the IAM policy statement allows any principal to perform one of `actions`,
+ * without a condition to restrict who or what the request comes from. Actions are matched
+ * case-insensitively, supporting `*` wildcards in the statement.
+ * @internal
+ */
+export function allowsPublicAccess(statement: any, actions: string[]): boolean {
+    if (statement.Effect !== "Allow" || statement.Condition) {
+        return false;
+    }
+
+    const principal = statement.Principal;
+    const isPublic = principal === "*" ||
+        (principal && typeof principal === "object" && toArray(principal.AWS).includes("*"));
+    if (!isPublic) {
+        return false;
+    }
+
+    const matchesAny = (action: string, patterns: any[]) => patterns.some(
+        pattern => typeof pattern === "string" && matchesGlob(action.toLowerCase(), pattern.toLowerCase()));
+    if (statement.NotAction !== undefined) {
+        return actions.some(action => !matchesAny(action, toArray(statement.NotAction)));
+    }
+    return actions.some(action => matchesAny(action, toArray(statement.Action)));
+}